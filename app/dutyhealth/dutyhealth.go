@@ -0,0 +1,261 @@
+// Copyright © 2022-2025 Obol Labs Inc. Licensed under the terms of a Business Source License 1.1
+
+// Package dutyhealth summarises the health of duty production from the state of the SSE listener
+// and the duty database.
+package dutyhealth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	eth2p0 "github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jonboulle/clockwork"
+
+	"github.com/obolnetwork/charon/app/sse"
+	"github.com/obolnetwork/charon/core/dutydb"
+)
+
+const (
+	defaultSSEUnhealthyAfter = time.Minute
+	defaultPendingDegraded   = 100
+	defaultPendingUnhealthy  = 1000
+
+	// Store staleness defaults in epochs. Small clusters only store attestation data once per epoch,
+	// at a slot varying per epoch, so consecutive stores can be up to two epochs apart.
+	storeDegradedEpochs  = 2
+	storeUnhealthyEpochs = 4
+	storeMarginSlots     = 2
+)
+
+// Listener is the subset of sse.Listener the summary depends on.
+type Listener interface {
+	Ready() (bool, error)
+	LastHead() (slot uint64, root eth2p0.Root, at time.Time, ok bool)
+}
+
+// DutyDB is the subset of dutydb.MemDB the summary depends on.
+type DutyDB interface {
+	PendingQueryCount() int
+	LastStore() (time.Time, bool)
+	Stats() dutydb.Stats
+}
+
+var (
+	_ Listener = sse.Listener(nil)
+	_ DutyDB   = (*dutydb.MemDB)(nil)
+)
+
+// Status is a health verdict, ordered from best to worst.
+type Status int
+
+const (
+	StatusHealthy Status = iota
+	StatusDegraded
+	StatusUnhealthy
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusHealthy:
+		return "healthy"
+	case StatusDegraded:
+		return "degraded"
+	case StatusUnhealthy:
+		return "unhealthy"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler, so statuses are serialised by name.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// SSEHealth is the health of the SSE event streams.
+type SSEHealth struct {
+	Status  Status        `json:"status"`
+	Ready   bool          `json:"ready"`              // True if a stream is connected and received a fresh head event, see sse.Listener.Ready.
+	Reason  string        `json:"reason,omitempty"`   // Why the streams aren't ready, empty if ready.
+	HeadAge time.Duration `json:"head_age,omitempty"` // Time since the last head event, zero if none was received.
+}
+
+// DutyDBHealth is the health of the duty database.
+type DutyDBHealth struct {
+	Status         Status        `json:"status"`
+	PendingQueries int           `json:"pending_queries"`     // Await queries blocked waiting for data.
+	StoreAge       time.Duration `json:"store_age,omitempty"` // Time since the last store, zero if nothing was stored yet.
+	Stored         dutydb.Stats  `json:"stored"`
+}
+
+// Summary is the overall health of duty production.
+type Summary struct {
+	Status  Status       `json:"status"` // The worst of the SSE and dutydb statuses.
+	SSE     SSEHealth    `json:"sse"`
+	DutyDB  DutyDBHealth `json:"dutydb"`
+	Reasons []string     `json:"reasons,omitempty"` // Why the status isn't healthy.
+}
+
+type options struct {
+	clock               clockwork.Clock
+	sseUnhealthyAfter   time.Duration
+	storeDegradedAfter  time.Duration
+	storeUnhealthyAfter time.Duration
+	pendingDegraded     int
+	pendingUnhealthy    int
+}
+
+// Option configures the health checker.
+type Option func(*options)
+
+// WithClock returns an option overriding the clock used to measure the age of head events and stores.
+func WithClock(clock clockwork.Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithSSEUnhealthyAfter returns an option overriding how long after the last head event SSE streams
+// that aren't ready are unhealthy rather than degraded. It defaults to one minute.
+func WithSSEUnhealthyAfter(d time.Duration) Option {
+	return func(o *options) {
+		o.sseUnhealthyAfter = d
+	}
+}
+
+// WithStoreThresholds returns an option overriding how long after the last store, or after startup if nothing
+// was stored yet, the duty database is degraded and unhealthy. They default to two and four epochs plus two slots.
+func WithStoreThresholds(degraded, unhealthy time.Duration) Option {
+	return func(o *options) {
+		o.storeDegradedAfter = degraded
+		o.storeUnhealthyAfter = unhealthy
+	}
+}
+
+// WithPendingThresholds returns an option overriding the number of pending await queries at which
+// the duty database is degraded and unhealthy. They default to 100 and 1000.
+func WithPendingThresholds(degraded, unhealthy int) Option {
+	return func(o *options) {
+		o.pendingDegraded = degraded
+		o.pendingUnhealthy = unhealthy
+	}
+}
+
+// New returns a new health checker summarising the SSE listener and duty database.
+// The slot duration and slots per epoch determine the default store staleness thresholds.
+func New(listener Listener, db DutyDB, slotDuration time.Duration, slotsPerEpoch uint64, opts ...Option) *Checker {
+	epoch := time.Duration(slotsPerEpoch) * slotDuration
+	margin := storeMarginSlots * slotDuration
+
+	o := options{
+		clock:               clockwork.NewRealClock(),
+		sseUnhealthyAfter:   defaultSSEUnhealthyAfter,
+		storeDegradedAfter:  storeDegradedEpochs*epoch + margin,
+		storeUnhealthyAfter: storeUnhealthyEpochs*epoch + margin,
+		pendingDegraded:     defaultPendingDegraded,
+		pendingUnhealthy:    defaultPendingUnhealthy,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Checker{
+		listener: listener,
+		db:       db,
+		opts:     o,
+		started:  o.clock.Now(),
+	}
+}
+
+// Checker summarises the health of duty production.
+type Checker struct {
+	listener Listener
+	db       DutyDB
+	opts     options
+	started  time.Time
+}
+
+// HealthSummary returns the current health of duty production:
+//   - SSE streams are healthy if ready, degraded if not ready but a head event was received within
+//     the SSE unhealthy threshold, and unhealthy otherwise.
+//   - The duty database is degraded or unhealthy if the number of pending queries reaches the respective
+//     threshold, or if the last store is older than the respective threshold. If nothing was stored yet,
+//     the time since startup is compared against the thresholds instead.
+func (c *Checker) HealthSummary() Summary {
+	var summary Summary
+	degrade := func(status Status, reason string) {
+		summary.Reasons = append(summary.Reasons, reason)
+		summary.Status = max(summary.Status, status)
+	}
+
+	now := c.opts.clock.Now()
+
+	// SSE health
+	ready, err := c.listener.Ready()
+	summary.SSE.Ready = ready
+	if _, _, at, ok := c.listener.LastHead(); ok {
+		summary.SSE.HeadAge = now.Sub(at)
+	}
+	if !ready {
+		summary.SSE.Reason = "not ready"
+		if err != nil {
+			summary.SSE.Reason = err.Error()
+		}
+
+		summary.SSE.Status = StatusUnhealthy
+		if summary.SSE.HeadAge > 0 && summary.SSE.HeadAge <= c.opts.sseUnhealthyAfter {
+			summary.SSE.Status = StatusDegraded
+		}
+
+		degrade(summary.SSE.Status, "sse: "+summary.SSE.Reason)
+	}
+
+	// Dutydb health
+	db := &summary.DutyDB
+	db.Stored = c.db.Stats()
+	db.PendingQueries = c.db.PendingQueryCount()
+
+	dbDegrade := func(status Status, reason string) {
+		db.Status = max(db.Status, status)
+		degrade(status, "dutydb: "+reason)
+	}
+
+	if db.PendingQueries >= c.opts.pendingUnhealthy {
+		dbDegrade(StatusUnhealthy, fmt.Sprintf("%d pending queries", db.PendingQueries))
+	} else if db.PendingQueries >= c.opts.pendingDegraded {
+		dbDegrade(StatusDegraded, fmt.Sprintf("%d pending queries", db.PendingQueries))
+	}
+
+	if at, ok := c.db.LastStore(); !ok {
+		if uptime := now.Sub(c.started); uptime > c.opts.storeUnhealthyAfter {
+			dbDegrade(StatusUnhealthy, "nothing stored in "+uptime.String())
+		} else if uptime > c.opts.storeDegradedAfter {
+			dbDegrade(StatusDegraded, "nothing stored in "+uptime.String())
+		}
+	} else {
+		db.StoreAge = now.Sub(at)
+		if db.StoreAge > c.opts.storeUnhealthyAfter {
+			dbDegrade(StatusUnhealthy, "last store "+db.StoreAge.String()+" ago")
+		} else if db.StoreAge > c.opts.storeDegradedAfter {
+			dbDegrade(StatusDegraded, "last store "+db.StoreAge.String()+" ago")
+		}
+	}
+
+	return summary
+}
+
+// ServeHTTP serves the health summary as JSON, responding with 503 Service Unavailable if unhealthy.
+func (c *Checker) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	summary := c.HealthSummary()
+
+	code := http.StatusOK
+	if summary.Status == StatusUnhealthy {
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(summary)
+}
@@ -0,0 +1,259 @@
+// Copyright © 2022-2025 Obol Labs Inc. Licensed under the terms of a Business Source License 1.1
+
+package dutyhealth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	eth2p0 "github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obolnetwork/charon/app/dutyhealth"
+	"github.com/obolnetwork/charon/app/errors"
+	"github.com/obolnetwork/charon/core/dutydb"
+)
+
+type fakeListener struct {
+	readyErr error
+	headAt   time.Time // Zero if no head event was received.
+}
+
+func (l fakeListener) Ready() (bool, error) {
+	return l.readyErr == nil, l.readyErr
+}
+
+func (l fakeListener) LastHead() (uint64, eth2p0.Root, time.Time, bool) {
+	return 1, eth2p0.Root{}, l.headAt, !l.headAt.IsZero()
+}
+
+type fakeDB struct {
+	pending int
+	storeAt time.Time // Zero if nothing was stored.
+}
+
+func (d fakeDB) PendingQueryCount() int {
+	return d.pending
+}
+
+func (d fakeDB) LastStore() (time.Time, bool) {
+	return d.storeAt, !d.storeAt.IsZero()
+}
+
+func (fakeDB) Stats() dutydb.Stats {
+	return dutydb.Stats{Attestations: 2}
+}
+
+const (
+	slotDuration  = 12 * time.Second
+	slotsPerEpoch = 32
+	epoch         = slotsPerEpoch * slotDuration
+)
+
+// newChecker returns a checker started the uptime before the clock's current time.
+func newChecker(clock *clockwork.FakeClock, uptime time.Duration, listener fakeListener, db fakeDB, opts ...dutyhealth.Option) *dutyhealth.Checker {
+	started := clockwork.NewFakeClockAt(clock.Now().Add(-uptime))
+	checker := dutyhealth.New(listener, db, slotDuration, slotsPerEpoch, append([]dutyhealth.Option{dutyhealth.WithClock(started)}, opts...)...)
+	started.Advance(uptime)
+
+	return checker
+}
+
+func TestHealthSummary(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	now := clock.Now()
+	ago := func(d time.Duration) time.Time {
+		return now.Add(-d)
+	}
+
+	readyListener := fakeListener{headAt: ago(time.Second)}
+	freshDB := fakeDB{pending: 3, storeAt: ago(time.Second)}
+
+	tests := []struct {
+		name      string
+		uptime    time.Duration // Defaults to an hour.
+		listener  fakeListener
+		db        fakeDB
+		status    dutyhealth.Status
+		sseStatus dutyhealth.Status
+		dbStatus  dutyhealth.Status
+		reasons   []string
+	}{
+		{
+			name:     "healthy",
+			listener: readyListener,
+			db:       freshDB,
+			status:   dutyhealth.StatusHealthy,
+		},
+		{
+			name:      "sse stale head",
+			listener:  fakeListener{readyErr: errors.New("stale SSE head events"), headAt: ago(30 * time.Second)},
+			db:        freshDB,
+			status:    dutyhealth.StatusDegraded,
+			sseStatus: dutyhealth.StatusDegraded,
+			reasons:   []string{"sse: stale SSE head events"},
+		},
+		{
+			name:      "sse head too old",
+			listener:  fakeListener{readyErr: errors.New("stale SSE head events"), headAt: ago(2 * time.Minute)},
+			db:        freshDB,
+			status:    dutyhealth.StatusUnhealthy,
+			sseStatus: dutyhealth.StatusUnhealthy,
+			reasons:   []string{"sse: stale SSE head events"},
+		},
+		{
+			name:      "sse disconnected",
+			listener:  fakeListener{readyErr: errors.New("no connected SSE streams")},
+			db:        freshDB,
+			status:    dutyhealth.StatusUnhealthy,
+			sseStatus: dutyhealth.StatusUnhealthy,
+			reasons:   []string{"sse: no connected SSE streams"},
+		},
+		{
+			name:     "dutydb nothing stored after startup",
+			uptime:   time.Minute,
+			listener: readyListener,
+			db:       fakeDB{},
+			status:   dutyhealth.StatusHealthy,
+		},
+		{
+			name:     "dutydb nothing stored",
+			uptime:   20 * time.Minute,
+			listener: readyListener,
+			db:       fakeDB{},
+			status:   dutyhealth.StatusDegraded,
+			dbStatus: dutyhealth.StatusDegraded,
+			reasons:  []string{"dutydb: nothing stored in 20m0s"},
+		},
+		{
+			name:     "dutydb store lagging",
+			listener: readyListener,
+			db:       fakeDB{storeAt: ago(20 * time.Minute)},
+			status:   dutyhealth.StatusDegraded,
+			dbStatus: dutyhealth.StatusDegraded,
+			reasons:  []string{"dutydb: last store 20m0s ago"},
+		},
+		{
+			name:     "dutydb store stale",
+			listener: readyListener,
+			db:       fakeDB{storeAt: ago(30 * time.Minute)},
+			status:   dutyhealth.StatusUnhealthy,
+			dbStatus: dutyhealth.StatusUnhealthy,
+			reasons:  []string{"dutydb: last store 30m0s ago"},
+		},
+		{
+			name:     "dutydb backlog",
+			listener: readyListener,
+			db:       fakeDB{pending: 100, storeAt: ago(time.Second)},
+			status:   dutyhealth.StatusDegraded,
+			dbStatus: dutyhealth.StatusDegraded,
+			reasons:  []string{"dutydb: 100 pending queries"},
+		},
+		{
+			name:     "dutydb backed up",
+			listener: readyListener,
+			db:       fakeDB{pending: 1000, storeAt: ago(20 * time.Minute)},
+			status:   dutyhealth.StatusUnhealthy,
+			dbStatus: dutyhealth.StatusUnhealthy,
+			reasons:  []string{"dutydb: 1000 pending queries", "dutydb: last store 20m0s ago"},
+		},
+		{
+			name:      "worst of both",
+			listener:  fakeListener{readyErr: errors.New("no connected SSE streams")},
+			db:        fakeDB{},
+			status:    dutyhealth.StatusUnhealthy,
+			sseStatus: dutyhealth.StatusUnhealthy,
+			dbStatus:  dutyhealth.StatusUnhealthy,
+			reasons:   []string{"sse: no connected SSE streams", "dutydb: nothing stored in 1h0m0s"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			uptime := test.uptime
+			if uptime == 0 {
+				uptime = time.Hour
+			}
+			checker := newChecker(clock, uptime, test.listener, test.db)
+
+			summary := checker.HealthSummary()
+			require.Equal(t, test.status, summary.Status)
+			require.Equal(t, test.sseStatus, summary.SSE.Status)
+			require.Equal(t, test.dbStatus, summary.DutyDB.Status)
+			require.Equal(t, test.reasons, summary.Reasons)
+			require.Equal(t, test.sseStatus == dutyhealth.StatusHealthy, summary.SSE.Ready)
+			require.Equal(t, test.db.pending, summary.DutyDB.PendingQueries)
+			require.Equal(t, dutydb.Stats{Attestations: 2}, summary.DutyDB.Stored)
+		})
+	}
+}
+
+func TestThresholdOptions(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	listener := fakeListener{readyErr: errors.New("stale SSE head events"), headAt: clock.Now().Add(-2 * time.Minute)}
+	db := fakeDB{pending: 10, storeAt: clock.Now().Add(-10 * time.Second)}
+
+	checker := newChecker(clock, time.Hour, listener, db)
+	require.Equal(t, dutyhealth.StatusUnhealthy, checker.HealthSummary().SSE.Status)
+	require.Equal(t, dutyhealth.StatusHealthy, checker.HealthSummary().DutyDB.Status)
+
+	checker = newChecker(clock, time.Hour, listener, db,
+		dutyhealth.WithSSEUnhealthyAfter(5*time.Minute),
+		dutyhealth.WithPendingThresholds(5, 50),
+		dutyhealth.WithStoreThresholds(time.Second, 5*time.Second),
+	)
+	summary := checker.HealthSummary()
+	require.Equal(t, dutyhealth.StatusDegraded, summary.SSE.Status)
+	require.Equal(t, dutyhealth.StatusUnhealthy, summary.DutyDB.Status)
+	require.Equal(t, []string{
+		"sse: stale SSE head events",
+		"dutydb: 10 pending queries",
+		"dutydb: last store 10s ago",
+	}, summary.Reasons)
+}
+
+func TestEpochStoreCadence(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+
+	// A small cluster stores attestation data once per epoch, at a slot varying per epoch.
+	for _, age := range []time.Duration{slotDuration, epoch, 2*epoch - slotDuration} {
+		db := fakeDB{storeAt: clock.Now().Add(-age)}
+		summary := newChecker(clock, time.Hour, fakeListener{headAt: clock.Now()}, db).HealthSummary()
+		require.Equal(t, dutyhealth.StatusHealthy, summary.Status, age)
+		require.Empty(t, summary.Reasons)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+
+	serve := func(listener fakeListener, db fakeDB) (int, map[string]any) {
+		rec := httptest.NewRecorder()
+		newChecker(clock, time.Hour, listener, db).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+		return rec.Code, body
+	}
+
+	ready := fakeListener{headAt: clock.Now()}
+
+	code, body := serve(ready, fakeDB{storeAt: clock.Now()})
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "healthy", body["status"])
+
+	// Degraded is still served as OK.
+	code, body = serve(ready, fakeDB{storeAt: clock.Now().Add(-20 * time.Minute)})
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "degraded", body["status"])
+
+	code, body = serve(fakeListener{readyErr: errors.New("no connected SSE streams")}, fakeDB{storeAt: clock.Now()})
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, "unhealthy", body["status"])
+	require.Equal(t, "unhealthy", body["sse"].(map[string]any)["status"])
+}
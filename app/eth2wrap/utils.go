@@ -4,11 +4,13 @@ package eth2wrap
 
 import (
 	"context"
+	"math"
 	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/attestantio/go-eth2-client/api"
 	eth2p0 "github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jonboulle/clockwork"
 
 	"github.com/obolnetwork/charon/app/errors"
 )
@@ -90,6 +92,77 @@ func FetchSlotsConfig(ctx context.Context, client eth2client.SpecProvider) (slot
 	return slotDuration, slotsPerEpoch, nil
 }
 
+// SlotTime returns the start time of the slot given the chain genesis time and slot duration.
+// Start times beyond the range of time.Duration since genesis, about 292 years, saturate at that bound.
+func SlotTime(genesis time.Time, slotDuration time.Duration, slot uint64) time.Time {
+	if slotDuration <= 0 {
+		return genesis
+	}
+
+	if slot > uint64(math.MaxInt64/slotDuration) {
+		return genesis.Add(math.MaxInt64)
+	}
+
+	return genesis.Add(time.Duration(slot) * slotDuration)
+}
+
+// SlotAt returns the slot at the provided time given the chain genesis time and slot duration, it is the inverse of SlotTime.
+// It returns zero before genesis or if the slot duration isn't positive.
+func SlotAt(genesis time.Time, slotDuration time.Duration, t time.Time) uint64 {
+	elapsed := t.Sub(genesis)
+	if elapsed < 0 || slotDuration <= 0 {
+		return 0
+	}
+
+	return uint64(elapsed / slotDuration)
+}
+
+// SlotClock provides the current time and the beacon chain's slot timing.
+// It allows tests to drive deterministic slot timing without real waits.
+type SlotClock interface {
+	clockwork.Clock
+	// Genesis returns the chain genesis time.
+	Genesis() time.Time
+	// SlotDuration returns the duration of a slot.
+	SlotDuration() time.Duration
+	// CurrentSlot returns the slot at the current time, or zero before genesis, see SlotAt.
+	CurrentSlot() uint64
+	// SlotStartTime returns the start time of the slot, see SlotTime.
+	SlotStartTime(slot uint64) time.Time
+}
+
+// NewSlotClock returns a slot clock using the provided clock for the current time.
+func NewSlotClock(clock clockwork.Clock, genesis time.Time, slotDuration time.Duration) SlotClock {
+	return slotClock{
+		Clock:        clock,
+		genesis:      genesis,
+		slotDuration: slotDuration,
+	}
+}
+
+type slotClock struct {
+	clockwork.Clock
+
+	genesis      time.Time
+	slotDuration time.Duration
+}
+
+func (c slotClock) Genesis() time.Time {
+	return c.genesis
+}
+
+func (c slotClock) SlotDuration() time.Duration {
+	return c.slotDuration
+}
+
+func (c slotClock) CurrentSlot() uint64 {
+	return SlotAt(c.genesis, c.slotDuration, c.Now())
+}
+
+func (c slotClock) SlotStartTime(slot uint64) time.Time {
+	return SlotTime(c.genesis, c.slotDuration, slot)
+}
+
 func FetchForkConfig(ctx context.Context, client eth2client.SpecProvider) (fork ForkForkSchedule, err error) {
 	spec, err := client.Spec(ctx, &api.SpecOpts{})
 	if err != nil {
@@ -4,9 +4,11 @@ package eth2wrap_test
 
 import (
 	"encoding/hex"
+	"math"
 	"testing"
 	"time"
 
+	"github.com/jonboulle/clockwork"
 	"github.com/stretchr/testify/require"
 
 	"github.com/obolnetwork/charon/app/eth2wrap"
@@ -67,3 +69,68 @@ func TestFetchForkConfig(t *testing.T) {
 	// Matching beaconmock/static.json
 	require.Equal(t, forkConfig, ffs)
 }
+
+func TestSlotTime(t *testing.T) {
+	genesis := time.Unix(1646092800, 0)
+	const slotDuration = 12 * time.Second
+
+	tests := []struct {
+		name string
+		slot uint64
+		time time.Time
+	}{
+		{name: "genesis", slot: 0, time: genesis},
+		{name: "first slot", slot: 1, time: genesis.Add(slotDuration)},
+		{name: "mainnet scale", slot: 10_000_000, time: genesis.Add(10_000_000 * slotDuration)},
+		{name: "largest representable", slot: math.MaxInt64 / uint64(slotDuration), time: genesis.Add(math.MaxInt64 / slotDuration * slotDuration)},
+		{name: "saturated", slot: math.MaxUint64, time: genesis.Add(math.MaxInt64)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.time, eth2wrap.SlotTime(genesis, slotDuration, test.slot))
+		})
+	}
+
+	require.Equal(t, genesis, eth2wrap.SlotTime(genesis, 0, 10))
+}
+
+func TestSlotAt(t *testing.T) {
+	genesis := time.Unix(1646092800, 0)
+	const slotDuration = 12 * time.Second
+
+	// Before genesis.
+	require.Zero(t, eth2wrap.SlotAt(genesis, slotDuration, genesis.Add(-time.Nanosecond)))
+	require.Zero(t, eth2wrap.SlotAt(genesis, slotDuration, genesis.Add(-slotDuration*10)))
+
+	// Genesis edge.
+	require.Zero(t, eth2wrap.SlotAt(genesis, slotDuration, genesis))
+	require.Zero(t, eth2wrap.SlotAt(genesis, slotDuration, genesis.Add(slotDuration-time.Nanosecond)))
+	require.EqualValues(t, 1, eth2wrap.SlotAt(genesis, slotDuration, genesis.Add(slotDuration)))
+
+	// Invalid slot duration.
+	require.Zero(t, eth2wrap.SlotAt(genesis, 0, genesis.Add(time.Hour)))
+
+	// SlotAt is the inverse of SlotTime.
+	for _, slot := range []uint64{0, 1, 2, 31, 32, 10_000_000, math.MaxInt64 / uint64(slotDuration)} {
+		start := eth2wrap.SlotTime(genesis, slotDuration, slot)
+		require.Equal(t, slot, eth2wrap.SlotAt(genesis, slotDuration, start))
+		require.Equal(t, slot, eth2wrap.SlotAt(genesis, slotDuration, start.Add(slotDuration-time.Nanosecond)))
+	}
+}
+
+func TestSlotClock(t *testing.T) {
+	genesis := time.Unix(1646092800, 0)
+	const slotDuration = 12 * time.Second
+
+	fakeClock := clockwork.NewFakeClockAt(genesis.Add(-time.Second))
+	clock := eth2wrap.NewSlotClock(fakeClock, genesis, slotDuration)
+	require.Equal(t, genesis, clock.Genesis())
+	require.Equal(t, slotDuration, clock.SlotDuration())
+	require.Zero(t, clock.CurrentSlot())
+
+	fakeClock.Advance(time.Second + 2*slotDuration)
+	require.EqualValues(t, 2, clock.CurrentSlot())
+	require.Equal(t, fakeClock.Now(), clock.Now())
+	require.Equal(t, fakeClock.Now(), clock.SlotStartTime(2))
+}
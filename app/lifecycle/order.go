@@ -29,6 +29,7 @@ const (
 	StartP2PEventCollector
 	StartPeerInfo
 	StartParSigDB
+	StartDutyDB
 	StartStackSnipe
 )
 
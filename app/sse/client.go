@@ -6,13 +6,21 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/hex"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	eth2p0 "github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jonboulle/clockwork"
+
 	"github.com/obolnetwork/charon/app/errors"
 	"github.com/obolnetwork/charon/app/expbackoff"
 	"github.com/obolnetwork/charon/app/log"
@@ -24,6 +32,23 @@ type event struct {
 	Event     string
 	Data      []byte
 	Timestamp time.Time
+
+	// Decoding result cached by decode.
+	decoded   bool
+	typed     Event
+	supported bool
+	decodeErr error
+}
+
+// decode returns the typed event, see decodeEvent. The result is cached, so events consumed by both the client
+// and the listener are only decoded once. It isn't thread safe, since events are processed sequentially.
+func (e *event) decode() (Event, bool, error) {
+	if !e.decoded {
+		e.typed, e.supported, e.decodeErr = decodeEvent(e)
+		e.decoded = true
+	}
+
+	return e.typed, e.supported, e.decodeErr
 }
 
 type (
@@ -32,81 +57,247 @@ type (
 
 type client struct {
 	addr       string
-	sseURL     *url.URL
+	sseURL     *url.URL // Includes the configured topics, built once so that reconnects subscribe to the same topics.
 	retry      time.Duration
 	httpClient *http.Client
 	headers    http.Header
+	clock      clockwork.Clock
+	userAgent  string
+	heads      *headDebouncer // Nil if head events aren't debounced.
+
+	defaultEvent string // Name of events without an event field.
+
+	maxReconnects int           // Consecutive failed connection attempts before stopping, zero retries forever.
+	established   int           // Number of connections responding 200 OK, used by start to reset failures.
+	idleTimeout   time.Duration // Zero disables the idle timeout.
+	resetIdle     func()        // Resets the idle timeout of the current connection, nil if disabled.
+
+	// Readiness state, accessed by Listener.Ready.
+	connected atomic.Bool
+	headMu    sync.RWMutex
+	head      headSnapshot // Last valid head event, zero if none.
 }
 
+// headSnapshot is the slot and block root of a head event and the time it was received.
+type headSnapshot struct {
+	slot uint64
+	root eth2p0.Root
+	at   time.Time
+}
+
+// retryAfterError is returned when the server is rate limiting or unavailable and requests
+// a delay via the Retry-After header before reconnecting.
+type retryAfterError struct {
+	statusCode int
+	delay      time.Duration
+}
+
+func (e retryAfterError) Error() string {
+	return "server requested retry after " + e.delay.String() + " (status " + strconv.Itoa(e.statusCode) + ")"
+}
+
+// decodeError is returned by the event handler when an event fails to decode.
+// The event is dropped and the stream continues with the next event.
+type decodeError struct {
+	err error
+}
+
+func (e decodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e decodeError) Unwrap() error {
+	return e.err
+}
+
+// unixAddrPrefix prefixes beacon node addresses served over a unix domain socket.
+// The full address is used as the stable metric label of the socket.
+const unixAddrPrefix = "unix://"
+
+// maxClockSkew is the clock skew to the beacon node above which a warning is logged.
+// It exceeds the one second resolution of the Date header used to detect it.
+const maxClockSkew = 2 * time.Second
+
+// maxRetryAfter caps the reconnect delay requested via Retry-After, so a misbehaving or
+// misconfigured server can't stall the event stream for longer than a few epochs.
+const maxRetryAfter = 5 * time.Minute
+
 var (
-	errStreamConn = errors.New("cannot connect to the stream")
-	defaultRetry  = time.Second
+	errStreamConn    = errors.New("cannot connect to the stream")
+	errMaxReconnects = errors.New("maximum SSE reconnects exceeded")
+	defaultRetry     = time.Second
 )
 
-func newClient(addr string, header http.Header) (*client, error) {
-	prefixedAddr := addr
-	if !strings.HasPrefix(addr, "http") {
-		prefixedAddr = "http://" + addr
+func newClient(addr string, header http.Header, clock clockwork.Clock, opts ...Option) (*client, error) {
+	o := defaultOptions(opts...)
+
+	tlsConfig, err := o.tlsConfig()
+	if err != nil {
+		return nil, err
 	}
-	u, err := url.Parse(prefixedAddr)
+
+	u, httpClient, err := parseAddr(addr, o.proxyURL, tlsConfig)
 	if err != nil {
-		return nil, errors.Wrap(err, "parse bn addr", z.Str("addr", addr))
+		return nil, err
+	}
+
+	u.Path, err = o.endpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	topics, err := o.topics()
+	if err != nil {
+		return nil, err
 	}
 
-	u.Path = "/eth/v1/events"
 	q := u.Query()
-	q.Add("topics", sseHeadEvent)
-	q.Add("topics", sseChainReorgEvent)
+	for _, topic := range topics {
+		q.Add("topics", topic)
+	}
 	u.RawQuery = q.Encode()
 
+	c := &client{
+		addr:          addr,
+		sseURL:        u,
+		retry:         defaultRetry,
+		httpClient:    httpClient,
+		headers:       header,
+		clock:         clock,
+		userAgent:     o.userAgent,
+		idleTimeout:   o.idleTimeout,
+		maxReconnects: o.maxReconnects,
+		defaultEvent:  o.defaultEvent,
+	}
+	if o.debounceHeads {
+		c.heads = new(headDebouncer)
+	}
+
+	return c, nil
+}
+
+func newClientForT(addr, path string) (*client, error) {
+	u, httpClient, err := parseAddr(addr, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path
+
+	// For testing purposes, we use a different retry duration.
 	return &client{
-		addr:       addr,
-		sseURL:     u,
-		retry:      defaultRetry,
-		httpClient: &http.Client{},
-		headers:    header,
+		addr:         addr,
+		sseURL:       u,
+		retry:        100 * time.Millisecond,
+		httpClient:   httpClient,
+		headers:      make(http.Header),
+		clock:        clockwork.NewRealClock(),
+		userAgent:    defaultOptions().userAgent,
+		defaultEvent: sseMessageEvent,
 	}, nil
 }
 
-func newClientForT(addr, path string) (*client, error) {
+// parseAddr returns the base URL and HTTP client for the beacon node address.
+// Addresses of the form unix:///path/to/socket are dialed over the unix domain socket
+// using a placeholder host in the URL, others default to http if no scheme is provided
+// and are routed via the proxy if not empty or else the environment's proxy. The TLS config, if not nil,
+// applies to https addresses.
+func parseAddr(addr string, proxyURL string, tlsConfig *tls.Config) (*url.URL, *http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	if socket, ok := strings.CutPrefix(addr, unixAddrPrefix); ok {
+		if socket == "" {
+			return nil, nil, errors.New("missing unix socket path", z.Str("addr", addr))
+		}
+
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
+
+		return &url.URL{Scheme: "http", Host: "unix"}, &http.Client{Transport: transport}, nil
+	}
+
 	prefixedAddr := addr
 	if !strings.HasPrefix(addr, "http") {
 		prefixedAddr = "http://" + addr
 	}
 	u, err := url.Parse(prefixedAddr)
 	if err != nil {
-		return nil, errors.Wrap(err, "parse bn addr", z.Str("addr", addr))
+		return nil, nil, errors.Wrap(err, "parse bn addr", z.Str("addr", addr))
 	}
-	u.Path = path
 
-	// For testing purposes, we use a different retry duration.
-	return &client{
-		addr:       addr,
-		sseURL:     u,
-		retry:      100 * time.Millisecond,
-		httpClient: &http.Client{},
-		headers:    make(http.Header),
-	}, nil
+	if proxyURL != "" {
+		proxy, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "parse proxy url")
+		} else if proxy.Scheme == "" || proxy.Host == "" {
+			return nil, nil, errors.New("invalid proxy url, scheme and host required")
+		}
+
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	return u, &http.Client{Transport: transport}, nil
 }
 
 // start connects to the SSE stream. This function will block until SSE stream is stopped.
 func (c *client) start(ctx context.Context, eventFn EventHandler) error {
 	backoff := func() {}
 	backoffSet := false
+	var failures int // Consecutive failed connection attempts.
 
 	for {
+		established := c.established
 		err := c.connect(ctx, eventFn)
+		if c.established != established {
+			failures = 0 // A successful connection resets the count, even if the stream drops later.
+		}
+
+		var retryErr retryAfterError
 
 		switch {
 		case err == nil, errors.Is(err, io.EOF):
 			// Reset the retry.
 			c.retry = defaultRetry
 			backoffSet = false
+			failures = 0
 
 			continue
 		case ctx.Err() != nil:
 			// Exit function if context done.
 			return nil //nolint:nilerr
+		}
+
+		if errors.As(err, &retryErr) || errors.Is(err, errStreamConn) {
+			failures++
+			sseReconnectCounter.WithLabelValues(c.addr).Inc()
+			if c.maxReconnects > 0 && failures >= c.maxReconnects {
+				// Surface the stopped client as disconnected, since it won't reconnect.
+				c.connected.Store(false)
+				sseConnectedGauge.WithLabelValues(c.addr).Set(0)
+
+				return errors.Wrap(errMaxReconnects, "stop SSE client", z.Int("attempts", failures),
+					z.Str("url", c.sseURL.String()), z.Str("last_error", err.Error()))
+			}
+		}
+
+		switch {
+		case errors.As(err, &retryErr):
+			// Honour the server's requested delay instead of the backoff schedule, up to maxRetryAfter.
+			delay := min(retryErr.delay, maxRetryAfter)
+			log.Debug(ctx, "SSE server requested retry after delay", z.Str("delay", retryErr.delay.String()),
+				z.Str("capped_delay", delay.String()), z.Int("status_code", retryErr.statusCode),
+				z.Str("url", c.sseURL.String()))
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-c.clock.After(delay):
+			}
 		default:
 			// If error is not stream-related error, do not attempt retries and return the error.
 			if !errors.Is(err, errStreamConn) {
@@ -132,22 +323,53 @@ func (c *client) start(ctx context.Context, eventFn EventHandler) error {
 func (c *client) connect(ctx context.Context, eventFn EventHandler) error {
 	log.Debug(ctx, "Connecting to SSE stream", z.Str("url", c.sseURL.String()))
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.sseURL.String(), nil)
+	// The request is cancelled if the stream is idle, i.e. neither events nor keepalives are received.
+	reqCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var idle atomic.Bool
+	if c.idleTimeout > 0 {
+		timer := c.clock.AfterFunc(c.idleTimeout, func() {
+			idle.Store(true)
+			cancel()
+		})
+		defer timer.Stop()
+
+		c.resetIdle = func() { timer.Reset(c.idleTimeout) }
+		defer func() { c.resetIdle = nil }()
+	}
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, c.sseURL.String(), nil)
 	if err != nil {
 		return errors.Wrap(err, "create new request")
 	}
 
 	req.Header = c.headers.Clone()
 	req.Header.Set("Accept", "text/event-stream")
+	if c.userAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
 
+	start := c.clock.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return errStreamConn
 	}
 	defer resp.Body.Close()
+	sseConnectHistogram.WithLabelValues(c.addr).Observe(c.clock.Since(start).Seconds())
 
 	switch resp.StatusCode {
 	case http.StatusOK:
+		c.established++
+		c.observeClockSkew(ctx, resp.Header.Get("Date"))
+
+		c.connected.Store(true)
+		sseConnectedGauge.WithLabelValues(c.addr).Set(1)
+		defer func() {
+			c.connected.Store(false)
+			sseConnectedGauge.WithLabelValues(c.addr).Set(0)
+		}()
+
 		r := bufio.NewReader(resp.Body)
 
 		for {
@@ -156,7 +378,10 @@ func (c *client) connect(ctx context.Context, eventFn EventHandler) error {
 				return nil
 			default:
 				event, err := c.parseEvent(r)
-				if err != nil {
+				if err != nil && idle.Load() && ctx.Err() == nil {
+					// Reconnect since the request was cancelled due to the idle timeout.
+					return errors.Wrap(errStreamConn, "sse stream idle timeout", z.Str("timeout", c.idleTimeout.String()))
+				} else if err != nil {
 					return err
 				}
 
@@ -164,32 +389,164 @@ func (c *client) connect(ctx context.Context, eventFn EventHandler) error {
 					continue
 				}
 
-				if err := eventFn(ctx, event, c.addr); err != nil {
+				if event.Event == "" {
+					event.Event = c.defaultEvent
+				}
+
+				// Invalid head events are passed on, leaving error handling to the event handler.
+				if typed, ok, err := event.decode(); err == nil && ok && event.Event == sseHeadEvent {
+					head := typed.(HeadEvent)
+					if c.heads != nil && c.heads.duplicate(head) {
+						sseHeadDebouncedCounter.WithLabelValues(c.addr).Inc()
+						continue
+					}
+
+					c.storeHead(head)
+				}
+
+				if err := eventFn(ctx, event, c.addr); errors.As(err, new(decodeError)) {
+					continue // Decode errors are metered by the event handler.
+				} else if err != nil {
 					return err
 				}
 			}
 		}
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), c.clock.Now()); ok {
+			return retryAfterError{statusCode: resp.StatusCode, delay: delay}
+		}
+
+		return errStreamConn
 	default:
 		return errors.New("bad response status code", z.Int("status_code", resp.StatusCode))
 	}
 }
 
+// storeHead updates the head snapshot with the decoded head event. Events with invalid block roots are ignored.
+func (c *client) storeHead(head HeadEvent) {
+	root, err := hex.DecodeString(strings.TrimPrefix(head.Block, "0x"))
+	if err != nil || len(root) != len(eth2p0.Root{}) {
+		return
+	}
+
+	c.headMu.Lock()
+	defer c.headMu.Unlock()
+
+	c.head = headSnapshot{slot: head.Slot, root: eth2p0.Root(root), at: head.ArrivalTime()}
+}
+
+// LastHead returns the slot and block root of the last head event received and when it was received,
+// or false if none was received.
+func (c *client) LastHead() (uint64, eth2p0.Root, time.Time, bool) {
+	c.headMu.RLock()
+	defer c.headMu.RUnlock()
+
+	if c.head.at.IsZero() {
+		return 0, eth2p0.Root{}, time.Time{}, false
+	}
+
+	return c.head.slot, c.head.root, c.head.at, true
+}
+
+// lastHeadTime returns the time the last valid head event was received and true, or false if none was received.
+func (c *client) lastHeadTime() (time.Time, bool) {
+	_, _, at, ok := c.LastHead()
+
+	return at, ok
+}
+
+// headDebouncer detects repeated head events for the same slot and block root.
+// It isn't thread safe, since events of a connection are processed sequentially.
+type headDebouncer struct {
+	slot   uint64
+	blocks map[string]bool
+}
+
+// duplicate returns true if the head event was already seen. Only blocks of the latest slot are remembered.
+func (d *headDebouncer) duplicate(head HeadEvent) bool {
+	if head.Slot != d.slot {
+		d.slot = head.Slot
+		d.blocks = make(map[string]bool)
+	}
+
+	if d.blocks[head.Block] {
+		return true
+	}
+	d.blocks[head.Block] = true
+
+	return false
+}
+
+// observeClockSkew records the skew between the local clock and the beacon node's clock as reported by the
+// response Date header. Head events don't include a timestamp, so this is the only reference of the beacon node's
+// clock. Since a local clock drift skews the computed slot start and therefore the head delay, it is logged if it
+// exceeds maxClockSkew. The Date header has a resolution of one second.
+func (c *client) observeClockSkew(ctx context.Context, date string) {
+	if date == "" {
+		return
+	}
+
+	bnTime, err := http.ParseTime(date)
+	if err != nil {
+		log.Debug(ctx, "Ignoring invalid SSE response date header", z.Str("date", date))
+		return
+	}
+
+	skew := c.clock.Now().Sub(bnTime)
+	sseClockSkewGauge.WithLabelValues(c.addr).Set(skew.Seconds())
+
+	if skew > maxClockSkew || skew < -maxClockSkew {
+		log.Warn(ctx, "Local clock skew to beacon node exceeds threshold, check NTP configuration", nil,
+			z.Str("skew", skew.String()), z.Str("addr", c.addr))
+	}
+}
+
+// parseRetryAfter returns the delay specified by a Retry-After header value, either in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+
+		return time.Duration(secs) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return max(date.Sub(now), 0), true
+}
+
 func (c *client) parseEvent(r *bufio.Reader) (*event, error) {
 	event := &event{
-		Timestamp: time.Now(),
+		Timestamp: c.clock.Now(),
 	}
+	bytesCounter := sseBytesCounter.WithLabelValues(c.addr)
 
 	for {
-		parts, err := formatAndValidateEvent(r)
+		parts, n, err := formatAndValidateEvent(r)
+		bytesCounter.Add(float64(n))
 		if err != nil {
 			return nil, err
 		}
+		if c.resetIdle != nil {
+			c.resetIdle()
+		}
 		if len(parts) == 0 {
 			return event, nil
 		}
 
 		// Check response type.
 		switch string(parts[0]) {
+		case "":
+			// Lines starting with a colon are comments, used by servers as keepalives.
+			sseKeepaliveCounter.WithLabelValues(c.addr).Inc()
 		case "retry":
 			ms, err := strconv.Atoi(string(parts[1]))
 			if err != nil {
@@ -202,8 +559,11 @@ func (c *client) parseEvent(r *bufio.Reader) (*event, error) {
 		case "event":
 			event.Event = string(parts[1])
 		case "data":
+			// Consecutive data lines are joined with newlines, including empty ones.
 			if event.Data != nil {
 				event.Data = append(event.Data, '\n')
+			} else {
+				event.Data = []byte{}
 			}
 
 			event.Data = append(event.Data, parts[1]...)
@@ -213,19 +573,22 @@ func (c *client) parseEvent(r *bufio.Reader) (*event, error) {
 	}
 }
 
-func formatAndValidateEvent(r *bufio.Reader) ([][]byte, error) {
+// formatAndValidateEvent reads the next line of the stream and returns its field and value parts,
+// and the number of bytes read.
+func formatAndValidateEvent(r *bufio.Reader) ([][]byte, int, error) {
 	line, err := r.ReadBytes('\n')
+	n := len(line)
 	if err != nil {
 		// Connection was lost during reading.
 		if errors.Is(err, io.ErrUnexpectedEOF) {
-			return nil, errStreamConn
+			return nil, n, errStreamConn
 		}
 
 		if errors.Is(err, io.EOF) && len(line) != 0 {
-			return nil, errors.New("incomplete event at the end of the stream")
+			return nil, n, errors.New("incomplete event at the end of the stream")
 		}
 
-		return nil, errors.Wrap(err, "read event")
+		return nil, n, errors.Wrap(err, "read event")
 	}
 
 	// Remove \n suffix.
@@ -239,7 +602,7 @@ func formatAndValidateEvent(r *bufio.Reader) ([][]byte, error) {
 	}
 
 	if len(line) == 0 {
-		return [][]byte{}, nil
+		return [][]byte{}, n, nil
 	}
 
 	parts := bytes.SplitN(line, []byte(":"), 2)
@@ -254,5 +617,5 @@ func formatAndValidateEvent(r *bufio.Reader) ([][]byte, error) {
 		parts[1] = parts[1][1:]
 	}
 
-	return parts, nil
+	return parts, n, nil
 }
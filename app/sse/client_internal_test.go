@@ -6,17 +6,40 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/jonboulle/clockwork"
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	pb "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
 
 	"github.com/obolnetwork/charon/app/errors"
+	"github.com/obolnetwork/charon/app/eth2wrap"
+	"github.com/obolnetwork/charon/app/log"
+	"github.com/obolnetwork/charon/app/version"
 )
 
 func TestReconnect(t *testing.T) {
@@ -35,7 +58,7 @@ func TestReconnect(t *testing.T) {
 	defer ts.Close()
 
 	// Create SSE client and add to waitgroup.
-	cl, err := newClient(ts.URL, make(http.Header))
+	cl, err := newClient(ts.URL, make(http.Header), clockwork.NewRealClock())
 	require.NoError(t, err)
 	eventHandler := func(ctx context.Context, event *event, url string) error { return nil }
 
@@ -58,7 +81,7 @@ func TestReconnect(t *testing.T) {
 
 func TestParseEventRetry(t *testing.T) {
 	r := bufio.NewReader(bytes.NewBufferString("retry: 10\n\n"))
-	client := &client{}
+	client := &client{clock: clockwork.NewRealClock()}
 
 	_, err := client.parseEvent(r)
 	require.NoError(t, err)
@@ -67,7 +90,7 @@ func TestParseEventRetry(t *testing.T) {
 
 func TestParseEventInvalidRetry(t *testing.T) {
 	r := bufio.NewReader(bytes.NewBufferString("retry: ???\n\n"))
-	client := &client{}
+	client := &client{clock: clockwork.NewRealClock()}
 
 	_, err := client.parseEvent(r)
 	require.NoError(t, err)
@@ -141,6 +164,26 @@ func TestParseEvent(t *testing.T) {
 			},
 			err: nil,
 		},
+		{
+			name: "parse multiline data empty lines",
+			data: "data\ndata: some data\ndata:\ndata: multiline data\n\n",
+			event: &event{
+				ID:    "",
+				Event: "",
+				Data:  []byte("\nsome data\n\nmultiline data"),
+			},
+			err: nil,
+		},
+		{
+			name: "parse multiline json data",
+			data: "event: head\ndata: {\ndata:   \"slot\": \"10\",\ndata:   \"block\": \"0xaa\"\ndata: }\n\n",
+			event: &event{
+				ID:    "",
+				Event: "head",
+				Data:  []byte("{\n  \"slot\": \"10\",\n  \"block\": \"0xaa\"\n}"),
+			},
+			err: nil,
+		},
 		{
 			name: "parse empty type",
 			data: ": some comment\n\n",
@@ -221,7 +264,7 @@ data: multiline data
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			r := bufio.NewReader(bytes.NewBufferString(test.data))
-			client := &client{}
+			client := &client{clock: clockwork.NewRealClock()}
 
 			event, err := client.parseEvent(r)
 			if test.event != nil {
@@ -282,6 +325,41 @@ func TestClientReconnect(t *testing.T) {
 	require.Equal(t, 5, counter)
 }
 
+func TestClientReconnectTopics(t *testing.T) {
+	topics := make(chan []string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case topics <- r.URL.Query()["topics"]:
+		default: // Only the first two requests are asserted.
+		}
+
+		// Disconnect after a single event.
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "event: head\ndata: {}\n\n")
+	}))
+	defer server.Close()
+
+	cl, err := newClient(server.URL, make(http.Header), clockwork.NewRealClock(),
+		WithEvents(sseChainReorgEvent, "block_gossip"), WithUnknownEvents())
+	require.NoError(t, err)
+	cl.retry = 0
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- cl.start(ctx, func(context.Context, *event, string) error { return nil })
+	}()
+
+	expect := []string{sseChainReorgEvent, "block_gossip"}
+	require.Equal(t, expect, <-topics)
+	require.Equal(t, expect, <-topics, "reconnect must subscribe to the configured topics")
+
+	cancel()
+	require.NoError(t, <-errCh)
+}
+
 func TestClientError409(t *testing.T) {
 	server := httptest.NewServer(sseHandler())
 	defer server.Close()
@@ -321,3 +399,730 @@ func TestClientEventHandlerErrorPropagation(t *testing.T) {
 	err = client.start(ctx, eventHandler)
 	require.ErrorIs(t, err, parserErr, "expected error from event handler to be returned")
 }
+
+func TestClientUserAgent(t *testing.T) {
+	uaCh := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uaCh <- r.UserAgent()
+		w.Header().Set("Content-Type", "text/event-stream")
+	}))
+	defer server.Close()
+
+	eventHandler := func(context.Context, *event, string) error { return nil }
+
+	cl, err := newClient(server.URL, make(http.Header), clockwork.NewRealClock())
+	require.NoError(t, err)
+	_ = cl.connect(t.Context(), eventHandler)
+	require.Equal(t, "charon/"+version.Version.String(), <-uaCh)
+
+	cl, err = newClient(server.URL, make(http.Header), clockwork.NewRealClock(), WithUserAgent("custom/1.0"))
+	require.NoError(t, err)
+	_ = cl.connect(t.Context(), eventHandler)
+	require.Equal(t, "custom/1.0", <-uaCh)
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	t.Run("requested", func(t *testing.T) {
+		testClientRetryAfter(t, "5", 5*time.Second)
+	})
+
+	t.Run("oversized", func(t *testing.T) {
+		// A day long delay is capped, so the client reconnects after maxRetryAfter.
+		testClientRetryAfter(t, "86400", maxRetryAfter)
+	})
+}
+
+func testClientRetryAfter(t *testing.T, retryAfter string, delay time.Duration) {
+	t.Helper()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", retryAfter)
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "data: event after retry\n\n")
+	}))
+	defer server.Close()
+
+	clock := clockwork.NewFakeClock()
+	cl, err := newClient(server.URL, make(http.Header), clock)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	handler := func(context.Context, *event, string) error {
+		cancel()
+		return nil
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- cl.start(ctx, handler) }()
+
+	// The client waits for the requested delay on the clock before reconnecting.
+	require.NoError(t, clock.BlockUntilContext(ctx, 1))
+	require.EqualValues(t, 1, requests.Load())
+
+	clock.Advance(delay - time.Nanosecond)
+	require.EqualValues(t, 1, requests.Load())
+
+	clock.Advance(time.Nanosecond)
+	require.NoError(t, <-errCh)
+	require.EqualValues(t, 2, requests.Load())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		delay time.Duration
+		ok    bool
+	}{
+		{value: "", ok: false},
+		{value: "3", delay: 3 * time.Second, ok: true},
+		{value: "-1", ok: false},
+		{value: now.Add(time.Minute).Format(http.TimeFormat), delay: time.Minute, ok: true},
+		{value: now.Add(-time.Minute).Format(http.TimeFormat), delay: 0, ok: true},
+		{value: "soon", ok: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			delay, ok := parseRetryAfter(test.value, now)
+			require.Equal(t, test.ok, ok)
+			require.Equal(t, test.delay, delay)
+		})
+	}
+}
+
+func TestClientUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "bn.sock")
+	ln, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/eth/v1/events", r.URL.Path)
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprint(w, "event: head\ndata: unix socket event\n\n")
+		}),
+		ReadHeaderTimeout: time.Second,
+	}
+	go func() { _ = server.Serve(ln) }()
+	defer server.Close()
+
+	addr := "unix://" + socket
+	cl, err := newClient(addr, make(http.Header), clockwork.NewRealClock())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	var received *event
+	handler := func(_ context.Context, e *event, eventAddr string) error {
+		require.Equal(t, addr, eventAddr)
+		received = e
+		cancel()
+
+		return nil
+	}
+
+	require.NoError(t, cl.start(ctx, handler))
+	require.NotNil(t, received)
+	require.Equal(t, sseHeadEvent, received.Event)
+	require.Equal(t, []byte("unix socket event"), received.Data)
+
+	_, err = newClient("unix://", make(http.Header), clockwork.NewRealClock())
+	require.ErrorContains(t, err, "missing unix socket path")
+}
+
+func TestClientProxy(t *testing.T) {
+	const bnAddr = "http://beacon-node.invalid:5052"
+
+	// The fake forward proxy serves the SSE stream itself, asserting it received the proxied request.
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "beacon-node.invalid:5052", r.URL.Host)
+		require.Equal(t, "/eth/v1/events", r.URL.Path)
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "data: proxied event\n\n")
+	}))
+	defer proxy.Close()
+
+	header := make(http.Header)
+	header.Set("Authorization", "Bearer token")
+
+	cl, err := newClient(bnAddr, header, clockwork.NewRealClock(), WithProxy(proxy.URL))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	var events int
+	handler := func(_ context.Context, e *event, _ string) error {
+		require.Equal(t, []byte("proxied event"), e.Data)
+
+		// Reconnects are also routed via the proxy.
+		events++
+		if events == 2 {
+			cancel()
+		}
+
+		return nil
+	}
+
+	require.NoError(t, cl.start(ctx, handler))
+	require.Equal(t, 2, events)
+
+	_, err = newClient(bnAddr, header, clockwork.NewRealClock(), WithProxy("proxy:8080"))
+	require.ErrorContains(t, err, "invalid proxy url")
+}
+
+func TestClientCert(t *testing.T) {
+	certFile, keyFile, clientCert := generateClientCert(t)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "event: head\ndata: mtls event\n\n")
+	}))
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	// trustServer configures the client to trust the test server's self-signed certificate.
+	trustServer := func(cl *client) {
+		transport := cl.httpClient.Transport.(*http.Transport)
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		transport.TLSClientConfig.RootCAs = x509.NewCertPool()
+		transport.TLSClientConfig.RootCAs.AddCert(server.Certificate())
+	}
+
+	connect := func(cl *client) (*event, error) {
+		var received *event
+		err := cl.connect(t.Context(), func(_ context.Context, e *event, _ string) error {
+			received = e
+			return nil
+		})
+
+		return received, err
+	}
+
+	// Without a client certificate the handshake fails.
+	cl, err := newClient(server.URL, make(http.Header), clockwork.NewRealClock())
+	require.NoError(t, err)
+	trustServer(cl)
+	_, err = connect(cl)
+	require.ErrorIs(t, err, errStreamConn)
+
+	// With a client certificate the stream is received.
+	cl, err = newClient(server.URL, make(http.Header), clockwork.NewRealClock(), WithClientCert(certFile, keyFile))
+	require.NoError(t, err)
+	trustServer(cl)
+	received, err := connect(cl)
+	require.ErrorIs(t, err, io.EOF)
+	require.NotNil(t, received)
+	require.Equal(t, []byte("mtls event"), received.Data)
+
+	// Invalid certificate files are rejected on construction.
+	_, err = newClient(server.URL, make(http.Header), clockwork.NewRealClock(), WithClientCert(certFile, filepath.Join(t.TempDir(), "missing.pem")))
+	require.ErrorContains(t, err, "load SSE client certificate")
+}
+
+// generateClientCert writes a self-signed PEM encoded client certificate and key to temporary files.
+func generateClientCert(t *testing.T) (string, string, *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "charon"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	return certFile, keyFile, cert
+}
+
+func TestClientClockSkew(t *testing.T) {
+	var buf zaptest.Buffer
+	log.InitLogfmtForT(t, &buf)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "data: event\n\n")
+	}))
+	defer server.Close()
+
+	eventHandler := func(context.Context, *event, string) error { return nil }
+
+	// The local clock is 5s ahead of the beacon node.
+	const offset = 5 * time.Second
+	cl, err := newClient(server.URL, make(http.Header), clockwork.NewFakeClockAt(time.Now().Add(offset)))
+	require.NoError(t, err)
+	_ = cl.connect(t.Context(), eventHandler)
+
+	// The date header has a resolution of one second.
+	skew := promtestutil.ToFloat64(sseClockSkewGauge.WithLabelValues(server.URL))
+	require.InDelta(t, offset.Seconds(), skew, 1.1)
+	require.Contains(t, buf.String(), "Local clock skew to beacon node exceeds threshold")
+
+	// No warning is logged without skew.
+	buf.Reset()
+	cl, err = newClient(server.URL, make(http.Header), clockwork.NewRealClock())
+	require.NoError(t, err)
+	_ = cl.connect(t.Context(), eventHandler)
+
+	require.InDelta(t, 0, promtestutil.ToFloat64(sseClockSkewGauge.WithLabelValues(server.URL)), 1.1)
+	require.NotContains(t, buf.String(), "Local clock skew to beacon node exceeds threshold")
+}
+
+func TestClientHeadDebounce(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, head := range []string{
+			`{"slot":"5","block":"0xaa"}`,
+			`{"slot":"5","block":"0xaa"}`, // Duplicate
+			`{"slot":"5","block":"0xbb"}`, // Distinct root for the same slot
+			`{"slot":"5","block":"0xbb"}`, // Duplicate
+			`{"slot":"6","block":"0xaa"}`,
+		} {
+			_, _ = fmt.Fprintf(w, "event: head\ndata: %s\n\n", head)
+		}
+	}))
+	defer server.Close()
+
+	receive := func(t *testing.T, opts ...Option) []string {
+		t.Helper()
+
+		cl, err := newClient(server.URL, make(http.Header), clockwork.NewRealClock(), opts...)
+		require.NoError(t, err)
+
+		var heads []string
+		handler := func(_ context.Context, e *event, _ string) error {
+			heads = append(heads, string(e.Data))
+			return nil
+		}
+		_ = cl.connect(t.Context(), handler)
+
+		return heads
+	}
+
+	require.Len(t, receive(t), 5)
+
+	before := promtestutil.ToFloat64(sseHeadDebouncedCounter.WithLabelValues(server.URL))
+	require.Equal(t, []string{
+		`{"slot":"5","block":"0xaa"}`,
+		`{"slot":"5","block":"0xbb"}`,
+		`{"slot":"6","block":"0xaa"}`,
+	}, receive(t, WithHeadDebounce()))
+	require.InDelta(t, 2, promtestutil.ToFloat64(sseHeadDebouncedCounter.WithLabelValues(server.URL))-before, 0)
+}
+
+func TestClientEvents(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		topics []string
+		err    string
+	}{
+		{
+			name:   "default",
+			topics: defaultEvents,
+		},
+		{
+			name:   "configured",
+			opts:   []Option{WithEvents(sseHeadEvent, "finalized_checkpoint", sseHeadEvent)},
+			topics: []string{sseHeadEvent, "finalized_checkpoint"},
+		},
+		{
+			name:   "configured with light client",
+			opts:   []Option{WithEvents(sseChainReorgEvent), WithLightClientEvents()},
+			topics: []string{sseChainReorgEvent, sseLightClientFinalityEvent},
+		},
+		{
+			name: "unknown",
+			opts: []Option{WithEvents(sseHeadEvent, "custom_event")},
+			err:  "unknown SSE event",
+		},
+		{
+			name:   "unknown allowed",
+			opts:   []Option{WithEvents(sseHeadEvent, "custom_event"), WithUnknownEvents()},
+			topics: []string{sseHeadEvent, "custom_event"},
+		},
+		{
+			name: "empty",
+			opts: []Option{WithEvents()},
+			err:  "no SSE events configured",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cl, err := newClient("localhost:5052", nil, clockwork.NewRealClock(), test.opts...)
+			if test.err != "" {
+				require.ErrorContains(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.Equal(t, "/eth/v1/events", cl.sseURL.Path)
+			require.Equal(t, test.topics, cl.sseURL.Query()["topics"])
+		})
+	}
+}
+
+func TestClientKeepalive(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, ": keepalive\n\n")
+		_, _ = fmt.Fprint(w, "event: head\n: keepalive\ndata: 1\n\n")
+		_, _ = fmt.Fprint(w, ":\n\n: keepalive\n\n")
+		_, _ = fmt.Fprint(w, "event: head\ndata: 2\n\n")
+	}))
+	defer server.Close()
+
+	cl, err := newClient(server.URL, make(http.Header), clockwork.NewRealClock())
+	require.NoError(t, err)
+
+	before := promtestutil.ToFloat64(sseKeepaliveCounter.WithLabelValues(server.URL))
+
+	var data []string
+	handler := func(_ context.Context, e *event, _ string) error {
+		data = append(data, string(e.Data))
+		return nil
+	}
+	_ = cl.connect(t.Context(), handler)
+
+	// Keepalives are counted but never dispatched.
+	require.Equal(t, []string{"1", "2"}, data)
+	require.InDelta(t, 4, promtestutil.ToFloat64(sseKeepaliveCounter.WithLabelValues(server.URL))-before, 0)
+}
+
+func TestClientBytes(t *testing.T) {
+	payloads := []string{
+		": keepalive\n\n",
+		"event: head\ndata: {\"slot\":\"1\"}\n\n",
+		"event: head\r\ndata: {\"slot\":\"2\"}\r\n\r\n",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, payload := range payloads {
+			_, _ = fmt.Fprint(w, payload)
+		}
+	}))
+	defer server.Close()
+
+	cl, err := newClient(server.URL, make(http.Header), clockwork.NewRealClock())
+	require.NoError(t, err)
+
+	before := promtestutil.ToFloat64(sseBytesCounter.WithLabelValues(server.URL))
+
+	require.ErrorIs(t, cl.connect(t.Context(), func(context.Context, *event, string) error { return nil }), io.EOF)
+
+	var total int
+	for _, payload := range payloads {
+		total += len(payload)
+	}
+	require.InDelta(t, total, promtestutil.ToFloat64(sseBytesCounter.WithLabelValues(server.URL))-before, 0)
+}
+
+func TestClientDefaultEvent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "data: 1\n\n")
+		_, _ = fmt.Fprint(w, "id: 2\ndata: 2\n\n")
+		_, _ = fmt.Fprint(w, "event: chain_reorg\ndata: 3\n\n")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		opts   []Option
+		expect []string
+	}{
+		{
+			name:   "default",
+			expect: []string{sseMessageEvent, sseMessageEvent, sseChainReorgEvent},
+		},
+		{
+			name:   "head",
+			opts:   []Option{WithDefaultEvent(sseHeadEvent)},
+			expect: []string{sseHeadEvent, sseHeadEvent, sseChainReorgEvent},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cl, err := newClient(server.URL, make(http.Header), clockwork.NewRealClock(), test.opts...)
+			require.NoError(t, err)
+
+			var names []string
+			handler := func(_ context.Context, e *event, _ string) error {
+				names = append(names, e.Event)
+				return nil
+			}
+			require.ErrorIs(t, cl.connect(t.Context(), handler), io.EOF)
+			require.Equal(t, test.expect, names)
+		})
+	}
+}
+
+func TestClientMaxReconnects(t *testing.T) {
+	// Reserve an address that refuses connections.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := "http://" + ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	const maxReconnects = 3
+
+	cl, err := newClient(addr, make(http.Header), clockwork.NewRealClock(), WithMaxReconnects(maxReconnects))
+	require.NoError(t, err)
+	cl.retry = time.Millisecond
+
+	before := promtestutil.ToFloat64(sseReconnectCounter.WithLabelValues(addr))
+	sseConnectedGauge.WithLabelValues(addr).Set(1) // Stale from a previous connection.
+
+	err = cl.start(t.Context(), func(context.Context, *event, string) error { return nil })
+	require.ErrorIs(t, err, errMaxReconnects)
+	require.ErrorContains(t, err, "maximum SSE reconnects exceeded")
+
+	// All failed attempts are counted, including the last one.
+	require.InDelta(t, maxReconnects, promtestutil.ToFloat64(sseReconnectCounter.WithLabelValues(addr))-before, 0)
+	require.InDelta(t, 0, promtestutil.ToFloat64(sseConnectedGauge.WithLabelValues(addr)), 0)
+	require.False(t, cl.connected.Load())
+}
+
+func TestClientMaxReconnectsAfterConnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	const (
+		maxReconnects = 2
+		drops         = 2 * maxReconnects
+	)
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) > drops {
+			cancel()
+		}
+
+		// Drop the stream mid-event by writing less than the declared content length.
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Content-Length", "1000")
+		_, _ = fmt.Fprint(w, "event: head\ndata: {\"slot\":\"1\"}\n\n")
+	}))
+	defer server.Close()
+
+	cl, err := newClient(server.URL, make(http.Header), clockwork.NewRealClock(), WithMaxReconnects(maxReconnects))
+	require.NoError(t, err)
+	cl.retry = time.Millisecond
+
+	// Connections dropped after connecting successfully reset the failed attempts.
+	require.NoError(t, cl.start(ctx, func(context.Context, *event, string) error { return nil }))
+	require.Greater(t, int(requests.Load()), drops)
+}
+
+func TestClientConnectedGauge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "event: head\ndata: 1\n\n")
+	}))
+	defer server.Close()
+
+	cl, err := newClient(server.URL, make(http.Header), clockwork.NewRealClock())
+	require.NoError(t, err)
+
+	var connected float64
+	_ = cl.connect(t.Context(), func(context.Context, *event, string) error {
+		connected = promtestutil.ToFloat64(sseConnectedGauge.WithLabelValues(server.URL))
+		return nil
+	})
+
+	require.InDelta(t, 1, connected, 0)
+	require.InDelta(t, 0, promtestutil.ToFloat64(sseConnectedGauge.WithLabelValues(server.URL)), 0)
+}
+
+func TestClientIdleTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, ": keepalive\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done() // Stall the stream.
+	}))
+	defer server.Close()
+
+	cl, err := newClient(server.URL, make(http.Header), clockwork.NewRealClock(), WithIdleTimeout(50*time.Millisecond))
+	require.NoError(t, err)
+
+	err = cl.connect(t.Context(), func(context.Context, *event, string) error { return nil })
+	require.ErrorIs(t, err, errStreamConn)
+	require.ErrorContains(t, err, "sse stream idle timeout")
+}
+
+func TestClientMultilineData(t *testing.T) {
+	const head = `{
+  "slot": "10",
+  "block": "0x9a2fefd2fdb57f74993c7780ea5b9030d2897b615b89f808011ca5aebed54eaf",
+  "state": "0x600e852a08c1200654ddf11025f1ceacb3c2e74bdd5c630cde0838b2591b69f9",
+  "epoch_transition": false,
+  "execution_optimistic": false
+}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "event: head\n")
+		for _, line := range strings.Split(head, "\n") {
+			_, _ = fmt.Fprintf(w, "data: %s\r\n", line)
+		}
+		_, _ = fmt.Fprint(w, "\n")
+	}))
+	defer server.Close()
+
+	cl, err := newClient(server.URL, make(http.Header), clockwork.NewRealClock())
+	require.NoError(t, err)
+
+	l := &listener{
+		clock:         eth2wrap.NewSlotClock(clockwork.NewRealClock(), time.Now(), 12*time.Second),
+		slotsPerEpoch: 32,
+	}
+
+	var events []*event
+	handler := func(ctx context.Context, e *event, addr string) error {
+		events = append(events, e)
+		return l.eventHandler(ctx, e, addr)
+	}
+	require.ErrorIs(t, cl.connect(t.Context(), handler), io.EOF)
+
+	// The payload is reconstructed and decoded as a single event.
+	require.Len(t, events, 1)
+	require.Equal(t, head, string(events[0].Data))
+
+	var data headEventData
+	require.NoError(t, json.Unmarshal(events[0].Data, &data))
+	require.Equal(t, "10", data.Slot)
+}
+
+func TestClientDecodeErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "event: head\ndata: {\"slot\":\"ten\"}\n\n")
+		_, _ = fmt.Fprint(w, "event: head\ndata: {\"slot\":\"11\"}\n\n")
+	}))
+	defer server.Close()
+
+	cl, err := newClient(server.URL, make(http.Header), clockwork.NewRealClock())
+	require.NoError(t, err)
+
+	l := &listener{
+		clock:         eth2wrap.NewSlotClock(clockwork.NewRealClock(), time.Now(), 12*time.Second),
+		slotsPerEpoch: 32,
+	}
+
+	var handled int
+	handler := func(ctx context.Context, e *event, addr string) error {
+		handled++
+		return l.eventHandler(ctx, e, addr)
+	}
+
+	before := promtestutil.ToFloat64(sseDecodeErrorsCounter.WithLabelValues(cl.addr, sseHeadEvent))
+	require.ErrorIs(t, cl.connect(t.Context(), handler), io.EOF)
+
+	// The malformed event is metered and the stream continues with the next event.
+	require.Equal(t, 2, handled)
+	require.InDelta(t, 1, promtestutil.ToFloat64(sseDecodeErrorsCounter.WithLabelValues(cl.addr, sseHeadEvent))-before, 0)
+	require.InDelta(t, 11, promtestutil.ToFloat64(sseHeadSlotGauge.WithLabelValues(cl.addr)), 0)
+}
+
+func TestClientEndpointPath(t *testing.T) {
+	const path = "/proxy/beacon/eth/v1/events"
+
+	dialed := make(chan *url.URL, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dialed <- r.URL
+		w.Header().Set("Content-Type", "text/event-stream")
+	}))
+	defer server.Close()
+
+	cl, err := newClient(server.URL, make(http.Header), clockwork.NewRealClock(), WithEndpointPath(path), WithEvents(sseHeadEvent))
+	require.NoError(t, err)
+	require.Equal(t, server.URL+path+"?topics=head", cl.sseURL.String())
+
+	require.ErrorIs(t, cl.connect(t.Context(), func(context.Context, *event, string) error { return nil }), io.EOF)
+
+	u := <-dialed
+	require.Equal(t, path, u.Path)
+	require.Equal(t, []string{sseHeadEvent}, u.Query()["topics"])
+
+	for _, invalid := range []string{"eth/v1/events", "/events?topics=head", "/events#head", "/%zz"} {
+		_, err := newClient(server.URL, make(http.Header), clockwork.NewRealClock(), WithEndpointPath(invalid))
+		require.ErrorContains(t, err, "SSE endpoint path", invalid)
+	}
+}
+
+func TestClientConnectDuration(t *testing.T) {
+	const delay = 50 * time.Millisecond
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay) // Delay the response headers.
+		w.Header().Set("Content-Type", "text/event-stream")
+	}))
+	defer server.Close()
+
+	cl, err := newClient(server.URL, make(http.Header), clockwork.NewRealClock())
+	require.NoError(t, err)
+
+	histogram := func() *pb.Histogram {
+		var m pb.Metric
+		require.NoError(t, sseConnectHistogram.WithLabelValues(cl.addr).(prometheus.Histogram).Write(&m))
+
+		return m.GetHistogram()
+	}
+	before := histogram()
+
+	// Both the initial connection and the reconnect are observed.
+	for range 2 {
+		require.ErrorIs(t, cl.connect(t.Context(), func(context.Context, *event, string) error { return nil }), io.EOF)
+	}
+
+	after := histogram()
+	require.EqualValues(t, 2, after.GetSampleCount()-before.GetSampleCount())
+	require.GreaterOrEqual(t, after.GetSampleSum()-before.GetSampleSum(), 2*delay.Seconds())
+}
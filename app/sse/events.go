@@ -0,0 +1,265 @@
+// Copyright © 2022-2025 Obol Labs Inc. Licensed under the terms of a Business Source License 1.1
+
+package sse
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/obolnetwork/charon/app/errors"
+	"github.com/obolnetwork/charon/app/z"
+)
+
+// Event is a beacon node SSE event decoded into its typed struct.
+type Event interface {
+	// Name returns the SSE event name, e.g. "head".
+	Name() string
+	// ArrivalTime returns the time the event was received.
+	ArrivalTime() time.Time
+}
+
+// eventMeta is embedded in all typed events and implements Event.
+type eventMeta struct {
+	name    string
+	arrival time.Time
+}
+
+func (m eventMeta) Name() string { return m.name }
+
+func (m eventMeta) ArrivalTime() time.Time { return m.arrival }
+
+// HeadEvent is a decoded head event.
+type HeadEvent struct {
+	eventMeta
+
+	Slot                      uint64
+	Block                     string
+	State                     string
+	EpochTransition           bool
+	PreviousDutyDependentRoot string
+	CurrentDutyDependentRoot  string
+	ExecutionOptimistic       bool
+}
+
+// ReorgEvent is a decoded chain_reorg event.
+type ReorgEvent struct {
+	eventMeta
+
+	Slot                uint64
+	Depth               uint64
+	Epoch               uint64
+	OldHeadBlock        string
+	NewHeadBlock        string
+	OldHeadState        string
+	NewHeadState        string
+	ExecutionOptimistic bool
+}
+
+// FinalizedCheckpointEvent is a decoded finalized_checkpoint event.
+type FinalizedCheckpointEvent struct {
+	eventMeta
+
+	Block               string
+	State               string
+	Epoch               uint64
+	ExecutionOptimistic bool
+}
+
+// ContributionAndProofEvent is a decoded contribution_and_proof event.
+type ContributionAndProofEvent struct {
+	eventMeta
+
+	Slot              uint64
+	AggregatorIndex   uint64
+	SubcommitteeIndex uint64
+	BeaconBlockRoot   string
+}
+
+// AttesterSlashingEvent is a decoded attester_slashing event.
+type AttesterSlashingEvent struct {
+	eventMeta
+
+	// ValidatorIndices are the sorted indices of the slashed validators, i.e. those attesting in both attestations.
+	ValidatorIndices []uint64
+}
+
+// ProposerSlashingEvent is a decoded proposer_slashing event.
+type ProposerSlashingEvent struct {
+	eventMeta
+
+	ValidatorIndex uint64
+	Slot           uint64
+}
+
+// LightClientFinalityUpdateEvent is a decoded light_client_finality_update event.
+type LightClientFinalityUpdateEvent struct {
+	eventMeta
+
+	AttestedSlot  uint64
+	FinalizedSlot uint64
+}
+
+var (
+	_ Event = HeadEvent{}
+	_ Event = ReorgEvent{}
+	_ Event = FinalizedCheckpointEvent{}
+	_ Event = ContributionAndProofEvent{}
+	_ Event = AttesterSlashingEvent{}
+	_ Event = ProposerSlashingEvent{}
+	_ Event = LightClientFinalityUpdateEvent{}
+)
+
+// decodeEvent returns the typed event of the raw SSE event. It returns false if the event isn't supported.
+// Fork versioned payloads are unwrapped by the respective decoders.
+func decodeEvent(e *event) (Event, bool, error) {
+	meta := eventMeta{name: e.Event, arrival: e.Timestamp}
+
+	var (
+		typed Event
+		err   error
+	)
+	switch e.Event {
+	case sseHeadEvent:
+		typed, err = decodeHeadEvent(meta, e.Data)
+	case sseChainReorgEvent:
+		typed, err = decodeReorgEvent(meta, e.Data)
+	case sseFinalizedCheckpointEvent:
+		typed, err = decodeFinalizedCheckpointEvent(meta, e.Data)
+	case sseContributionAndProofEvent:
+		typed, err = decodeContributionAndProofEvent(meta, e.Data)
+	case sseAttesterSlashingEvent:
+		indices, decodeErr := decodeAttesterSlashing(e.Data)
+		if decodeErr != nil {
+			return nil, false, errors.Wrap(decodeErr, "decode SSE attester_slashing event")
+		}
+		typed = AttesterSlashingEvent{eventMeta: meta, ValidatorIndices: indices}
+	case sseProposerSlashingEvent:
+		index, slot, decodeErr := decodeProposerSlashing(e.Data)
+		if decodeErr != nil {
+			return nil, false, errors.Wrap(decodeErr, "decode SSE proposer_slashing event")
+		}
+		typed = ProposerSlashingEvent{eventMeta: meta, ValidatorIndex: index, Slot: slot}
+	case sseLightClientFinalityEvent:
+		attested, finalized, decodeErr := decodeLightClientFinalityUpdate(e.Data)
+		if decodeErr != nil {
+			return nil, false, errors.Wrap(decodeErr, "decode SSE light_client_finality_update event")
+		}
+		typed = LightClientFinalityUpdateEvent{eventMeta: meta, AttestedSlot: attested, FinalizedSlot: finalized}
+	default:
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return typed, true, nil
+}
+
+func decodeHeadEvent(meta eventMeta, data []byte) (HeadEvent, error) {
+	var head headEventData
+	if err := json.Unmarshal(data, &head); err != nil {
+		return HeadEvent{}, errors.Wrap(err, "unmarshal SSE head event")
+	}
+	slot, err := strconv.ParseUint(head.Slot, 10, 64)
+	if err != nil {
+		return HeadEvent{}, errors.Wrap(err, "parse slot to uint64")
+	}
+	if slot > math.MaxInt64 {
+		return HeadEvent{}, errors.New("slot value exceeds int64 range", z.U64("slot", slot))
+	}
+
+	return HeadEvent{
+		eventMeta:                 meta,
+		Slot:                      slot,
+		Block:                     head.Block,
+		State:                     head.State,
+		EpochTransition:           head.EpochTransition,
+		PreviousDutyDependentRoot: head.PreviousDutyDependentRoot,
+		CurrentDutyDependentRoot:  head.CurrentDutyDependentRoot,
+		ExecutionOptimistic:       head.ExecutionOptimistic,
+	}, nil
+}
+
+func decodeReorgEvent(meta eventMeta, data []byte) (ReorgEvent, error) {
+	var reorg chainReorgData
+	if err := json.Unmarshal(data, &reorg); err != nil {
+		return ReorgEvent{}, errors.Wrap(err, "unmarshal SSE chain_reorg event")
+	}
+	slot, err := strconv.ParseUint(reorg.Slot, 10, 64)
+	if err != nil {
+		return ReorgEvent{}, errors.Wrap(err, "parse slot to uint64")
+	}
+	depth, err := strconv.ParseUint(reorg.Depth, 10, 64)
+	if err != nil {
+		return ReorgEvent{}, errors.Wrap(err, "parse depth to uint64")
+	}
+
+	var epoch uint64
+	if reorg.Epoch != "" {
+		epoch, err = strconv.ParseUint(reorg.Epoch, 10, 64)
+		if err != nil {
+			return ReorgEvent{}, errors.Wrap(err, "parse epoch to uint64")
+		}
+	}
+
+	return ReorgEvent{
+		eventMeta:           meta,
+		Slot:                slot,
+		Depth:               depth,
+		Epoch:               epoch,
+		OldHeadBlock:        reorg.OldHeadBlock,
+		NewHeadBlock:        reorg.NewHeadBlock,
+		OldHeadState:        reorg.OldHeadState,
+		NewHeadState:        reorg.NewHeadState,
+		ExecutionOptimistic: reorg.ExecutionOptimistic,
+	}, nil
+}
+
+func decodeFinalizedCheckpointEvent(meta eventMeta, data []byte) (FinalizedCheckpointEvent, error) {
+	var checkpoint finalizedCheckpointData
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return FinalizedCheckpointEvent{}, errors.Wrap(err, "unmarshal SSE finalized_checkpoint event")
+	}
+	epoch, err := strconv.ParseUint(checkpoint.Epoch, 10, 64)
+	if err != nil {
+		return FinalizedCheckpointEvent{}, errors.Wrap(err, "parse epoch to uint64")
+	}
+
+	return FinalizedCheckpointEvent{
+		eventMeta:           meta,
+		Block:               checkpoint.Block,
+		State:               checkpoint.State,
+		Epoch:               epoch,
+		ExecutionOptimistic: checkpoint.ExecutionOptimistic,
+	}, nil
+}
+
+func decodeContributionAndProofEvent(meta eventMeta, data []byte) (ContributionAndProofEvent, error) {
+	msg, err := decodeContributionAndProof(data)
+	if err != nil {
+		return ContributionAndProofEvent{}, errors.Wrap(err, "unmarshal SSE contribution_and_proof event")
+	}
+
+	slot, err := strconv.ParseUint(msg.Contribution.Slot, 10, 64)
+	if err != nil {
+		return ContributionAndProofEvent{}, errors.Wrap(err, "parse slot to uint64")
+	}
+	aggIdx, err := strconv.ParseUint(msg.AggregatorIndex, 10, 64)
+	if err != nil {
+		return ContributionAndProofEvent{}, errors.Wrap(err, "parse aggregator index to uint64")
+	}
+	subcommIdx, err := strconv.ParseUint(msg.Contribution.SubcommitteeIndex, 10, 64)
+	if err != nil {
+		return ContributionAndProofEvent{}, errors.Wrap(err, "parse subcommittee index to uint64")
+	}
+
+	return ContributionAndProofEvent{
+		eventMeta:         meta,
+		Slot:              slot,
+		AggregatorIndex:   aggIdx,
+		SubcommitteeIndex: subcommIdx,
+		BeaconBlockRoot:   msg.Contribution.BeaconBlockRoot,
+	}, nil
+}
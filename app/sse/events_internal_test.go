@@ -0,0 +1,145 @@
+// Copyright © 2022-2025 Obol Labs Inc. Licensed under the terms of a Business Source License 1.1
+
+package sse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeEvent(t *testing.T) {
+	arrival := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	meta := func(name string) eventMeta {
+		return eventMeta{name: name, arrival: arrival}
+	}
+
+	tests := []struct {
+		name   string
+		event  string
+		data   string
+		expect Event
+		err    string
+	}{
+		{
+			name:  "head",
+			event: sseHeadEvent,
+			data:  `{"slot":"10","block":"0xaa","state":"0xbb","epoch_transition":true,"previous_duty_dependent_root":"0xcc","current_duty_dependent_root":"0xdd","execution_optimistic":true}`,
+			expect: HeadEvent{
+				eventMeta:                 meta(sseHeadEvent),
+				Slot:                      10,
+				Block:                     "0xaa",
+				State:                     "0xbb",
+				EpochTransition:           true,
+				PreviousDutyDependentRoot: "0xcc",
+				CurrentDutyDependentRoot:  "0xdd",
+				ExecutionOptimistic:       true,
+			},
+		},
+		{
+			name:  "head slot overflow",
+			event: sseHeadEvent,
+			data:  `{"slot":"18446744073709551615"}`,
+			err:   "slot value exceeds int64 range",
+		},
+		{
+			name:  "chain_reorg",
+			event: sseChainReorgEvent,
+			data:  `{"slot":"200","depth":"2","old_head_block":"0xaa","new_head_block":"0xbb","old_head_state":"0xcc","new_head_state":"0xdd","epoch":"6","execution_optimistic":false}`,
+			expect: ReorgEvent{
+				eventMeta:    meta(sseChainReorgEvent),
+				Slot:         200,
+				Depth:        2,
+				Epoch:        6,
+				OldHeadBlock: "0xaa",
+				NewHeadBlock: "0xbb",
+				OldHeadState: "0xcc",
+				NewHeadState: "0xdd",
+			},
+		},
+		{
+			name:  "chain_reorg parse depth",
+			event: sseChainReorgEvent,
+			data:  `{"slot":"200","depth":"x"}`,
+			err:   "parse depth to uint64",
+		},
+		{
+			name:  "finalized_checkpoint",
+			event: sseFinalizedCheckpointEvent,
+			data:  `{"block":"0xaa","state":"0xbb","epoch":"2","execution_optimistic":false}`,
+			expect: FinalizedCheckpointEvent{
+				eventMeta: meta(sseFinalizedCheckpointEvent),
+				Block:     "0xaa",
+				State:     "0xbb",
+				Epoch:     2,
+			},
+		},
+		{
+			name:  "contribution_and_proof",
+			event: sseContributionAndProofEvent,
+			data:  `{"message":{"aggregator_index":"5","contribution":{"slot":"7","beacon_block_root":"0xaa","subcommittee_index":"3"}},"signature":"0x01"}`,
+			expect: ContributionAndProofEvent{
+				eventMeta:         meta(sseContributionAndProofEvent),
+				Slot:              7,
+				AggregatorIndex:   5,
+				SubcommitteeIndex: 3,
+				BeaconBlockRoot:   "0xaa",
+			},
+		},
+		{
+			name:  "attester_slashing versioned",
+			event: sseAttesterSlashingEvent,
+			data:  `{"version":"electra","data":{"attestation_1":{"attesting_indices":["3","1","2"]},"attestation_2":{"attesting_indices":["2","3","4"]}}}`,
+			expect: AttesterSlashingEvent{
+				eventMeta:        meta(sseAttesterSlashingEvent),
+				ValidatorIndices: []uint64{2, 3},
+			},
+		},
+		{
+			name:  "proposer_slashing",
+			event: sseProposerSlashingEvent,
+			data:  `{"signed_header_1":{"message":{"slot":"9","proposer_index":"4"}},"signed_header_2":{"message":{"slot":"9","proposer_index":"4"}}}`,
+			expect: ProposerSlashingEvent{
+				eventMeta:      meta(sseProposerSlashingEvent),
+				ValidatorIndex: 4,
+				Slot:           9,
+			},
+		},
+		{
+			name:  "light_client_finality_update",
+			event: sseLightClientFinalityEvent,
+			data:  `{"version":"deneb","data":{"attested_header":{"beacon":{"slot":"1000"}},"finalized_header":{"beacon":{"slot":"936"}},"signature_slot":"1001"}}`,
+			expect: LightClientFinalityUpdateEvent{
+				eventMeta:     meta(sseLightClientFinalityEvent),
+				AttestedSlot:  1000,
+				FinalizedSlot: 936,
+			},
+		},
+		{
+			name:  "unsupported",
+			event: "block",
+			data:  `{"slot":"10"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			typed, ok, err := decodeEvent(&event{Event: test.event, Data: []byte(test.data), Timestamp: arrival})
+			if test.err != "" {
+				require.ErrorContains(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+
+			if test.expect == nil {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, test.event, typed.Name())
+			require.Equal(t, arrival, typed.ArrivalTime())
+			require.Equal(t, test.expect, typed)
+		})
+	}
+}
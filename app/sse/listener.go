@@ -5,13 +5,15 @@ package sse
 import (
 	"context"
 	"encoding/json"
-	"math"
 	"net/http"
+	"slices"
 	"strconv"
 	"sync"
 	"time"
 
 	eth2p0 "github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jonboulle/clockwork"
+	"golang.org/x/time/rate"
 
 	"github.com/obolnetwork/charon/app/errors"
 	"github.com/obolnetwork/charon/app/eth2wrap"
@@ -24,6 +26,25 @@ type ChainReorgEventHandlerFunc func(ctx context.Context, epoch eth2p0.Epoch)
 
 type Listener interface {
 	SubscribeChainReorgEvent(ChainReorgEventHandlerFunc)
+	// Ready returns true if at least one SSE stream is connected and received a head event within
+	// the freshness window, see WithReadyFreshness. Otherwise, it returns an error describing why not.
+	Ready() (bool, error)
+	// LastHead returns the slot and block root of the most recently received head event across all beacon nodes
+	// and when it was received, or false if none was received.
+	LastHead() (slot uint64, root eth2p0.Root, at time.Time, ok bool)
+	// RecentReorgs returns the most recent chain reorg events received from the beacon node, oldest first,
+	// see WithReorgHistory.
+	RecentReorgs(addr string) []ReorgEvent
+}
+
+// defaultReorgHistory is the default number of recent chain reorg events retained per beacon node.
+const defaultReorgHistory = 8
+
+// headOrder is the head event ordering state of a beacon node.
+type headOrder struct {
+	slot    uint64 // Slot of the last head event.
+	reorged bool   // True if a chain reorg event was received since the last head event.
+	seen    bool   // True if a head event was received, false if only a chain reorg event was.
 }
 
 type listener struct {
@@ -31,16 +52,39 @@ type listener struct {
 
 	chainReorgSubs []ChainReorgEventHandlerFunc
 	lastReorgEpoch eth2p0.Epoch
+	headOrders     map[string]headOrder    // Head ordering state by beacon node address, see checkHeadOrder.
+	maxHeadSlot    uint64                  // Highest head slot observed across all beacon node clients, see updateHeadLag.
+	finalized      map[string]uint64       // Finalized checkpoint epoch by beacon node address.
+	slowHeadLogs   map[string]z.Field      // Slow head event log filters by beacon node address, see logSlowHead.
+	reorgs         map[string][]ReorgEvent // Recent chain reorg events by beacon node address, oldest first.
 
 	// immutable fields
-	genesisTime   time.Time
-	slotDuration  time.Duration
+	clock         eth2wrap.SlotClock
 	slotsPerEpoch uint64
+	clients       []*client
+	readyWindow   time.Duration // Zero defaults to two slots.
+	slowHead      time.Duration // Zero defaults to the top head delay bucket.
+	reorgHistory  int           // Number of recent chain reorg events retained per beacon node.
+	events        []string      // Events handled, nil handles all events.
 }
 
 var _ Listener = (*listener)(nil)
 
-func StartListener(ctx context.Context, eth2Cl eth2wrap.Client, addresses, headers []string) (Listener, error) {
+func StartListener(ctx context.Context, eth2Cl eth2wrap.Client, addresses, headers []string, opts ...Option) (Listener, error) {
+	o := defaultOptions(opts...)
+	events, err := o.topics()
+	if err != nil {
+		return nil, err
+	}
+
+	// Fail early on invalid client certificates or endpoint paths instead of skipping all clients.
+	if _, err := o.tlsConfig(); err != nil {
+		return nil, err
+	}
+	if _, err := o.endpoint(); err != nil {
+		return nil, err
+	}
+
 	// It is fine to use response from eth2cl (and respectively response from one of the nodes),
 	// as configurations are per network and not per node.
 	genesisTime, err := eth2wrap.FetchGenesisTime(ctx, eth2Cl)
@@ -54,9 +98,12 @@ func StartListener(ctx context.Context, eth2Cl eth2wrap.Client, addresses, heade
 
 	l := &listener{
 		chainReorgSubs: make([]ChainReorgEventHandlerFunc, 0),
-		genesisTime:    genesisTime,
-		slotDuration:   slotDuration,
+		clock:          eth2wrap.NewSlotClock(clockwork.NewRealClock(), genesisTime, slotDuration),
 		slotsPerEpoch:  slotsPerEpoch,
+		readyWindow:    o.readyWindow,
+		slowHead:       o.slowHead,
+		reorgHistory:   o.reorgHistory,
+		events:         events,
 	}
 
 	parsedHeaders, err := eth2util.ParseBeaconNodeHeaders(headers)
@@ -68,18 +115,23 @@ func StartListener(ctx context.Context, eth2Cl eth2wrap.Client, addresses, heade
 		httpHeader.Add(k, v)
 	}
 
-	// Open connections for each beacon node.
+	// Create clients before starting them, so the clients are immutable when returning.
 	for _, addr := range addresses {
-		go func(addr string) {
-			client, err := newClient(addr, httpHeader)
-			if err != nil {
-				log.Warn(ctx, "Failed to create SSE client", err, z.Str("addr", addr))
-			} else {
-				if err := client.start(ctx, l.eventHandler); err != nil {
-					log.Warn(ctx, "Failed to start SSE client", err, z.Str("addr", addr))
-				}
+		client, err := newClient(addr, httpHeader, l.clock, opts...)
+		if err != nil {
+			log.Warn(ctx, "Failed to create SSE client", err, z.Str("addr", addr))
+			continue
+		}
+		l.clients = append(l.clients, client)
+	}
+
+	// Open connections for each beacon node.
+	for _, client := range l.clients {
+		go func() {
+			if err := client.start(ctx, l.eventHandler); err != nil {
+				log.Warn(ctx, "Failed to start SSE client", err, z.Str("addr", client.addr))
 			}
-		}(addr)
+		}()
 	}
 
 	return l, nil
@@ -92,63 +144,121 @@ func (p *listener) SubscribeChainReorgEvent(handler ChainReorgEventHandlerFunc)
 	p.chainReorgSubs = append(p.chainReorgSubs, handler)
 }
 
-func (p *listener) eventHandler(ctx context.Context, event *event, addr string) error {
-	switch event.Event {
-	case sseHeadEvent:
-		return p.handleHeadEvent(ctx, event, addr)
-	case sseChainReorgEvent:
-		return p.handleChainReorgEvent(ctx, event, addr)
-	default:
-		return nil
+func (p *listener) LastHead() (uint64, eth2p0.Root, time.Time, bool) {
+	var (
+		slot  uint64
+		root  eth2p0.Root
+		at    time.Time
+		found bool
+	)
+	for _, c := range p.clients {
+		if cSlot, cRoot, cAt, ok := c.LastHead(); ok && cAt.After(at) {
+			slot, root, at, found = cSlot, cRoot, cAt, true
+		}
 	}
+
+	return slot, root, at, found
 }
 
-func (p *listener) handleHeadEvent(ctx context.Context, event *event, addr string) error {
-	var head headEventData
-	err := json.Unmarshal(event.Data, &head)
-	if err != nil {
-		return errors.Wrap(err, "unmarshal SSE head event", z.Str("addr", addr))
+func (p *listener) Ready() (bool, error) {
+	window := p.readyWindow
+	if window == 0 {
+		window = 2 * p.clock.SlotDuration()
+	}
+
+	var (
+		connected bool
+		lastHead  time.Time
+	)
+	for _, c := range p.clients {
+		if !c.connected.Load() {
+			continue
+		}
+		connected = true
+
+		if head, ok := c.lastHeadTime(); ok && head.After(lastHead) {
+			lastHead = head
+		}
 	}
-	slot, err := strconv.ParseUint(head.Slot, 10, 64)
+
+	if !connected {
+		return false, errors.New("no connected SSE streams", z.Int("clients", len(p.clients)))
+	} else if lastHead.IsZero() {
+		return false, errors.New("no SSE head events received")
+	} else if age := p.clock.Now().Sub(lastHead); age > window {
+		return false, errors.New("stale SSE head events", z.Str("age", age.String()), z.Str("window", window.String()))
+	}
+
+	return true, nil
+}
+
+func (p *listener) eventHandler(ctx context.Context, event *event, addr string) error {
+	if p.events != nil && !slices.Contains(p.events, event.Event) {
+		return nil
+	}
+
+	// Events are decoded once here, handlers consume the typed events.
+	typed, ok, err := event.decode()
 	if err != nil {
-		return errors.Wrap(err, "parse slot to uint64", z.Str("addr", addr))
+		sseDecodeErrorsCounter.WithLabelValues(addr, event.Event).Inc()
+		log.Debug(ctx, "Failed to decode SSE event", z.Err(err),
+			z.Str("event", event.Event), z.Str("addr", addr), z.Str("data", string(event.Data)))
+
+		return decodeError{err: errors.Wrap(err, "decode SSE event", z.Str("event", event.Event), z.Str("addr", addr))}
+	} else if !ok {
+		return nil
 	}
-	if slot > math.MaxInt64 {
-		return errors.New("slot value exceeds int64 range", z.Str("addr", addr), z.U64("slot", slot))
+
+	switch e := typed.(type) {
+	case HeadEvent:
+		p.handleHeadEvent(ctx, e, addr)
+	case ReorgEvent:
+		return p.handleChainReorgEvent(ctx, e, addr)
+	case ContributionAndProofEvent:
+		p.handleContributionAndProofEvent(ctx, e, addr)
+	case AttesterSlashingEvent:
+		p.handleAttesterSlashingEvent(ctx, e, addr)
+	case ProposerSlashingEvent:
+		p.handleProposerSlashingEvent(ctx, e, addr)
+	case LightClientFinalityUpdateEvent:
+		p.handleLightClientFinalityEvent(ctx, e, addr)
+	case FinalizedCheckpointEvent:
+		p.handleFinalizedCheckpointEvent(ctx, e, addr)
 	}
-	delay, ok := p.computeDelay(slot, event.Timestamp)
+
+	return nil
+}
+
+func (p *listener) handleHeadEvent(ctx context.Context, head HeadEvent, addr string) {
+	delay, ok := p.computeDelay(head.Slot, head.ArrivalTime())
 	if !ok {
-		log.Debug(ctx, "Beacon node received head event too late", z.U64("slot", slot), z.Str("delay", delay.String()))
+		log.Debug(ctx, "Beacon node received head event too late", z.U64("slot", head.Slot), z.Str("delay", delay.String()))
 	} else {
 		sseHeadDelayHistogram.WithLabelValues(addr).Observe(delay.Seconds())
 	}
+	// Heads arriving more than a slot late are the slowest, so they are logged even though they aren't observed.
+	p.logSlowHead(ctx, head.Slot, delay, addr)
 
-	sseHeadSlotGauge.WithLabelValues(addr).Set(float64(slot))
+	sseHeadSlotGauge.WithLabelValues(addr).Set(float64(head.Slot))
+
+	if prev, ok := p.checkHeadOrder(addr, head.Slot); !ok {
+		sseOutOfOrderCounter.WithLabelValues(addr).Inc()
+		log.Warn(ctx, "Beacon node head event out of order without chain reorg", nil,
+			z.U64("slot", head.Slot), z.U64("prev_slot", prev), z.Str("addr", addr))
+	}
+	p.updateHeadLag(head.Slot)
+	p.updateFinalityDistance(addr)
 
 	log.Debug(ctx, "SSE head event",
-		z.U64("slot", slot),
+		z.U64("slot", head.Slot),
 		z.Str("delay", delay.String()),
 		z.Str("block", head.Block),
 		z.Str("prev_ddr", head.PreviousDutyDependentRoot),
 		z.Str("curr_ddr", head.CurrentDutyDependentRoot))
-
-	return nil
 }
 
-func (p *listener) handleChainReorgEvent(ctx context.Context, event *event, addr string) error {
-	var chainReorg chainReorgData
-	err := json.Unmarshal(event.Data, &chainReorg)
-	if err != nil {
-		return errors.Wrap(err, "unmarshal SSE chain_reorg event", z.Str("addr", addr))
-	}
-	slot, err := strconv.ParseUint(chainReorg.Slot, 10, 64)
-	if err != nil {
-		return errors.Wrap(err, "parse slot to uint64", z.Str("addr", addr))
-	}
-	depth, err := strconv.ParseUint(chainReorg.Depth, 10, 64)
-	if err != nil {
-		return errors.Wrap(err, "parse depth to uint64", z.Str("addr", addr))
-	}
+func (p *listener) handleChainReorgEvent(ctx context.Context, reorg ReorgEvent, addr string) error {
+	slot, depth := reorg.Slot, reorg.Depth
 	if slot < depth {
 		log.Warn(ctx, "Invalid chain reorg event: depth exceeds slot", nil, z.U64("slot", slot), z.U64("depth", depth))
 		return errors.New("invalid chain reorg event: depth exceeds slot")
@@ -156,20 +266,311 @@ func (p *listener) handleChainReorgEvent(ctx context.Context, event *event, addr
 
 	reorgEpoch := (slot - depth) / p.slotsPerEpoch
 	p.notifyChainReorg(ctx, eth2p0.Epoch(reorgEpoch))
+	p.markReorg(addr)
+	p.recordReorg(reorg, addr)
 
-	log.Debug(ctx, "SSE chain reorg event",
+	// Block roots are logged rather than used as metric labels to bound label cardinality.
+	log.Info(ctx, "SSE chain reorg event",
 		z.U64("slot", slot),
-		z.Str("epoch", chainReorg.Epoch),
+		z.U64("ancestor_slot", slot-depth),
+		z.U64("epoch", reorg.Epoch),
 		z.U64("reorg_epoch", reorgEpoch),
 		z.U64("depth", depth),
-		z.Str("old_head_block", chainReorg.OldHeadBlock),
-		z.Str("new_head_block", chainReorg.NewHeadBlock))
+		z.Str("old_head_block", reorg.OldHeadBlock),
+		z.Str("new_head_block", reorg.NewHeadBlock),
+		z.Str("old_head_state", reorg.OldHeadState),
+		z.Str("new_head_state", reorg.NewHeadState),
+		z.Str("addr", addr))
 
 	sseChainReorgDepthHistogram.WithLabelValues(addr).Observe(float64(depth))
+	sseLastReorgDepthGauge.WithLabelValues(addr).Set(float64(depth))
+	sseLastReorgSlotGauge.WithLabelValues(addr).Set(float64(slot))
 
 	return nil
 }
 
+func (*listener) handleContributionAndProofEvent(ctx context.Context, contrib ContributionAndProofEvent, addr string) {
+	sseContributionAndProofCounter.WithLabelValues(addr, strconv.FormatUint(contrib.SubcommitteeIndex, 10)).Inc()
+
+	log.Debug(ctx, "SSE contribution and proof event",
+		z.U64("slot", contrib.Slot),
+		z.U64("aggregator_index", contrib.AggregatorIndex),
+		z.U64("subcommittee_index", contrib.SubcommitteeIndex),
+		z.Str("block", contrib.BeaconBlockRoot))
+}
+
+// decodeContributionAndProof returns the contribution and proof message from the event data,
+// supporting both the signed envelope and the bare message.
+func decodeContributionAndProof(data []byte) (contributionAndProofMessage, error) {
+	var signed contributionAndProofData
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return contributionAndProofMessage{}, errors.Wrap(err, "unmarshal signed contribution and proof")
+	}
+	if signed.Message != nil {
+		return *signed.Message, nil
+	}
+
+	var msg contributionAndProofMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return contributionAndProofMessage{}, errors.Wrap(err, "unmarshal contribution and proof")
+	}
+
+	return msg, nil
+}
+
+func (*listener) handleAttesterSlashingEvent(ctx context.Context, slashing AttesterSlashingEvent, addr string) {
+	sseAttesterSlashingCounter.WithLabelValues(addr).Inc()
+
+	log.Warn(ctx, "Beacon node observed attester slashing", nil,
+		z.Any("validator_indices", slashing.ValidatorIndices), z.Str("addr", addr))
+}
+
+func (*listener) handleProposerSlashingEvent(ctx context.Context, slashing ProposerSlashingEvent, addr string) {
+	sseProposerSlashingCounter.WithLabelValues(addr).Inc()
+
+	log.Warn(ctx, "Beacon node observed proposer slashing", nil,
+		z.U64("validator_index", slashing.ValidatorIndex), z.U64("slot", slashing.Slot), z.Str("addr", addr))
+}
+
+func (p *listener) handleFinalizedCheckpointEvent(ctx context.Context, checkpoint FinalizedCheckpointEvent, addr string) {
+	p.Lock()
+	if p.finalized == nil {
+		p.finalized = make(map[string]uint64)
+	}
+	p.finalized[addr] = checkpoint.Epoch
+	p.Unlock()
+
+	p.updateFinalityDistance(addr)
+
+	log.Debug(ctx, "SSE finalized checkpoint event",
+		z.U64("epoch", checkpoint.Epoch),
+		z.Str("block", checkpoint.Block))
+}
+
+func (*listener) handleLightClientFinalityEvent(ctx context.Context, update LightClientFinalityUpdateEvent, addr string) {
+	sseLightClientFinalizedSlotGauge.WithLabelValues(addr).Set(float64(update.FinalizedSlot))
+
+	log.Debug(ctx, "SSE light client finality update event",
+		z.U64("attested_slot", update.AttestedSlot),
+		z.U64("finalized_slot", update.FinalizedSlot))
+}
+
+// decodeLightClientFinalityUpdate returns the attested and finalized header slots of the light client finality update.
+// Payloads wrapped in a versioned envelope are supported.
+func decodeLightClientFinalityUpdate(data []byte) (uint64, uint64, error) {
+	var update lightClientFinalityUpdateData
+	if err := json.Unmarshal(unwrapVersioned(data), &update); err != nil {
+		return 0, 0, errors.Wrap(err, "unmarshal light client finality update")
+	}
+
+	attestedSlot, err := strconv.ParseUint(update.AttestedHeader.slot(), 10, 64)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "parse attested header slot to uint64")
+	}
+	finalizedSlot, err := strconv.ParseUint(update.FinalizedHeader.slot(), 10, 64)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "parse finalized header slot to uint64")
+	}
+
+	return attestedSlot, finalizedSlot, nil
+}
+
+// decodeAttesterSlashing returns the sorted indices of the validators slashed by the attester slashing,
+// i.e. those attesting in both attestations. Payloads wrapped in a versioned envelope are supported.
+func decodeAttesterSlashing(data []byte) ([]uint64, error) {
+	var slashing attesterSlashingData
+	if err := json.Unmarshal(unwrapVersioned(data), &slashing); err != nil {
+		return nil, errors.Wrap(err, "unmarshal attester slashing")
+	}
+
+	attesting := make(map[uint64]bool)
+	for _, idx := range slashing.Attestation1.AttestingIndices {
+		i, err := strconv.ParseUint(idx, 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse attesting index to uint64")
+		}
+		attesting[i] = true
+	}
+
+	var indices []uint64
+	for _, idx := range slashing.Attestation2.AttestingIndices {
+		i, err := strconv.ParseUint(idx, 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse attesting index to uint64")
+		}
+		if attesting[i] {
+			indices = append(indices, i)
+			delete(attesting, i) // Avoid duplicates.
+		}
+	}
+	slices.Sort(indices)
+
+	return indices, nil
+}
+
+// decodeProposerSlashing returns the index of the validator slashed by the proposer slashing and the slot.
+// Payloads wrapped in a versioned envelope are supported.
+func decodeProposerSlashing(data []byte) (uint64, uint64, error) {
+	var slashing proposerSlashingData
+	if err := json.Unmarshal(unwrapVersioned(data), &slashing); err != nil {
+		return 0, 0, errors.Wrap(err, "unmarshal proposer slashing")
+	}
+
+	index, err := strconv.ParseUint(slashing.SignedHeader1.Message.ProposerIndex, 10, 64)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "parse proposer index to uint64")
+	}
+	slot, err := strconv.ParseUint(slashing.SignedHeader1.Message.Slot, 10, 64)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "parse slot to uint64")
+	}
+
+	return index, slot, nil
+}
+
+// unwrapVersioned returns the data of a {"version", "data"} envelope, or the input if it isn't one.
+func unwrapVersioned(data []byte) []byte {
+	var versioned versionedData
+	if err := json.Unmarshal(data, &versioned); err != nil || versioned.Version == "" || len(versioned.Data) == 0 {
+		return data
+	}
+
+	return versioned.Data
+}
+
+// checkHeadOrder records the head event slot of the beacon node and returns the previous head slot and false if
+// the slot is lower than the previous one without a chain reorg event in between. Head events are otherwise
+// delivered in non-decreasing slot order, so this indicates a bug or a proxy shuffling events of multiple nodes.
+func (p *listener) checkHeadOrder(addr string, slot uint64) (uint64, bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.headOrders == nil {
+		p.headOrders = make(map[string]headOrder)
+	}
+
+	prev := p.headOrders[addr]
+	p.headOrders[addr] = headOrder{slot: slot, seen: true}
+
+	return prev.slot, !prev.seen || prev.reorged || slot >= prev.slot
+}
+
+// logSlowHead logs a warning if the head event delay exceeds the slow head threshold.
+// It logs at most once per epoch per beacon node to avoid log spam during sustained slowness.
+func (p *listener) logSlowHead(ctx context.Context, slot uint64, delay time.Duration, addr string) {
+	threshold := p.slowHead
+	if threshold == 0 {
+		threshold = time.Duration(headDelayBuckets[len(headDelayBuckets)-1] * float64(time.Second))
+	}
+
+	if delay <= threshold {
+		return
+	}
+
+	p.Lock()
+	if p.slowHeadLogs == nil {
+		p.slowHeadLogs = make(map[string]z.Field)
+	}
+
+	filter, ok := p.slowHeadLogs[addr]
+	if !ok {
+		epoch := time.Duration(p.slotsPerEpoch) * p.clock.SlotDuration()
+		filter = log.Filter(log.WithFilterRateLimit(rate.Every(epoch)))
+		p.slowHeadLogs[addr] = filter
+	}
+	p.Unlock()
+
+	log.Warn(ctx, "Beacon node head event delay exceeds safe window", nil,
+		z.U64("slot", slot),
+		z.Str("delay", delay.String()),
+		z.Str("threshold", threshold.String()),
+		z.Str("addr", addr),
+		filter)
+}
+
+// updateHeadLag updates the highest head slot observed across all beacon node clients with the received head slot
+// and sets the distance in slots between it and the latest head slot of each beacon node that sent a head event.
+// A persistently positive lag indicates a beacon node trailing the others.
+func (p *listener) updateHeadLag(slot uint64) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.maxHeadSlot = max(p.maxHeadSlot, slot)
+
+	for addr, order := range p.headOrders {
+		if !order.seen {
+			continue
+		}
+
+		sseHeadLagGauge.WithLabelValues(addr).Set(float64(p.maxHeadSlot - order.slot))
+	}
+}
+
+// updateFinalityDistance sets the distance in slots between the head of the beacon node and the last slot of its
+// finalized epoch. It isn't set until both a head and a finalized checkpoint event were received from the beacon node.
+func (p *listener) updateFinalityDistance(addr string) {
+	p.Lock()
+	defer p.Unlock()
+
+	head := p.headOrders[addr]
+	if !head.seen {
+		return
+	}
+	epoch, ok := p.finalized[addr]
+	if !ok {
+		return
+	}
+
+	var distance uint64
+	if lastSlot := (epoch+1)*p.slotsPerEpoch - 1; head.slot > lastSlot {
+		distance = head.slot - lastSlot
+	}
+
+	sseHeadFinalityDistanceGauge.WithLabelValues(addr).Set(float64(distance))
+}
+
+// markReorg records that a chain reorg event was received from the beacon node, allowing the next head to regress.
+func (p *listener) markReorg(addr string) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.headOrders == nil {
+		p.headOrders = make(map[string]headOrder)
+	}
+
+	order := p.headOrders[addr]
+	order.reorged = true
+	p.headOrders[addr] = order
+}
+
+// recordReorg adds the chain reorg event to the recent reorg events of the beacon node,
+// dropping the oldest events exceeding the reorg history.
+func (p *listener) recordReorg(reorg ReorgEvent, addr string) {
+	if p.reorgHistory <= 0 {
+		return
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	if p.reorgs == nil {
+		p.reorgs = make(map[string][]ReorgEvent)
+	}
+
+	history := append(p.reorgs[addr], reorg)
+	if len(history) > p.reorgHistory {
+		history = slices.Delete(history, 0, len(history)-p.reorgHistory)
+	}
+	p.reorgs[addr] = history
+}
+
+func (p *listener) RecentReorgs(addr string) []ReorgEvent {
+	p.Lock()
+	defer p.Unlock()
+
+	return slices.Clone(p.reorgs[addr])
+}
+
 func (p *listener) notifyChainReorg(ctx context.Context, epoch eth2p0.Epoch) {
 	p.Lock()
 	defer p.Unlock()
@@ -186,12 +587,13 @@ func (p *listener) notifyChainReorg(ctx context.Context, epoch eth2p0.Epoch) {
 
 // Compute delay between start of the slot and receiving the head update event.
 func (p *listener) computeDelay(slot uint64, eventTS time.Time) (time.Duration, bool) {
-	slotStartTime := p.genesisTime.Add(time.Duration(slot) * p.slotDuration)
+	slotDuration := p.clock.SlotDuration()
+	slotStartTime := p.clock.SlotStartTime(slot)
 	delay := eventTS.Sub(slotStartTime)
 	// Chain's head is updated upon majority of the chain voting with attestations for a block.
 	// Realistically this happens between 2/3 and 3/3 of the slot's timeframe.
-	delayOK := delay < p.slotDuration
+	delayOK := delay < slotDuration
 
 	// calculate time of receiving the event - the time of start of the slot
-	return delay + p.slotDuration, delayOK
+	return delay + slotDuration, delayOK
 }
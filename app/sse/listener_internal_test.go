@@ -3,14 +3,28 @@
 package sse
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	eth2p0 "github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jonboulle/clockwork"
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	pb "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
 
 	"github.com/obolnetwork/charon/app/errors"
+	"github.com/obolnetwork/charon/app/eth2wrap"
+	"github.com/obolnetwork/charon/app/log"
+	"github.com/obolnetwork/charon/testutil"
 	"github.com/obolnetwork/charon/testutil/beaconmock"
 )
 
@@ -89,9 +103,8 @@ func TestHandleEvents(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			l := &listener{
 				chainReorgSubs: make([]ChainReorgEventHandlerFunc, 0),
-				slotDuration:   12 * time.Second,
+				clock:          eth2wrap.NewSlotClock(clockwork.NewRealClock(), time.Date(2020, 12, 1, 12, 0, 23, 0, time.UTC), 12*time.Second),
 				slotsPerEpoch:  32,
-				genesisTime:    time.Date(2020, 12, 1, 12, 0, 23, 0, time.UTC),
 			}
 
 			err := l.eventHandler(t.Context(), test.event, "test")
@@ -113,6 +126,50 @@ func TestStartListener(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestHeadLag(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	bmock, err := beaconmock.New()
+	require.NoError(t, err)
+
+	// newServer returns a beacon node server sending a chain reorg and a head event for the slot.
+	newServer := func(slot int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = fmt.Fprintf(w, "event: chain_reorg\ndata: {\"slot\":\"%d\",\"depth\":\"1\"}\n\n", slot)
+			_, _ = fmt.Fprintf(w, "event: head\ndata: {\"slot\":\"%d\"}\n\n", slot)
+		}))
+	}
+
+	ahead, behind := newServer(12), newServer(10)
+	defer ahead.Close()
+	defer behind.Close()
+
+	// A beacon node that only sent chain reorgs has no lag.
+	reorgOnly := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprint(w, "event: chain_reorg\ndata: {\"slot\":\"5\",\"depth\":\"1\"}\n\n")
+	}))
+	defer reorgOnly.Close()
+
+	_, err = StartListener(ctx, bmock, []string{ahead.URL, behind.URL, reorgOnly.URL}, nil,
+		WithEvents(sseHeadEvent, sseChainReorgEvent))
+	require.NoError(t, err)
+
+	lag := func(server *httptest.Server) float64 {
+		return promtestutil.ToFloat64(sseHeadLagGauge.WithLabelValues(server.URL))
+	}
+
+	require.Eventually(t, func() bool {
+		return lag(behind) == 2
+	}, time.Second*5, time.Millisecond*10)
+	require.InDelta(t, 0, lag(ahead), 0)
+
+	cancel()
+	require.False(t, sseHeadLagGauge.DeleteLabelValues(reorgOnly.URL))
+}
+
 func TestSubscribeNotifyChainReorg(t *testing.T) {
 	ctx := t.Context()
 	l := &listener{
@@ -166,8 +223,7 @@ func TestComputeDelay(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			l := &listener{
-				genesisTime:   genesisTime,
-				slotDuration:  slotDuration,
+				clock:         eth2wrap.NewSlotClock(clockwork.NewRealClock(), genesisTime, slotDuration),
 				slotsPerEpoch: 32,
 			}
 
@@ -177,3 +233,578 @@ func TestComputeDelay(t *testing.T) {
 		})
 	}
 }
+
+func TestHeadDelayFakeClock(t *testing.T) {
+	const (
+		slot = 5
+		addr = "fake-clock"
+	)
+
+	genesisTime := time.Date(2020, 12, 1, 12, 0, 23, 0, time.UTC)
+	slotDuration := 12 * time.Second
+
+	// Receive the head event 4s into the slot.
+	fakeClock := clockwork.NewFakeClockAt(genesisTime.Add(slot*slotDuration + 4*time.Second))
+	clock := eth2wrap.NewSlotClock(fakeClock, genesisTime, slotDuration)
+
+	cl := &client{clock: clock}
+	r := bufio.NewReader(strings.NewReader("event: head\ndata: {\"slot\":\"5\"}\n\n"))
+	event, err := cl.parseEvent(r)
+	require.NoError(t, err)
+	require.Equal(t, fakeClock.Now(), event.Timestamp)
+
+	l := &listener{
+		clock:         clock,
+		slotsPerEpoch: 32,
+	}
+
+	histogram := func() *pb.Histogram {
+		var m pb.Metric
+		require.NoError(t, sseHeadDelayHistogram.WithLabelValues(addr).(prometheus.Histogram).Write(&m))
+
+		return m.GetHistogram()
+	}
+
+	before := histogram()
+	require.NoError(t, l.eventHandler(t.Context(), event, addr))
+	after := histogram()
+
+	require.EqualValues(t, 1, after.GetSampleCount()-before.GetSampleCount())
+	require.InDelta(t, (slotDuration + 4*time.Second).Seconds(), after.GetSampleSum()-before.GetSampleSum(), 1e-9)
+}
+
+func TestSlowHeadLog(t *testing.T) {
+	var buf zaptest.Buffer
+	log.InitLogfmtForT(t, &buf)
+
+	genesisTime := time.Date(2020, 12, 1, 12, 0, 23, 0, time.UTC)
+	slotDuration := 12 * time.Second
+	clock := eth2wrap.NewSlotClock(clockwork.NewFakeClockAt(genesisTime), genesisTime, slotDuration)
+
+	l := &listener{
+		clock:         clock,
+		slotsPerEpoch: 32,
+	}
+
+	// head sends a head event for the slot received the offset into the slot.
+	head := func(slot uint64, offset time.Duration, addr string) {
+		t.Helper()
+
+		e := &event{
+			Event:     sseHeadEvent,
+			Data:      fmt.Appendf(nil, `{"slot":"%d"}`, slot),
+			Timestamp: genesisTime.Add(time.Duration(slot)*slotDuration + offset),
+		}
+		require.NoError(t, l.eventHandler(t.Context(), e, addr))
+	}
+
+	const msg = `msg="Beacon node head event delay exceeds safe window"`
+
+	// Delays within the top bucket aren't logged.
+	head(1, 7*time.Second, "slow-head-a")
+	require.NotContains(t, buf.String(), msg)
+
+	// Delays exceeding the top bucket are logged.
+	head(2, 10*time.Second, "slow-head-a")
+	require.Equal(t, 1, strings.Count(buf.String(), msg))
+	require.Contains(t, buf.String(), msg+" slot=2 delay=22s threshold=20s addr=slow-head-a")
+
+	// Repeats are rate limited per beacon node.
+	head(3, 10*time.Second, "slow-head-a")
+	head(4, 11*time.Second, "slow-head-a")
+	require.Equal(t, 1, strings.Count(buf.String(), msg))
+
+	head(3, 10*time.Second, "slow-head-b")
+	require.Equal(t, 2, strings.Count(buf.String(), msg))
+	require.Contains(t, buf.String(), "addr=slow-head-b")
+
+	// Heads arriving more than a full slot late are logged too.
+	head(4, 15*time.Second, "slow-head-d")
+	require.Equal(t, 3, strings.Count(buf.String(), msg))
+	require.Contains(t, buf.String(), msg+" slot=4 delay=27s threshold=20s addr=slow-head-d")
+
+	// The threshold is configurable.
+	l.slowHead = 5 * time.Second
+	head(5, 0, "slow-head-c")
+	require.Equal(t, 4, strings.Count(buf.String(), msg))
+	require.Contains(t, buf.String(), "threshold=5s")
+}
+
+func TestContributionAndProofEvent(t *testing.T) {
+	const addr = "contribution-test"
+
+	signed := func(subcommIdx int) []byte {
+		return fmt.Appendf(nil, `{"message":{"aggregator_index":"997","contribution":{"slot":"100","beacon_block_root":"0xcf8e0d4e9587369b2301d0790347320302cc0943d5a1884560367e8208d920f2","subcommittee_index":"%d","aggregation_bits":"0xffffffffffffffffffffffffffffffff","signature":"0x1b66ac1fb663c9bc59509846d6ec05345bd908eda73e670af888da41af171505cc411d61252fb6cb3fa0017b679f8bb2305b26a285fa2737f175668d0dff91cc1b66ac1fb663c9bc59509846d6ec05345bd908eda73e670af888da41af171505"},"selection_proof":"0x1b66ac1fb663c9bc59509846d6ec05345bd908eda73e670af888da41af171505cc411d61252fb6cb3fa0017b679f8bb2305b26a285fa2737f175668d0dff91cc1b66ac1fb663c9bc59509846d6ec05345bd908eda73e670af888da41af171505"},"signature":"0x1b66ac1fb663c9bc59509846d6ec05345bd908eda73e670af888da41af171505cc411d61252fb6cb3fa0017b679f8bb2305b26a285fa2737f175668d0dff91cc1b66ac1fb663c9bc59509846d6ec05345bd908eda73e670af888da41af171505"}`, subcommIdx)
+	}
+
+	msg, err := decodeContributionAndProof(signed(2))
+	require.NoError(t, err)
+	require.Equal(t, "997", msg.AggregatorIndex)
+	require.Equal(t, "100", msg.Contribution.Slot)
+	require.Equal(t, "2", msg.Contribution.SubcommitteeIndex)
+
+	// The bare message without the signed envelope is also supported.
+	msg, err = decodeContributionAndProof([]byte(`{"aggregator_index":"5","contribution":{"slot":"7","subcommittee_index":"3"}}`))
+	require.NoError(t, err)
+	require.Equal(t, "5", msg.AggregatorIndex)
+	require.Equal(t, "3", msg.Contribution.SubcommitteeIndex)
+
+	count := func(subcommIdx string) float64 {
+		return promtestutil.ToFloat64(sseContributionAndProofCounter.WithLabelValues(addr, subcommIdx))
+	}
+	before0, before1 := count("0"), count("1")
+
+	l := &listener{slotsPerEpoch: 32}
+	for _, subcommIdx := range []int{0, 1, 1} {
+		err := l.eventHandler(t.Context(), &event{Event: sseContributionAndProofEvent, Data: signed(subcommIdx)}, addr)
+		require.NoError(t, err)
+	}
+
+	require.InDelta(t, 1, count("0")-before0, 0)
+	require.InDelta(t, 2, count("1")-before1, 0)
+
+	err = l.eventHandler(t.Context(), &event{Event: sseContributionAndProofEvent, Data: []byte(`{"message":{"aggregator_index":"x"}}`)}, addr)
+	require.ErrorContains(t, err, "parse slot to uint64")
+}
+
+func TestSlashingEvents(t *testing.T) {
+	const addr = "slashing-test"
+
+	attesterSlashing := []byte(`{"attestation_1":{"attesting_indices":["3","1","7"],"data":{"slot":"1","index":"0","beacon_block_root":"0x01","source":{"epoch":"1","root":"0x01"},"target":{"epoch":"1","root":"0x01"}},"signature":"0x01"},"attestation_2":{"attesting_indices":["7","2","3"],"data":{"slot":"1","index":"0","beacon_block_root":"0x02","source":{"epoch":"1","root":"0x01"},"target":{"epoch":"1","root":"0x02"}},"signature":"0x02"}}`)
+	proposerSlashing := []byte(`{"signed_header_1":{"message":{"slot":"42","proposer_index":"9","parent_root":"0x01","state_root":"0x01","body_root":"0x01"},"signature":"0x01"},"signed_header_2":{"message":{"slot":"42","proposer_index":"9","parent_root":"0x02","state_root":"0x02","body_root":"0x02"},"signature":"0x02"}}`)
+
+	indices, err := decodeAttesterSlashing(attesterSlashing)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{3, 7}, indices)
+
+	// Versioned envelopes are supported.
+	indices, err = decodeAttesterSlashing([]byte(`{"version":"electra","data":` + string(attesterSlashing) + `}`))
+	require.NoError(t, err)
+	require.Equal(t, []uint64{3, 7}, indices)
+
+	index, slot, err := decodeProposerSlashing(proposerSlashing)
+	require.NoError(t, err)
+	require.EqualValues(t, 9, index)
+	require.EqualValues(t, 42, slot)
+
+	index, _, err = decodeProposerSlashing([]byte(`{"version":"deneb","data":` + string(proposerSlashing) + `}`))
+	require.NoError(t, err)
+	require.EqualValues(t, 9, index)
+
+	_, err = decodeAttesterSlashing([]byte(`{"attestation_1":{"attesting_indices":["x"]}}`))
+	require.ErrorContains(t, err, "parse attesting index")
+
+	attBefore := promtestutil.ToFloat64(sseAttesterSlashingCounter.WithLabelValues(addr))
+	proBefore := promtestutil.ToFloat64(sseProposerSlashingCounter.WithLabelValues(addr))
+
+	l := &listener{slotsPerEpoch: 32}
+	require.NoError(t, l.eventHandler(t.Context(), &event{Event: sseAttesterSlashingEvent, Data: attesterSlashing}, addr))
+	require.NoError(t, l.eventHandler(t.Context(), &event{Event: sseProposerSlashingEvent, Data: proposerSlashing}, addr))
+
+	require.InDelta(t, 1, promtestutil.ToFloat64(sseAttesterSlashingCounter.WithLabelValues(addr))-attBefore, 0)
+	require.InDelta(t, 1, promtestutil.ToFloat64(sseProposerSlashingCounter.WithLabelValues(addr))-proBefore, 0)
+}
+
+func TestListenerReady(t *testing.T) {
+	genesisTime := time.Date(2020, 12, 1, 12, 0, 23, 0, time.UTC)
+	fakeClock := clockwork.NewFakeClockAt(genesisTime.Add(time.Hour))
+
+	newListener := func(opts ...Option) (*listener, *client) {
+		c := &client{clock: fakeClock}
+
+		return &listener{
+			clock:         eth2wrap.NewSlotClock(fakeClock, genesisTime, 12*time.Second),
+			slotsPerEpoch: 32,
+			clients:       []*client{c, {clock: fakeClock}},
+			readyWindow:   defaultOptions(opts...).readyWindow,
+		}, c
+	}
+
+	t.Run("disconnected", func(t *testing.T) {
+		l, c := newListener()
+		c.head = headSnapshot{at: fakeClock.Now()}
+
+		ok, err := l.Ready()
+		require.False(t, ok)
+		require.ErrorContains(t, err, "no connected SSE streams")
+	})
+
+	t.Run("connected without head", func(t *testing.T) {
+		l, c := newListener()
+		c.connected.Store(true)
+
+		ok, err := l.Ready()
+		require.False(t, ok)
+		require.ErrorContains(t, err, "no SSE head events received")
+	})
+
+	t.Run("connected and fresh", func(t *testing.T) {
+		l, c := newListener()
+		c.connected.Store(true)
+		c.head = headSnapshot{at: fakeClock.Now().Add(-20 * time.Second)}
+
+		ok, err := l.Ready()
+		require.True(t, ok)
+		require.NoError(t, err)
+	})
+
+	t.Run("connected but stale", func(t *testing.T) {
+		l, c := newListener()
+		c.connected.Store(true)
+		c.head = headSnapshot{at: fakeClock.Now().Add(-30 * time.Second)}
+
+		ok, err := l.Ready()
+		require.False(t, ok)
+		require.ErrorContains(t, err, "stale SSE head events")
+	})
+
+	t.Run("custom freshness window", func(t *testing.T) {
+		l, c := newListener(WithReadyFreshness(time.Minute))
+		c.connected.Store(true)
+		c.head = headSnapshot{at: fakeClock.Now().Add(-30 * time.Second)}
+
+		ok, err := l.Ready()
+		require.True(t, ok)
+		require.NoError(t, err)
+	})
+}
+
+func TestClientReadinessState(t *testing.T) {
+	fakeClock := clockwork.NewFakeClock()
+	connected := make(chan bool, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprintf(w, "event: head\ndata: {\"slot\":\"1\",\"block\":\"%#x\"}\n\n", testutil.RandomRoot())
+	}))
+	defer server.Close()
+
+	cl, err := newClient(server.URL, make(http.Header), fakeClock)
+	require.NoError(t, err)
+
+	_ = cl.connect(t.Context(), func(context.Context, *event, string) error {
+		connected <- cl.connected.Load()
+		return nil
+	})
+
+	require.True(t, <-connected)
+	require.False(t, cl.connected.Load())
+
+	head, ok := cl.lastHeadTime()
+	require.True(t, ok)
+	require.True(t, head.Equal(fakeClock.Now()))
+}
+
+func TestLastHead(t *testing.T) {
+	fakeClock := clockwork.NewFakeClock()
+	root1, root2 := testutil.RandomRoot(), testutil.RandomRoot()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprintf(w, "event: head\ndata: {\"slot\":\"1\",\"block\":\"%#x\"}\n\n", root1)
+		_, _ = fmt.Fprintf(w, "event: head\ndata: {\"slot\":\"2\",\"block\":\"%#x\"}\n\n", root2)
+		_, _ = fmt.Fprint(w, "event: head\ndata: {\"slot\":\"3\",\"block\":\"invalid\"}\n\n")
+	}))
+	defer server.Close()
+
+	cl, err := newClient(server.URL, make(http.Header), fakeClock)
+	require.NoError(t, err)
+	l := &listener{clients: []*client{cl, {clock: fakeClock}}}
+
+	_, _, _, ok := cl.LastHead()
+	require.False(t, ok)
+	_, _, _, ok = l.LastHead()
+	require.False(t, ok)
+
+	var first time.Time
+	_ = cl.connect(t.Context(), func(context.Context, *event, string) error {
+		if first.IsZero() {
+			first = fakeClock.Now()
+			fakeClock.Advance(time.Second) // Events are timestamped when parsing starts.
+		}
+
+		return nil
+	})
+
+	// The invalid head is ignored.
+	slot, root, at, ok := cl.LastHead()
+	require.True(t, ok)
+	require.EqualValues(t, 2, slot)
+	require.Equal(t, root2, root)
+	require.True(t, at.Equal(first.Add(time.Second)))
+
+	lSlot, lRoot, lAt, ok := l.LastHead()
+	require.True(t, ok)
+	require.Equal(t, slot, lSlot)
+	require.Equal(t, root, lRoot)
+	require.Equal(t, at, lAt)
+}
+
+func TestLightClientFinalityUpdateEvent(t *testing.T) {
+	const addr = "light-client-test"
+
+	// Representative deneb payload, with branches and sync aggregate truncated.
+	deneb := []byte(`{"version":"deneb","data":{"attested_header":{"beacon":{"slot":"1000","proposer_index":"1","parent_root":"0x01","state_root":"0x01","body_root":"0x01"},"execution":{},"execution_branch":[]},"finalized_header":{"beacon":{"slot":"936","proposer_index":"2","parent_root":"0x02","state_root":"0x02","body_root":"0x02"},"execution":{},"execution_branch":[]},"finality_branch":[],"sync_aggregate":{"sync_committee_bits":"0x01","sync_committee_signature":"0x01"},"signature_slot":"1001"}}`)
+
+	attested, finalized, err := decodeLightClientFinalityUpdate(deneb)
+	require.NoError(t, err)
+	require.EqualValues(t, 1000, attested)
+	require.EqualValues(t, 936, finalized)
+
+	// Altair headers are the beacon header itself.
+	attested, finalized, err = decodeLightClientFinalityUpdate([]byte(`{"attested_header":{"slot":"64"},"finalized_header":{"slot":"32"},"signature_slot":"65"}`))
+	require.NoError(t, err)
+	require.EqualValues(t, 64, attested)
+	require.EqualValues(t, 32, finalized)
+
+	l := &listener{slotsPerEpoch: 32}
+	require.NoError(t, l.eventHandler(t.Context(), &event{Event: sseLightClientFinalityEvent, Data: deneb}, addr))
+	require.InDelta(t, 936, promtestutil.ToFloat64(sseLightClientFinalizedSlotGauge.WithLabelValues(addr)), 0)
+
+	// The topic is only subscribed to if enabled.
+	cl, err := newClient("localhost:5052", nil, clockwork.NewRealClock())
+	require.NoError(t, err)
+	require.NotContains(t, cl.sseURL.Query()["topics"], sseLightClientFinalityEvent)
+
+	cl, err = newClient("localhost:5052", nil, clockwork.NewRealClock(), WithLightClientEvents())
+	require.NoError(t, err)
+	require.Contains(t, cl.sseURL.Query()["topics"], sseLightClientFinalityEvent)
+}
+
+func TestListenerEvents(t *testing.T) {
+	ctx := t.Context()
+	reorg := &event{
+		Event: sseChainReorgEvent,
+		Data:  []byte(`{"slot":"200", "depth":"50", "epoch":"6"}`),
+	}
+
+	var reported []eth2p0.Epoch
+	subscribe := func(l *listener) {
+		l.SubscribeChainReorgEvent(func(_ context.Context, epoch eth2p0.Epoch) {
+			reported = append(reported, epoch)
+		})
+	}
+
+	// Events not configured are not handled.
+	l := &listener{slotsPerEpoch: 32, events: []string{sseHeadEvent}}
+	subscribe(l)
+	require.NoError(t, l.eventHandler(ctx, reorg, "test"))
+	require.Empty(t, reported)
+
+	l = &listener{slotsPerEpoch: 32, events: []string{sseHeadEvent, sseChainReorgEvent}}
+	subscribe(l)
+	require.NoError(t, l.eventHandler(ctx, reorg, "test"))
+	require.Equal(t, []eth2p0.Epoch{4}, reported)
+
+	_, err := StartListener(ctx, nil, nil, nil, WithEvents("unknown"))
+	require.ErrorContains(t, err, "unknown SSE event")
+}
+
+func TestChainReorgEventFields(t *testing.T) {
+	var buf zaptest.Buffer
+	log.InitLogfmtForT(t, &buf)
+
+	const addr = "reorg-test"
+
+	data := []byte(`{"slot":"200", "depth":"3", "old_head_block":"0x9a2fefd2fdb57f74993c7780ea5b9030d2897b615b89f808011ca5aebed54eaf", "new_head_block":"0x76262e91970d375a19bfe8a867288d7b9cde43c8635f598d93d39d041706fc76", "old_head_state":"0x9a2fefd2fdb57f74993c7780ea5b9030d2897b615b89f808011ca5aebed54eaf", "new_head_state":"0x600e852a08c1200654ddf11025f1ceacb3c2e74bdd5c630cde0838b2591b69f9", "epoch":"6", "execution_optimistic": false}`)
+
+	var decoded chainReorgData
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	l := &listener{slotsPerEpoch: 32}
+	require.NoError(t, l.eventHandler(t.Context(), &event{Event: sseChainReorgEvent, Data: data}, addr))
+
+	logged := buf.String()
+	for _, field := range []string{
+		"slot=200",
+		"ancestor_slot=197",
+		"depth=3",
+		"epoch=6",
+		"reorg_epoch=6",
+		"old_head_block=" + decoded.OldHeadBlock,
+		"new_head_block=" + decoded.NewHeadBlock,
+		"old_head_state=" + decoded.OldHeadState,
+		"new_head_state=" + decoded.NewHeadState,
+	} {
+		require.Contains(t, logged, field)
+	}
+
+	require.InDelta(t, 3, promtestutil.ToFloat64(sseLastReorgDepthGauge.WithLabelValues(addr)), 0)
+	require.InDelta(t, 200, promtestutil.ToFloat64(sseLastReorgSlotGauge.WithLabelValues(addr)), 0)
+}
+
+func TestHeadOutOfOrder(t *testing.T) {
+	genesisTime := time.Date(2020, 12, 1, 12, 0, 23, 0, time.UTC)
+	clock := eth2wrap.NewSlotClock(clockwork.NewFakeClockAt(genesisTime), genesisTime, 12*time.Second)
+
+	head := func(slot uint64) *event {
+		return &event{Event: sseHeadEvent, Data: fmt.Appendf(nil, `{"slot":"%d"}`, slot)}
+	}
+	reorg := func(slot uint64) *event {
+		return &event{Event: sseChainReorgEvent, Data: fmt.Appendf(nil, `{"slot":"%d","depth":"1"}`, slot)}
+	}
+
+	tests := []struct {
+		name   string
+		events []*event
+		expect float64
+	}{
+		{
+			name:   "in order",
+			events: []*event{head(10), head(11), head(11), head(13)},
+		},
+		{
+			name:   "reorg",
+			events: []*event{head(10), head(12), reorg(11), head(11), head(12)},
+		},
+		{
+			name:   "spurious backwards",
+			events: []*event{head(10), head(12), head(11), head(13)},
+			expect: 1,
+		},
+		{
+			name:   "backwards after reorg head",
+			events: []*event{head(12), reorg(11), head(11), head(10)},
+			expect: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addr := "out-of-order-" + test.name
+			l := &listener{clock: clock, slotsPerEpoch: 32}
+
+			for _, e := range test.events {
+				require.NoError(t, l.eventHandler(t.Context(), e, addr))
+			}
+
+			require.InDelta(t, test.expect, promtestutil.ToFloat64(sseOutOfOrderCounter.WithLabelValues(addr)), 0)
+		})
+	}
+}
+
+func TestHeadFinalityDistance(t *testing.T) {
+	genesisTime := time.Date(2020, 12, 1, 12, 0, 23, 0, time.UTC)
+	clock := eth2wrap.NewSlotClock(clockwork.NewFakeClockAt(genesisTime), genesisTime, 12*time.Second)
+
+	head := func(slot uint64) *event {
+		return &event{Event: sseHeadEvent, Data: fmt.Appendf(nil, `{"slot":"%d"}`, slot)}
+	}
+	finalized := func(epoch uint64) *event {
+		return &event{Event: sseFinalizedCheckpointEvent, Data: fmt.Appendf(nil, `{"block":"0xaa","state":"0xbb","epoch":"%d"}`, epoch)}
+	}
+	reorg := func(slot uint64) *event {
+		return &event{Event: sseChainReorgEvent, Data: fmt.Appendf(nil, `{"slot":"%d","depth":"1"}`, slot)}
+	}
+
+	tests := []struct {
+		name   string
+		events []*event
+		expect float64 // Negative if not published.
+	}{
+		{
+			name:   "head only",
+			events: []*event{head(100)},
+			expect: -1,
+		},
+		{
+			name:   "finalized only",
+			events: []*event{finalized(2)},
+			expect: -1,
+		},
+		{
+			name:   "head then finalized",
+			events: []*event{head(100), finalized(1)},
+			expect: 100 - 63,
+		},
+		{
+			name:   "finalized then heads",
+			events: []*event{finalized(1), head(100), head(101)},
+			expect: 101 - 63,
+		},
+		{
+			name:   "finality advances",
+			events: []*event{head(100), finalized(1), finalized(2)},
+			expect: 100 - 95,
+		},
+		{
+			name:   "reorg then finalized",
+			events: []*event{reorg(100), finalized(1)},
+			expect: -1,
+		},
+		{
+			name:   "reorg then finalized then head",
+			events: []*event{reorg(100), finalized(1), head(100)},
+			expect: 100 - 63,
+		},
+		{
+			name:   "head within finalized epoch",
+			events: []*event{finalized(3), head(100)},
+			expect: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addr := "finality-distance-" + test.name
+			l := &listener{clock: clock, slotsPerEpoch: 32}
+
+			for _, e := range test.events {
+				require.NoError(t, l.eventHandler(t.Context(), e, addr))
+			}
+
+			if test.expect < 0 {
+				// Deleting reports whether the series of the address exists.
+				require.False(t, sseHeadFinalityDistanceGauge.DeleteLabelValues(addr))
+				return
+			}
+
+			require.InDelta(t, test.expect, promtestutil.ToFloat64(sseHeadFinalityDistanceGauge.WithLabelValues(addr)), 0)
+		})
+	}
+}
+
+func TestRecentReorgs(t *testing.T) {
+	const addr = "recent-reorgs"
+
+	reorg := func(slot uint64) *event {
+		return &event{
+			Event:     sseChainReorgEvent,
+			Data:      fmt.Appendf(nil, `{"slot":"%d", "depth":"1", "old_head_block":"0x9a2fefd2fdb57f74993c7780ea5b9030d2897b615b89f808011ca5aebed54eaf", "new_head_block":"0x76262e91970d375a19bfe8a867288d7b9cde43c8635f598d93d39d041706fc76", "old_head_state":"0x9a2fefd2fdb57f74993c7780ea5b9030d2897b615b89f808011ca5aebed54eaf", "new_head_state":"0x600e852a08c1200654ddf11025f1ceacb3c2e74bdd5c630cde0838b2591b69f9", "epoch":"0", "execution_optimistic": false}`, slot),
+			Timestamp: time.Unix(int64(slot), 0),
+		}
+	}
+
+	slots := func(reorgs []ReorgEvent) []uint64 {
+		var resp []uint64
+		for _, reorg := range reorgs {
+			resp = append(resp, reorg.Slot)
+		}
+
+		return resp
+	}
+
+	l := &listener{slotsPerEpoch: 32, reorgHistory: 3}
+	require.Empty(t, l.RecentReorgs(addr))
+
+	for slot := uint64(10); slot < 15; slot++ {
+		require.NoError(t, l.eventHandler(t.Context(), reorg(slot), addr))
+	}
+
+	// The most recent reorgs are retained, oldest first.
+	reorgs := l.RecentReorgs(addr)
+	require.Equal(t, []uint64{12, 13, 14}, slots(reorgs))
+	require.Equal(t, time.Unix(14, 0), reorgs[2].ArrivalTime())
+	require.Equal(t, "0x76262e91970d375a19bfe8a867288d7b9cde43c8635f598d93d39d041706fc76", reorgs[2].NewHeadBlock)
+	require.Empty(t, l.RecentReorgs("other"))
+
+	// The returned reorgs aren't affected by later reorgs.
+	require.NoError(t, l.eventHandler(t.Context(), reorg(15), addr))
+	require.Equal(t, []uint64{12, 13, 14}, slots(reorgs))
+	require.Equal(t, []uint64{13, 14, 15}, slots(l.RecentReorgs(addr)))
+
+	// Reorgs aren't retained if disabled.
+	l = &listener{slotsPerEpoch: 32}
+	require.NoError(t, l.eventHandler(t.Context(), reorg(10), addr))
+	require.Empty(t, l.RecentReorgs(addr))
+}
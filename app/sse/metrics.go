@@ -8,6 +8,9 @@ import (
 	"github.com/obolnetwork/charon/app/promauto"
 )
 
+// headDelayBuckets are the head delay histogram buckets in seconds.
+var headDelayBuckets = []float64{4, 6, 8, 10, 12, 16, 20}
+
 var (
 	sseHeadSlotGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: "app",
@@ -21,7 +24,92 @@ var (
 		Subsystem: "beacon_node",
 		Name:      "sse_head_delay",
 		Help:      "Delay in seconds between slot start and head update, supplied by beacon node's SSE endpoint. Values between 8s and 12s for Ethereum mainnet are considered safe.",
-		Buckets:   []float64{4, 6, 8, 10, 12, 16, 20},
+		Buckets:   headDelayBuckets,
+	}, []string{"addr"})
+
+	sseConnectHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "app",
+		Subsystem: "beacon_node",
+		Name:      "sse_connect_seconds",
+		Help:      "Duration in seconds from dialing beacon node's SSE endpoint to receiving the response headers, including reconnects",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"addr"})
+
+	sseClockSkewGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "app",
+		Subsystem: "beacon_node",
+		Name:      "clock_skew_seconds",
+		Help:      "Skew in seconds of the local clock ahead of the beacon node's clock, as reported by the SSE endpoint's response date header",
+	}, []string{"addr"})
+
+	sseKeepaliveCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "app",
+		Subsystem: "beacon_node",
+		Name:      "sse_keepalives_total",
+		Help:      "Total number of keepalive comments received from beacon node's SSE endpoint",
+	}, []string{"addr"})
+
+	sseBytesCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "app",
+		Subsystem: "beacon_node",
+		Name:      "sse_bytes_total",
+		Help:      "Total number of bytes received from beacon node's SSE endpoint",
+	}, []string{"addr"})
+
+	sseConnectedGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "app",
+		Subsystem: "beacon_node",
+		Name:      "sse_connected",
+		Help:      "Set to 1 if the beacon node's SSE endpoint is connected, otherwise 0",
+	}, []string{"addr"})
+
+	sseReconnectCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "app",
+		Subsystem: "beacon_node",
+		Name:      "sse_reconnects_total",
+		Help:      "Total number of failed connection attempts to beacon node's SSE endpoint, each reconnected unless the maximum reconnects are reached",
+	}, []string{"addr"})
+
+	sseDecodeErrorsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "app",
+		Subsystem: "beacon_node",
+		Name:      "sse_decode_errors_total",
+		Help:      "Total number of dropped events that failed to decode by event, supplied by beacon node's SSE endpoint",
+	}, []string{"addr", "event"})
+
+	sseOutOfOrderCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "app",
+		Subsystem: "beacon_node",
+		Name:      "sse_out_of_order_total",
+		Help:      "Total number of head events with a lower slot than the previous head without a chain reorg, supplied by beacon node's SSE endpoint",
+	}, []string{"addr"})
+
+	sseHeadDebouncedCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "app",
+		Subsystem: "beacon_node",
+		Name:      "sse_head_debounced_total",
+		Help:      "Total number of suppressed duplicate head events, supplied by beacon node's SSE endpoint",
+	}, []string{"addr"})
+
+	sseLightClientFinalizedSlotGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "app",
+		Subsystem: "beacon_node",
+		Name:      "sse_light_client_finalized_slot",
+		Help:      "Finalized header slot of the latest light client finality update, supplied by beacon node's SSE endpoint",
+	}, []string{"addr"})
+
+	sseHeadFinalityDistanceGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "app",
+		Subsystem: "beacon_node",
+		Name:      "sse_head_finality_distance_slots",
+		Help:      "Distance in slots between the head slot and the last slot of the finalized epoch, supplied by beacon node's SSE endpoint. A growing distance indicates a finality stall.",
+	}, []string{"addr"})
+
+	sseHeadLagGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "app",
+		Subsystem: "beacon_node",
+		Name:      "sse_head_lag_slots",
+		Help:      "Distance in slots between the highest head slot across all beacon nodes and the head slot of the beacon node, supplied by beacon node's SSE endpoint. A persistently positive lag indicates a trailing beacon node.",
 	}, []string{"addr"})
 
 	sseChainReorgDepthHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
@@ -31,4 +119,39 @@ var (
 		Help:      "Chain reorg depth, supplied by beacon node's SSE endpoint",
 		Buckets:   []float64{1, 2, 4, 6, 8, 16},
 	}, []string{"addr"})
+
+	sseLastReorgDepthGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "app",
+		Subsystem: "beacon_node",
+		Name:      "sse_last_reorg_depth",
+		Help:      "Depth of the last chain reorg, supplied by beacon node's SSE endpoint",
+	}, []string{"addr"})
+
+	sseLastReorgSlotGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "app",
+		Subsystem: "beacon_node",
+		Name:      "sse_last_reorg_slot",
+		Help:      "New head slot of the last chain reorg, supplied by beacon node's SSE endpoint",
+	}, []string{"addr"})
+
+	sseContributionAndProofCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "app",
+		Subsystem: "beacon_node",
+		Name:      "sse_contribution_and_proof_total",
+		Help:      "Total number of sync committee contribution and proofs by subcommittee, supplied by beacon node's SSE endpoint if subscribed to the opt-in contribution_and_proof event",
+	}, []string{"addr", "subcommittee"})
+
+	sseAttesterSlashingCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "app",
+		Subsystem: "beacon_node",
+		Name:      "sse_attester_slashing_total",
+		Help:      "Total number of attester slashings, supplied by beacon node's SSE endpoint if subscribed to the opt-in attester_slashing event",
+	}, []string{"addr"})
+
+	sseProposerSlashingCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "app",
+		Subsystem: "beacon_node",
+		Name:      "sse_proposer_slashing_total",
+		Help:      "Total number of proposer slashings, supplied by beacon node's SSE endpoint if subscribed to the opt-in proposer_slashing event",
+	}, []string{"addr"})
 )
@@ -0,0 +1,235 @@
+// Copyright © 2022-2025 Obol Labs Inc. Licensed under the terms of a Business Source License 1.1
+
+package sse
+
+import (
+	"crypto/tls"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/obolnetwork/charon/app/errors"
+	"github.com/obolnetwork/charon/app/version"
+	"github.com/obolnetwork/charon/app/z"
+)
+
+type options struct {
+	userAgent     string
+	proxyURL      string
+	debounceHeads bool
+	readyWindow   time.Duration
+	slowHead      time.Duration // Zero defaults to the top head delay bucket.
+	lightClient   bool
+	events        []string // Nil for the default events.
+	unknownEvents bool
+	idleTimeout   time.Duration
+	certFile      string // Empty if no client certificate is presented.
+	keyFile       string
+	defaultEvent  string
+	maxReconnects int    // Zero retries forever.
+	reorgHistory  int    // Zero or negative disables the reorg history.
+	endpointPath  string // Empty for the standard events path.
+}
+
+// defaultEndpointPath is the standard beacon node API path of the SSE events endpoint.
+const defaultEndpointPath = "/eth/v1/events"
+
+// Option configures the SSE listener and its clients.
+type Option func(*options)
+
+// WithUserAgent returns an option overriding the User-Agent header sent in SSE requests.
+// It defaults to charon/<version>.
+func WithUserAgent(ua string) Option {
+	return func(o *options) {
+		o.userAgent = ua
+	}
+}
+
+// WithProxy returns an option routing SSE requests, including reconnects, via the HTTP, HTTPS
+// or SOCKS5 proxy URL. It defaults to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+// It doesn't apply to unix socket addresses.
+func WithProxy(proxyURL string) Option {
+	return func(o *options) {
+		o.proxyURL = proxyURL
+	}
+}
+
+// WithHeadDebounce returns an option suppressing repeated head events for the same slot and block root
+// from a single beacon node, which some emit on minor fork choice updates. Distinct block roots for
+// the same slot are not suppressed.
+func WithHeadDebounce() Option {
+	return func(o *options) {
+		o.debounceHeads = true
+	}
+}
+
+// WithReadyFreshness returns an option configuring the window within which the last head event must have
+// been received for Listener.Ready to report ready. It defaults to two slots.
+func WithReadyFreshness(window time.Duration) Option {
+	return func(o *options) {
+		o.readyWindow = window
+	}
+}
+
+// WithSlowHeadThreshold returns an option configuring the head event delay above which a warning is logged,
+// at most once per epoch per beacon node. It defaults to the top sse_head_delay histogram bucket, 20s.
+func WithSlowHeadThreshold(threshold time.Duration) Option {
+	return func(o *options) {
+		o.slowHead = threshold
+	}
+}
+
+// WithLightClientEvents returns an option subscribing to light_client_finality_update events.
+// It is disabled by default since not all beacon nodes support the topic and subscribing to unsupported topics can fail.
+func WithLightClientEvents() Option {
+	return func(o *options) {
+		o.lightClient = true
+	}
+}
+
+// WithEvents returns an option configuring the SSE events subscribed to and handled, replacing the default
+// events: head, chain_reorg and finalized_checkpoint. Other events are opt-in, including contribution_and_proof
+// and attester_slashing/proposer_slashing required by the respective sse_*_total metrics.
+// Unknown events are rejected unless WithUnknownEvents is provided.
+func WithEvents(events ...string) Option {
+	return func(o *options) {
+		o.events = append([]string{}, events...) // Non-nil even if empty.
+	}
+}
+
+// WithUnknownEvents returns an option allowing events not known to charon in WithEvents.
+// Unknown events are subscribed to but not handled.
+func WithUnknownEvents() Option {
+	return func(o *options) {
+		o.unknownEvents = true
+	}
+}
+
+// WithIdleTimeout returns an option reconnecting SSE streams that receive neither events nor keepalive
+// comments within the timeout. It is disabled by default since not all beacon nodes send keepalives.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = timeout
+	}
+}
+
+// WithClientCert returns an option presenting the PEM encoded client certificate and key to beacon nodes requiring
+// mutual TLS, on all connections including reconnects. The files are loaded when creating the clients.
+// It doesn't apply to unix socket addresses.
+func WithClientCert(certFile, keyFile string) Option {
+	return func(o *options) {
+		o.certFile = certFile
+		o.keyFile = keyFile
+	}
+}
+
+// WithDefaultEvent returns an option dispatching events without an event field under the event name,
+// e.g. "head" for beacon node proxies omitting the field for head events. It defaults to "message", as
+// defined by the SSE spec, which isn't handled.
+func WithDefaultEvent(name string) Option {
+	return func(o *options) {
+		o.defaultEvent = name
+	}
+}
+
+// WithMaxReconnects returns an option stopping SSE clients with an error after n consecutive failed connection
+// attempts, so the supervising process can decide how to proceed, e.g. by exiting to be restarted. A successful
+// connection resets the count. It defaults to zero which retries forever.
+func WithMaxReconnects(n int) Option {
+	return func(o *options) {
+		o.maxReconnects = n
+	}
+}
+
+// WithReorgHistory returns an option configuring the number of most recent chain reorg events retained per beacon node
+// for Listener.RecentReorgs. It defaults to 8, zero disables retaining reorg events.
+func WithReorgHistory(n int) Option {
+	return func(o *options) {
+		o.reorgHistory = n
+	}
+}
+
+// WithEndpointPath returns an option configuring the path of the SSE events endpoint for beacon nodes or proxies
+// serving it at a non-standard path. The topics are still appended as query parameters. It defaults to /eth/v1/events.
+func WithEndpointPath(path string) Option {
+	return func(o *options) {
+		o.endpointPath = path
+	}
+}
+
+// endpoint returns the path of the SSE events endpoint or an error if the configured path is invalid.
+func (o options) endpoint() (string, error) {
+	if o.endpointPath == "" {
+		return defaultEndpointPath, nil
+	}
+
+	u, err := url.Parse(o.endpointPath)
+	if err != nil {
+		return "", errors.Wrap(err, "parse SSE endpoint path", z.Str("path", o.endpointPath))
+	} else if !strings.HasPrefix(o.endpointPath, "/") || u.Path != o.endpointPath {
+		return "", errors.New("invalid SSE endpoint path, must be an absolute path without query or fragment",
+			z.Str("path", o.endpointPath))
+	}
+
+	return u.Path, nil
+}
+
+// tlsConfig returns the TLS config presenting the configured client certificate, or nil if none is configured.
+func (o options) tlsConfig() (*tls.Config, error) {
+	if o.certFile == "" && o.keyFile == "" {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(o.certFile, o.keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "load SSE client certificate", z.Str("cert_file", o.certFile), z.Str("key_file", o.keyFile))
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// topics returns the SSE topics to subscribe to or an error if an unknown event is configured.
+func (o options) topics() ([]string, error) {
+	events := o.events
+	if events == nil {
+		events = defaultEvents
+	}
+
+	var topics []string
+	for _, event := range events {
+		if !slices.Contains(knownEvents, event) && !o.unknownEvents {
+			return nil, errors.New("unknown SSE event", z.Str("event", event))
+		}
+		if !slices.Contains(topics, event) {
+			topics = append(topics, event)
+		}
+	}
+
+	if o.lightClient && !slices.Contains(topics, sseLightClientFinalityEvent) {
+		topics = append(topics, sseLightClientFinalityEvent)
+	}
+
+	if len(topics) == 0 {
+		return nil, errors.New("no SSE events configured")
+	}
+
+	return topics, nil
+}
+
+// defaultOptions returns the default options with opts applied.
+func defaultOptions(opts ...Option) options {
+	o := options{
+		userAgent:    "charon/" + version.Version.String(),
+		defaultEvent: sseMessageEvent,
+		reorgHistory: defaultReorgHistory,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
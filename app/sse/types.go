@@ -2,11 +2,49 @@
 
 package sse
 
+import "encoding/json"
+
 const (
-	sseHeadEvent       = "head"
-	sseChainReorgEvent = "chain_reorg"
+	sseHeadEvent                 = "head"
+	sseChainReorgEvent           = "chain_reorg"
+	sseContributionAndProofEvent = "contribution_and_proof"
+	sseAttesterSlashingEvent     = "attester_slashing"
+	sseProposerSlashingEvent     = "proposer_slashing"
+	sseLightClientFinalityEvent  = "light_client_finality_update"
+	sseFinalizedCheckpointEvent  = "finalized_checkpoint"
+
+	// sseMessageEvent is the type of events without an event field, as defined by the SSE spec.
+	sseMessageEvent = "message"
 )
 
+// defaultEvents are the SSE events subscribed to by default, other known events are opt-in via WithEvents.
+// The finalized_checkpoint event is essential for the head finality distance metric.
+var defaultEvents = []string{
+	sseHeadEvent,
+	sseChainReorgEvent,
+	sseFinalizedCheckpointEvent,
+}
+
+// knownEvents are the SSE events defined by the beacon node API.
+var knownEvents = []string{
+	sseHeadEvent,
+	"block",
+	"block_gossip",
+	"attestation",
+	"single_attestation",
+	"voluntary_exit",
+	"bls_to_execution_change",
+	sseProposerSlashingEvent,
+	sseAttesterSlashingEvent,
+	sseFinalizedCheckpointEvent,
+	sseChainReorgEvent,
+	sseContributionAndProofEvent,
+	sseLightClientFinalityEvent,
+	"light_client_optimistic_update",
+	"payload_attributes",
+	"blob_sidecar",
+}
+
 type headEventData struct {
 	Slot                      string `json:"slot"`
 	Block                     string `json:"block"`
@@ -27,3 +65,83 @@ type chainReorgData struct {
 	Epoch               string `json:"epoch"`
 	ExecutionOptimistic bool   `json:"execution_optimistic"`
 }
+
+type finalizedCheckpointData struct {
+	Block               string `json:"block"`
+	State               string `json:"state"`
+	Epoch               string `json:"epoch"`
+	ExecutionOptimistic bool   `json:"execution_optimistic"`
+}
+
+// contributionAndProofData is the signed contribution and proof event payload.
+// Message is nil if the beacon node sends the unsigned message without the signed envelope.
+type contributionAndProofData struct {
+	Message   *contributionAndProofMessage `json:"message"`
+	Signature string                       `json:"signature"`
+}
+
+type contributionAndProofMessage struct {
+	AggregatorIndex string                    `json:"aggregator_index"`
+	Contribution    syncCommitteeContribution `json:"contribution"`
+	SelectionProof  string                    `json:"selection_proof"`
+}
+
+type syncCommitteeContribution struct {
+	Slot              string `json:"slot"`
+	BeaconBlockRoot   string `json:"beacon_block_root"`
+	SubcommitteeIndex string `json:"subcommittee_index"`
+	AggregationBits   string `json:"aggregation_bits"`
+	Signature         string `json:"signature"`
+}
+
+// versionedData is the envelope some beacon nodes wrap fork versioned event payloads in.
+type versionedData struct {
+	Version string          `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type attesterSlashingData struct {
+	Attestation1 indexedAttestation `json:"attestation_1"`
+	Attestation2 indexedAttestation `json:"attestation_2"`
+}
+
+// indexedAttestation only includes the fields that are identical across forks.
+type indexedAttestation struct {
+	AttestingIndices []string `json:"attesting_indices"`
+}
+
+type proposerSlashingData struct {
+	SignedHeader1 signedBeaconBlockHeader `json:"signed_header_1"`
+	SignedHeader2 signedBeaconBlockHeader `json:"signed_header_2"`
+}
+
+type signedBeaconBlockHeader struct {
+	Message struct {
+		Slot          string `json:"slot"`
+		ProposerIndex string `json:"proposer_index"`
+	} `json:"message"`
+}
+
+type lightClientFinalityUpdateData struct {
+	AttestedHeader  lightClientHeader `json:"attested_header"`
+	FinalizedHeader lightClientHeader `json:"finalized_header"`
+	SignatureSlot   string            `json:"signature_slot"`
+}
+
+// lightClientHeader supports both the capella and later header with a nested beacon header
+// and the altair header which is the beacon header itself.
+type lightClientHeader struct {
+	Beacon *struct {
+		Slot string `json:"slot"`
+	} `json:"beacon"`
+	Slot string `json:"slot"`
+}
+
+// slot returns the slot of the beacon header.
+func (h lightClientHeader) slot() string {
+	if h.Beacon != nil {
+		return h.Beacon.Slot
+	}
+
+	return h.Slot
+}
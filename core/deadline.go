@@ -39,6 +39,16 @@ type Deadliner interface {
 	C() <-chan Duty
 }
 
+// HorizonDeadliner is optionally implemented by Deadliners exposing their horizon, the maximum
+// duration after the start of a duty's slot until the duty is deadlined. Use a type assertion to check for it.
+// Deadliners returned by NewHorizonDeadliner implement it.
+type HorizonDeadliner interface {
+	Deadliner
+
+	// Horizon returns the maximum duration after the start of a duty's slot until it is deadlined.
+	Horizon() time.Duration
+}
+
 // deadlinerInput represents the input to inputChan.
 type deadlineInput struct {
 	duty    Duty
@@ -69,6 +79,39 @@ func NewDeadliner(ctx context.Context, label string, deadlineFunc DeadlineFunc)
 	return newDeadliner(ctx, label, deadlineFunc, clockwork.NewRealClock())
 }
 
+// NewHorizonDeadliner returns a new instance of Deadline exposing the horizon of the deadline function,
+// see HorizonDeadliner and DutyDeadlineHorizon.
+func NewHorizonDeadliner(ctx context.Context, label string, deadlineFunc DeadlineFunc, horizon time.Duration) HorizonDeadliner {
+	return horizonDeadliner{
+		Deadliner: NewDeadliner(ctx, label, deadlineFunc),
+		horizon:   horizon,
+	}
+}
+
+// horizonDeadliner wraps a Deadliner with its horizon.
+type horizonDeadliner struct {
+	Deadliner
+
+	horizon time.Duration
+}
+
+func (d horizonDeadliner) Horizon() time.Duration {
+	return d.horizon
+}
+
+// DutyDeadlineHorizon returns the horizon of the duty deadlines provided by NewDutyDeadlineFunc,
+// i.e. the maximum duration after the start of a duty's slot until it is deadlined.
+func DutyDeadlineHorizon(slotDuration time.Duration) time.Duration {
+	var horizon time.Duration
+	for _, typ := range AllDutyTypes() {
+		if duration, ok := dutyDeadlineDuration(typ, slotDuration); ok {
+			horizon = max(horizon, duration)
+		}
+	}
+
+	return horizon
+}
+
 // NewDutyDeadlineFunc returns the function that provides duty deadlines or false if the duty never deadlines.
 func NewDutyDeadlineFunc(ctx context.Context, eth2Cl eth2wrap.Client) (DeadlineFunc, error) {
 	genesisTime, err := eth2wrap.FetchGenesisTime(ctx, eth2Cl)
@@ -81,35 +124,47 @@ func NewDutyDeadlineFunc(ctx context.Context, eth2Cl eth2wrap.Client) (DeadlineF
 	}
 
 	return func(duty Duty) (time.Time, bool) {
-		switch duty.Type {
-		case DutyExit, DutyBuilderRegistration:
-			// Do not timeout exit or registration duties.
+		duration, ok := dutyDeadlineDuration(duty.Type, slotDuration)
+		if !ok {
 			return time.Time{}, false
-		default:
 		}
 
-		var (
-			start    = genesisTime.Add(slotDuration * time.Duration(duty.Slot))
-			margin   = slotDuration / marginFactor
-			duration time.Duration
-		)
-
-		switch duty.Type {
-		case DutyProposer, DutyRandao:
-			duration = slotDuration / 3
-		case DutySyncMessage:
-			duration = 2 * slotDuration / 3
-		case DutyAttester, DutyAggregator, DutyPrepareAggregator:
-			// Even though attestations and aggregations are acceptable even after 2 slots, the rewards are heavily diminished.
-			duration = 2 * slotDuration
-		default:
-			duration = slotDuration
-		}
+		start := genesisTime.Add(slotDuration * time.Duration(duty.Slot))
 
-		return start.Add(duration + margin), true
+		return start.Add(duration), true
 	}, nil
 }
 
+// dutyDeadlineDuration returns the duration after the start of a duty's slot until it is deadlined,
+// including a margin, or false if duties of the type never deadline.
+func dutyDeadlineDuration(typ DutyType, slotDuration time.Duration) (time.Duration, bool) {
+	switch typ {
+	case DutyExit, DutyBuilderRegistration:
+		// Do not timeout exit or registration duties.
+		return 0, false
+	default:
+	}
+
+	var (
+		margin   = slotDuration / marginFactor
+		duration time.Duration
+	)
+
+	switch typ {
+	case DutyProposer, DutyRandao:
+		duration = slotDuration / 3
+	case DutySyncMessage:
+		duration = 2 * slotDuration / 3
+	case DutyAttester, DutyAggregator, DutyPrepareAggregator:
+		// Even though attestations and aggregations are acceptable even after 2 slots, the rewards are heavily diminished.
+		duration = 2 * slotDuration
+	default:
+		duration = slotDuration
+	}
+
+	return duration + margin, true
+}
+
 // newDeadliner returns a new Deadliner, this is for internal use only.
 func newDeadliner(ctx context.Context, label string, deadlineFunc DeadlineFunc, clock clockwork.Clock) Deadliner {
 	// outputBuffer big enough to support all duty types, which can expire at the same time
@@ -168,6 +168,40 @@ func TestNewDutyDeadlineFunc(t *testing.T) {
 	}
 }
 
+func TestDutyDeadlineHorizon(t *testing.T) {
+	bmock, err := beaconmock.New()
+	require.NoError(t, err)
+
+	genesisTime, err := eth2wrap.FetchGenesisTime(t.Context(), bmock)
+	require.NoError(t, err)
+
+	slotDuration, _, err := eth2wrap.FetchSlotsConfig(t.Context(), bmock)
+	require.NoError(t, err)
+
+	deadlineFunc, err := core.NewDutyDeadlineFunc(t.Context(), bmock)
+	require.NoError(t, err)
+
+	horizon := core.DutyDeadlineHorizon(slotDuration)
+	require.Equal(t, 2*slotDuration+slotDuration/12, horizon)
+
+	// The horizon is the latest deadline of any duty type relative to the start of its slot.
+	const slot = 100
+	var latest time.Duration
+	for _, typ := range core.AllDutyTypes() {
+		deadline, ok := deadlineFunc(core.Duty{Slot: slot, Type: typ})
+		if !ok {
+			continue
+		}
+
+		latest = max(latest, deadline.Sub(genesisTime.Add(slot*slotDuration)))
+	}
+	require.Equal(t, latest, horizon)
+
+	deadliner := core.NewHorizonDeadliner(t.Context(), "test", deadlineFunc, horizon)
+	require.Equal(t, horizon, deadliner.Horizon())
+	require.False(t, deadliner.Add(core.NewAttesterDuty(0)))
+}
+
 // sendDuties runs a goroutine which adds the duties to the deadliner channel.
 func addDuties(t *testing.T, wg *sync.WaitGroup, duties []core.Duty, expCh chan bool, deadliner core.Deadliner) {
 	t.Helper()
@@ -0,0 +1,26 @@
+// Copyright © 2022-2025 Obol Labs Inc. Licensed under the terms of a Business Source License 1.1
+
+package dutydb
+
+import (
+	"github.com/obolnetwork/charon/core"
+)
+
+// NewFakeDB returns a MemDB suitable for tests that doesn't require a real deadliner.
+// All duties are accepted for storing immediately, irrespective of their slot, and never expire,
+// so stored data remains available for the lifetime of the DB and Trim never deletes anything.
+func NewFakeDB(opts ...Option) *MemDB {
+	return NewMemDB(fakeDeadliner{}, opts...)
+}
+
+// fakeDeadliner is a core.Deadliner that accepts all duties and never expires them.
+type fakeDeadliner struct{}
+
+func (fakeDeadliner) Add(core.Duty) bool {
+	return true
+}
+
+// C returns a nil channel which blocks forever.
+func (fakeDeadliner) C() <-chan core.Duty {
+	return nil
+}
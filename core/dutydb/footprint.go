@@ -0,0 +1,53 @@
+// Copyright © 2022-2025 Obol Labs Inc. Licensed under the terms of a Business Source License 1.1
+
+package dutydb
+
+import (
+	"encoding/json"
+
+	"github.com/obolnetwork/charon/core"
+)
+
+// Representative sizes in bytes of stored entries, including map key overhead.
+const (
+	attDataSize      = 128 + 16  // SSZ attestation data and attKey.
+	attPubKeySize    = 48 + 24   // Pubkey and pkKey.
+	aggregateSize    = 512 + 40  // Typical SSZ aggregated attestation and aggKey.
+	contributionSize = 160 + 48  // SSZ sync committee contribution and contribKey.
+	selectionSize    = 120 + 24  // Aggregator selection and selKey.
+	proposalFallback = 128 << 10 // Typical proposal size if it can't be marshalled.
+)
+
+// SlotFootprint returns an approximate number of bytes used by the duties stored for the slot.
+// It isn't exact but grows with the number of stored entries, so it is useful for capacity planning and trending.
+func (db *MemDB) SlotFootprint(slot uint64) int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var footprint int
+
+	if proposal, ok := db.proDuties[slot]; ok {
+		b, err := json.Marshal(core.VersionedProposal{VersionedProposal: *proposal})
+		if err != nil {
+			footprint += proposalFallback
+		} else {
+			footprint += len(b)
+		}
+	}
+
+	shard := db.attShard(slot)
+	shard.mu.Lock()
+	for key := range shard.attDuties {
+		if key.Slot == slot {
+			footprint += attDataSize
+		}
+	}
+	footprint += len(shard.attKeysBySlot[slot]) * attPubKeySize
+	shard.mu.Unlock()
+
+	footprint += len(db.aggKeysBySlot[slot]) * aggregateSize
+	footprint += len(db.contribKeysBySlot[slot]) * contributionSize
+	footprint += len(db.selKeysBySlot[slot]) * selectionSize
+
+	return footprint
+}
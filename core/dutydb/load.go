@@ -0,0 +1,104 @@
+// Copyright © 2022-2025 Obol Labs Inc. Licensed under the terms of a Business Source License 1.1
+
+package dutydb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/obolnetwork/charon/app/errors"
+	"github.com/obolnetwork/charon/app/log"
+	"github.com/obolnetwork/charon/app/z"
+	"github.com/obolnetwork/charon/core"
+)
+
+// loadEntry is a single JSON encoded duty and its unsigned data set as read by LoadFrom.
+type loadEntry struct {
+	Duty core.Duty                       `json:"duty"`
+	Set  map[core.PubKey]json.RawMessage `json:"unsigned_data_set"`
+}
+
+// LoadFrom reads a stream of JSON encoded {"duty", "unsigned_data_set"} entries from r and stores them all.
+// Unlike Store, expired and future duties are not rejected, allowing captured duties to be replayed and fresh DBs
+// to be seeded. Since the deadliner never expires duties it rejected, expired duties are deleted once a duty of
+// the same type at or after their slot expires. The index of the first invalid entry is included in the returned error.
+func (db *MemDB) LoadFrom(r io.Reader) error {
+	db.mu.Lock()
+	expiredBefore := len(db.preloadedExpired)
+	db.mu.Unlock()
+
+	dec := json.NewDecoder(r)
+	for i := 0; ; i++ {
+		var entry loadEntry
+		if err := dec.Decode(&entry); errors.Is(err, io.EOF) {
+			db.mu.Lock()
+			expired := len(db.preloadedExpired) - expiredBefore
+			db.mu.Unlock()
+
+			if expired > 0 {
+				log.Info(context.Background(), "Preloaded expired duties, deleting them once later duties of their type expire",
+					z.Int("expired", expired), z.Int("loaded", i))
+			}
+
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "decode duty entry", z.Int("index", i))
+		}
+
+		set, err := unmarshalUnsignedDataSet(entry.Duty.Type, entry.Set)
+		if err != nil {
+			return errors.Wrap(err, "invalid duty entry", z.Int("index", i), z.Any("duty", entry.Duty))
+		}
+
+		if err := db.store(context.Background(), entry.Duty, set, true); err != nil {
+			return errors.Wrap(err, "store duty entry", z.Int("index", i), z.Any("duty", entry.Duty))
+		}
+	}
+}
+
+// unmarshalUnsignedDataSet returns the JSON encoded unsigned data set of the duty type.
+func unmarshalUnsignedDataSet(typ core.DutyType, raw map[core.PubKey]json.RawMessage) (core.UnsignedDataSet, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("empty unsigned data set")
+	}
+
+	set := make(core.UnsignedDataSet)
+	for pubkey, data := range raw {
+		var (
+			unsigned core.UnsignedData
+			err      error
+		)
+		switch typ {
+		case core.DutyAttester:
+			var att core.AttestationData
+			err = json.Unmarshal(data, &att)
+			unsigned = att
+		case core.DutyProposer:
+			var proposal core.VersionedProposal
+			err = json.Unmarshal(data, &proposal)
+			unsigned = proposal
+		case core.DutyAggregator:
+			var agg core.VersionedAggregatedAttestation
+			err = json.Unmarshal(data, &agg)
+			unsigned = agg
+		case core.DutySyncContribution:
+			var contrib core.SyncContribution
+			err = json.Unmarshal(data, &contrib)
+			unsigned = contrib
+		case core.DutyPrepareAggregator:
+			var sel core.AggregatorSelection
+			err = json.Unmarshal(data, &sel)
+			unsigned = sel
+		default:
+			return nil, errors.New("unsupported duty type", z.Str("type", typ.String()))
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "unmarshal unsigned data", z.Str("pubkey", pubkey.String()))
+		}
+
+		set[pubkey] = unsigned
+	}
+
+	return set, nil
+}
@@ -3,63 +3,245 @@
 package dutydb
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
+	"math/big"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	eth2api "github.com/attestantio/go-eth2-client/api"
+	eth2v1 "github.com/attestantio/go-eth2-client/api/v1"
 	eth2spec "github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/altair"
 	eth2p0 "github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jonboulle/clockwork"
+	"github.com/prysmaticlabs/go-bitfield"
 
 	"github.com/obolnetwork/charon/app/errors"
+	"github.com/obolnetwork/charon/app/eth2wrap"
+	"github.com/obolnetwork/charon/app/log"
 	"github.com/obolnetwork/charon/app/z"
 	"github.com/obolnetwork/charon/core"
+	"github.com/obolnetwork/charon/eth2util/eth2exp"
 )
 
+// maxFutureSlots is the number of slots beyond the current slot that duties may be stored for,
+// allowing for clock skew between peers.
+const maxFutureSlots = 1
+
+// genesisWindowSlots is the number of slots after genesis during which slot zero duties are accepted
+// if rejected otherwise. It covers the two slot deadline of slot zero attester and aggregator duties.
+const genesisWindowSlots = 2
+
+// ErrShutdown is returned by await methods when the DB is shutdown, see Shutdown.
+var ErrShutdown = errors.NewSentinel("dutydb shutdown")
+
+// ErrAwaitTimeout is returned by await methods when the default await timeout
+// expires for a context without a deadline, see WithDefaultAwaitTimeout.
+var ErrAwaitTimeout = errors.NewSentinel("dutydb await timeout")
+
+// ErrExpiredDuty is returned by Store if the duty has already expired, i.e. it was rejected by the deadliner.
+// Storing expired duties should not be retried.
+var ErrExpiredDuty = errors.NewSentinel("dutydb expired duty")
+
+// ErrQueryCancelled is returned by await methods when pending queries are cancelled, see CancelAllQueries.
+var ErrQueryCancelled = errors.NewSentinel("dutydb query cancelled")
+
+type options struct {
+	defaultAwaitTimeout time.Duration
+	attShards           int
+	queueWarnThreshold  int
+	clock               eth2wrap.SlotClock
+	rejectSlotZero      bool
+	expectedProposer    func(slot uint64) (eth2p0.ValidatorIndex, bool)
+	valueAwareProposals bool
+}
+
+// Option configures a MemDB.
+type Option func(*options)
+
+// WithDefaultAwaitTimeout returns an option configuring a MemDB to cap blocking await
+// calls to d if the provided context has no deadline. Awaits exceeding it return ErrAwaitTimeout.
+// Contexts with deadlines are respected unchanged. The default of zero means no timeout.
+//
+// This is a safety net against accumulating queries and goroutines for data that is never stored.
+func WithDefaultAwaitTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.defaultAwaitTimeout = d
+	}
+}
+
+// WithAttesterShards returns an option configuring a MemDB to shard attester duties and queries
+// by slot across n locks, allowing attester stores for different slots to proceed concurrently.
+// It defaults to 8.
+func WithAttesterShards(n int) Option {
+	return func(o *options) {
+		o.attShards = n
+	}
+}
+
+// WithQueueWarnThreshold returns an option configuring a MemDB to log a warning when a pending
+// query queue grows to n queries. It defaults to 1000, zero disables the warning.
+func WithQueueWarnThreshold(n int) Option {
+	return func(o *options) {
+		o.queueWarnThreshold = n
+	}
+}
+
+// WithRejectSlotZero returns an option configuring a MemDB to reject duties at slot zero, which are
+// usually the result of malformed upstream data. Slot zero duties are still accepted within the
+// genesis window of the first few slots if the slot clock is configured, see WithSlotClock.
+// It is disabled by default.
+func WithRejectSlotZero(enabled bool) Option {
+	return func(o *options) {
+		o.rejectSlotZero = enabled
+	}
+}
+
+// WithExpectedProposer returns an option configuring a MemDB to reject proposals whose proposer index doesn't
+// match the expected proposer of their slot returned by fn, guarding against beacon node mix-ups. Proposals for
+// slots without an expected proposer, i.e. if fn returns false, are accepted. It is disabled by default.
+func WithExpectedProposer(fn func(slot uint64) (eth2p0.ValidatorIndex, bool)) Option {
+	return func(o *options) {
+		o.expectedProposer = fn
+	}
+}
+
+// WithValueAwareProposals returns an option configuring a MemDB to replace a stored proposal with a different
+// proposal for the same slot if the latter has a higher total value, the sum of its execution and consensus values,
+// instead of rejecting it as clashing. Lower or equal value proposals are ignored. Proposals are still rejected as
+// clashing if either value isn't available. It is disabled by default.
+func WithValueAwareProposals(enabled bool) Option {
+	return func(o *options) {
+		o.valueAwareProposals = enabled
+	}
+}
+
+// WithSlotClock returns an option configuring a MemDB with the chain's slot timing, using clock
+// for the current time. It enables rejection of duties more than one slot in the future and the
+// eviction lag metric measuring how long after the end of their slot expired duties are deleted.
+func WithSlotClock(clock clockwork.Clock, genesis time.Time, slotDuration time.Duration) Option {
+	return func(o *options) {
+		o.clock = eth2wrap.NewSlotClock(clock, genesis, slotDuration)
+	}
+}
+
 // NewMemDB returns a new in-memory dutyDB instance.
-func NewMemDB(deadliner core.Deadliner) *MemDB {
+func NewMemDB(deadliner core.Deadliner, opts ...Option) *MemDB {
+	o := options{
+		attShards:          defaultAttShards,
+		queueWarnThreshold: defaultQueueWarnThreshold,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if horizoner, ok := deadliner.(core.HorizonDeadliner); ok {
+		deadlinerHorizonGauge.Set(horizoner.Horizon().Seconds())
+	}
+
+	stored := new(storedCounts)
+	queues := &queueMonitor{
+		threshold: o.queueWarnThreshold,
+		lengths:   make(map[queueKey]int),
+		totals:    make(map[string]int),
+		highWater: make(map[string]int),
+	}
+
 	return &MemDB{
-		attDuties:         make(map[attKey]*eth2p0.AttestationData),
-		attPubKeys:        make(map[pkKey]*core.PubKey),
-		attKeysBySlot:     make(map[uint64][]pkKey),
-		proDuties:         make(map[uint64]*eth2api.VersionedProposal),
-		aggDuties:         make(map[aggKey]core.VersionedAggregatedAttestation),
-		aggKeysBySlot:     make(map[uint64][]aggKey),
-		contribDuties:     make(map[contribKey]*altair.SyncCommitteeContribution),
-		contribKeysBySlot: make(map[uint64][]contribKey),
-		shutdown:          make(chan struct{}),
-		deadliner:         deadliner,
+		attShards:           newAttShards(o.attShards, stored, queues),
+		proDuties:           make(map[uint64]*eth2api.VersionedProposal),
+		proRoots:            make(map[uint64]eth2p0.Root),
+		proValues:           make(map[uint64]*big.Int),
+		aggDuties:           make(map[aggKey]core.VersionedAggregatedAttestation),
+		aggKeysBySlot:       make(map[uint64][]aggKey),
+		aggKeysByCommittee:  make(map[uint64]map[uint64][]aggKey),
+		contribDuties:       make(map[contribKey]*altair.SyncCommitteeContribution),
+		contribKeysBySlot:   make(map[uint64][]contribKey),
+		selDuties:           make(map[selKey]core.AggregatorSelection),
+		selKeysBySlot:       make(map[uint64][]selKey),
+		preloadedExpired:    make(map[core.Duty]bool),
+		shutdown:            make(chan struct{}),
+		cancelAll:           make(chan struct{}),
+		deadliner:           deadliner,
+		stored:              stored,
+		defaultAwaitTimeout: o.defaultAwaitTimeout,
+		clock:               o.clock,
+		rejectSlotZero:      o.rejectSlotZero,
+		expectedProposer:    o.expectedProposer,
+		valueAwareProposals: o.valueAwareProposals,
+		streams:             make(map[chan StoredEvent]struct{}),
+		produce: &produceMonitor{
+			duties: make(map[core.Duty]produceTimes),
+		},
+		queues: queues,
 	}
 }
 
+var (
+	_ core.DutyDB       = (*MemDB)(nil)
+	_ core.DutyDBReader = (*MemDB)(nil)
+	_ core.DutyDBReader = reader{}
+)
+
 // MemDB is an in-memory dutyDB implementation.
 // It is a placeholder for the badgerDB implementation.
 type MemDB struct {
+	// mu protects all fields except the attester shards which have their own locks.
+	// When both are required, mu must be acquired before any shard lock.
 	mu sync.Mutex
 
 	// DutyAttester
-	attDuties     map[attKey]*eth2p0.AttestationData
-	attPubKeys    map[pkKey]*core.PubKey
-	attKeysBySlot map[uint64][]pkKey
-	attQueries    []attQuery
+	attShards []*attShard
 
 	// DutyProposer
 	proDuties  map[uint64]*eth2api.VersionedProposal
+	proRoots   map[uint64]eth2p0.Root // Cached proposal roots, avoids recomputing the existing root on every re-store.
+	proValues  map[uint64]*big.Int    // Total proposal values if available, since they aren't retained when cloning.
 	proQueries []proQuery
 
 	// DutyAggregator
-	aggDuties     map[aggKey]core.VersionedAggregatedAttestation
-	aggKeysBySlot map[uint64][]aggKey
-	aggQueries    []aggQuery
+	aggDuties          map[aggKey]core.VersionedAggregatedAttestation
+	aggKeysBySlot      map[uint64][]aggKey
+	aggKeysByCommittee map[uint64]map[uint64][]aggKey // Aggregate keys by slot and committee index.
+	aggQueries         []aggQuery
+	aggSlotQueries     []aggSlotQuery
 
 	// DutySyncContribution
-	contribDuties     map[contribKey]*altair.SyncCommitteeContribution
-	contribKeysBySlot map[uint64][]contribKey
-	contribQueries    []contribQuery
-
-	shutdown  chan struct{}
-	deadliner core.Deadliner
+	contribDuties       map[contribKey]*altair.SyncCommitteeContribution
+	contribKeysBySlot   map[uint64][]contribKey
+	contribQueries      []contribQuery
+	contribMultiQueries []contribMultiQuery
+
+	// DutyPrepareAggregator
+	selDuties     map[selKey]core.AggregatorSelection
+	selKeysBySlot map[uint64][]selKey
+	selQueries    []selQuery
+
+	anyQueries []anyQuery // Queries for any duty of a type by slot, see AwaitAnyDuty.
+
+	// Preloaded duties rejected by the deadliner, which never expires them. They are deleted
+	// once a duty of the same type at or after their slot expires, see LoadFrom.
+	preloadedExpired map[core.Duty]bool
+
+	shutdown            chan struct{}
+	cancelAll           chan struct{} // Closed and replaced by CancelAllQueries, holding mu and all shard locks.
+	deadliner           core.Deadliner
+	stored              *storedCounts // Shared with the attester shards, see Stats.
+	lastStore           atomic.Int64  // Unix nanoseconds of the last successful store, zero if none, see LastStore.
+	defaultAwaitTimeout time.Duration
+	queues              *queueMonitor
+	produce             *produceMonitor
+	clock               eth2wrap.SlotClock // Nil if future duties aren't rejected and eviction lag isn't measured.
+	rejectSlotZero      bool
+	expectedProposer    func(slot uint64) (eth2p0.ValidatorIndex, bool) // Nil if proposer indices aren't validated.
+	valueAwareProposals bool
+
+	streamsMu sync.Mutex // Protects streams only, so streaming doesn't contend with mu.
+	streams   map[chan StoredEvent]struct{}
 }
 
 // Shutdown results in all blocking queries to return shutdown errors.
@@ -68,13 +250,155 @@ func (db *MemDB) Shutdown() {
 	close(db.shutdown)
 }
 
-// Store implements core.DutyDB, see its godoc.
-func (db *MemDB) Store(_ context.Context, duty core.Duty, unsignedSet core.UnsignedDataSet) error {
+// Reader returns a read-only view of the DB. It forwards to the live DB, so reads stay current,
+// but doesn't expose storing data or shutting down.
+func (db *MemDB) Reader() core.DutyDBReader {
+	return reader{DutyDBReader: db}
+}
+
+// CancelAllQueries results in all currently blocking queries to return ErrQueryCancelled.
+// Unlike Shutdown, it may be called multiple times and the DB remains usable; stored duties are not affected.
+func (db *MemDB) CancelAllQueries() {
 	db.mu.Lock()
 	defer db.mu.Unlock()
+	unlock := db.lockAttShards()
+	defer unlock()
+
+	close(db.cancelAll)
+	db.cancelAll = make(chan struct{})
+
+	for _, shard := range db.attShards {
+		shard.attQueries = nil
+		shard.attMultiQueries = nil
+	}
+	db.proQueries = nil
+	db.aggQueries = nil
+	db.aggSlotQueries = nil
+	db.contribQueries = nil
+	db.contribMultiQueries = nil
+	db.selQueries = nil
+	db.anyQueries = nil
+}
+
+// Store implements core.DutyDB, see its godoc.
+// Stored data is also sent to any streams, see StreamStores.
+//
+// The context error is returned without storing anything if the context is closed before storing. Otherwise
+// storing is aborted between entries of the data set when the context is closed, in which case the entries
+// already stored remain stored, as when storing an invalid entry fails.
+func (db *MemDB) Store(ctx context.Context, duty core.Duty, unsignedSet core.UnsignedDataSet) error {
+	if err := db.store(ctx, duty, unsignedSet, false); err != nil {
+		return err
+	}
+
+	db.publishStore(ctx, duty, unsignedSet)
+
+	return nil
+}
+
+// store stores the unsigned data set of the duty. Expired and future duties are rejected unless preload is true,
+// preloaded expired duties are tracked for deletion instead.
+func (db *MemDB) store(ctx context.Context, duty core.Duty, unsignedSet core.UnsignedDataSet, preload bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if db.rejectSlotZero && duty.Slot == 0 && !db.inGenesisWindow() {
+		return errors.New("not storing unsigned data for slot zero duty", z.Any("duty", duty))
+	}
+
+	if db.clock != nil && !preload {
+		if current := db.clock.CurrentSlot(); duty.Slot > current+maxFutureSlots {
+			return errors.New("not storing unsigned data for future duty", z.Any("duty", duty), z.U64("current_slot", current))
+		}
+	}
 
 	if !db.deadliner.Add(duty) {
-		return errors.New("not storing unsigned data for expired duty", z.Any("duty", duty))
+		if !preload {
+			return errors.Wrap(ErrExpiredDuty, "not storing unsigned data for expired duty", z.Any("duty", duty))
+		}
+
+		db.mu.Lock()
+		db.preloadedExpired[duty] = true
+		db.mu.Unlock()
+	}
+
+	var err error
+	if duty.Type == core.DutyAttester {
+		// Attester duties are sharded by slot and don't require the global lock.
+		err = db.storeAttestations(ctx, unsignedSet)
+	} else {
+		err = db.storeLocked(ctx, duty, unsignedSet)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := db.deleteExpired(); err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	db.resolveAnyQueriesUnsafe()
+	retainedSlotsGauge.Set(float64(db.retainedSlotsUnsafe()))
+	db.mu.Unlock()
+
+	if !preload {
+		now := db.now()
+		db.lastStore.Store(now.UnixNano())
+		db.produce.stored(duty, now)
+	}
+
+	return nil
+}
+
+// now returns the current time of the slot clock if configured, otherwise the wall clock time.
+func (db *MemDB) now() time.Time {
+	if db.clock != nil {
+		return db.clock.Now()
+	}
+
+	return time.Now()
+}
+
+// inGenesisWindow returns true if the slot clock is configured and the current slot is within the genesis window.
+func (db *MemDB) inGenesisWindow() bool {
+	return db.clock != nil && db.clock.CurrentSlot() <= genesisWindowSlots
+}
+
+// storeAttestations stores the unsigned attestations in the shards of their attestation data slots.
+func (db *MemDB) storeAttestations(ctx context.Context, unsignedSet core.UnsignedDataSet) error {
+	sets := make(map[*attShard]core.UnsignedDataSet)
+	for pubkey, unsignedData := range unsignedSet {
+		attData, ok := unsignedData.(core.AttestationData)
+		if !ok {
+			return errors.New("invalid unsigned attestation data")
+		}
+
+		shard := db.attShard(uint64(attData.Data.Slot))
+		if sets[shard] == nil {
+			sets[shard] = make(core.UnsignedDataSet)
+		}
+		sets[shard][pubkey] = unsignedData
+	}
+
+	for shard, set := range sets {
+		if err := shard.storeAttestations(ctx, set); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// storeLocked stores the unsigned data set of all non-attester duties while holding the global lock.
+func (db *MemDB) storeLocked(ctx context.Context, duty core.Duty, unsignedSet core.UnsignedDataSet) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	// The context may have been closed while waiting for the lock.
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
 	switch duty.Type {
@@ -92,61 +416,248 @@ func (db *MemDB) Store(_ context.Context, duty core.Duty, unsignedSet core.Unsig
 		db.resolveProQueriesUnsafe()
 	case core.DutyBuilderProposer:
 		return core.ErrDeprecatedDutyBuilderProposer
-	case core.DutyAttester:
-		for pubkey, unsignedData := range unsignedSet {
-			err := db.storeAttestationUnsafe(pubkey, unsignedData)
-			if err != nil {
-				return err
-			}
-		}
-		db.resolveAttQueriesUnsafe()
 	case core.DutyAggregator:
-		var err error
 		for _, unsignedData := range unsignedSet {
-			err = db.storeAggAttestationUnsafe(unsignedData)
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			err := db.storeAggAttestationUnsafe(ctx, duty, unsignedData)
 			if err != nil {
 				return err
 			}
 		}
 		db.resolveAggQueriesUnsafe()
+		db.resolveAggSlotQueriesUnsafe()
 	case core.DutySyncContribution:
 		for _, unsignedData := range unsignedSet {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
 			err := db.storeSyncContributionUnsafe(unsignedData)
 			if err != nil {
 				return err
 			}
 		}
 		db.resolveContribQueriesUnsafe()
+		db.resolveContribMultiQueriesUnsafe()
+	case core.DutyPrepareAggregator:
+		for _, unsignedData := range unsignedSet {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			err := db.storeAggSelectionUnsafe(unsignedData)
+			if err != nil {
+				return err
+			}
+		}
+		db.resolveSelQueriesUnsafe()
 	default:
 		return errors.New("unsupported duty type", z.Str("type", duty.Type.String()))
 	}
 
-	// Delete all expired duties.
+	return nil
+}
+
+// Trim blocks until the context is closed or the DB is shutdown, it deletes expired duties as the
+// deadliner expires them, so expiry doesn't depend on stores. It should only be called once.
+func (db *MemDB) Trim(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-db.shutdown:
+			return
+		case duty := <-db.deadliner.C(): // This buffered channel is small, so we need dedicated goroutine to service it.
+			if err := db.deleteExpiredDuty(duty); err != nil {
+				log.Warn(ctx, "Failed to delete expired duty", err, z.Any("duty", duty))
+			}
+		}
+	}
+}
+
+// deleteExpired deletes all expired duties not yet deleted by Trim.
+func (db *MemDB) deleteExpired() error {
 	for {
-		var deleted bool
 		select {
 		case duty := <-db.deadliner.C():
-			err := db.deleteDutyUnsafe(duty)
-			if err != nil {
+			if err := db.deleteExpiredDuty(duty); err != nil {
 				return err
 			}
-			deleted = true
 		default:
+			return nil
+		}
+	}
+}
+
+// deleteExpiredDuty deletes the expired duty. Each expired duty is received from the deadliner by either
+// Trim or deleteExpired, and deletion is idempotent, so concurrent draining doesn't double-delete.
+func (db *MemDB) deleteExpiredDuty(duty core.Duty) error {
+	db.mu.Lock()
+	err := db.deleteDutyUnsafe(duty)
+	if err == nil {
+		err = db.deletePreloadedExpiredUnsafe(duty)
+	}
+	retainedSlotsGauge.Set(float64(db.retainedSlotsUnsafe()))
+	db.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	db.produce.deleteExpired(duty)
+
+	if db.clock != nil {
+		lag := db.clock.Now().Sub(db.clock.SlotStartTime(duty.Slot + 1)) // Duties ideally expire at the end of their slot.
+		evictionLagHistogram.WithLabelValues(duty.Type.String()).Observe(lag.Seconds())
+	}
+
+	return nil
+}
+
+// deletePreloadedExpiredUnsafe deletes the preloaded expired duties of the expired duty's type at or before its slot.
+// It is unsafe since it assumes the lock is held.
+func (db *MemDB) deletePreloadedExpiredUnsafe(expired core.Duty) error {
+	for duty := range db.preloadedExpired {
+		if duty.Type != expired.Type || duty.Slot > expired.Slot {
+			continue
 		}
 
-		if !deleted {
-			break
+		if err := db.deleteDutyUnsafe(duty); err != nil {
+			return err
+		}
+		delete(db.preloadedExpired, duty)
+	}
+
+	return nil
+}
+
+// DeleteDuty deletes the unsigned data stored for the duty, e.g. if it was produced against the wrong fork.
+// Unlike expiry, it doesn't wait for the duty's deadline. Deleting a duty that isn't stored is a no-op.
+// Pending queries of the duty type are reconsidered afterwards, they remain blocked until the data is stored again.
+func (db *MemDB) DeleteDuty(duty core.Duty) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if err := db.deleteDutyUnsafe(duty); err != nil {
+		return err
+	}
+
+	switch duty.Type {
+	case core.DutyProposer:
+		db.resolveProQueriesUnsafe()
+	case core.DutyAttester:
+		for _, shard := range db.attShards {
+			shard.mu.Lock()
+			shard.resolveAttQueriesUnsafe()
+			shard.resolveAttMultiQueriesUnsafe()
+			shard.mu.Unlock()
 		}
+	case core.DutyAggregator:
+		db.resolveAggQueriesUnsafe()
+		db.resolveAggSlotQueriesUnsafe()
+	case core.DutySyncContribution:
+		db.resolveContribQueriesUnsafe()
+		db.resolveContribMultiQueriesUnsafe()
+	case core.DutyPrepareAggregator:
+		db.resolveSelQueriesUnsafe()
+	default:
 	}
+	db.resolveAnyQueriesUnsafe()
+
+	retainedSlotsGauge.Set(float64(db.retainedSlotsUnsafe()))
 
 	return nil
 }
 
-// AwaitProposal implements core.DutyDB, see its godoc.
+// retainedSlotsUnsafe returns the number of distinct slots with duties stored across all duty types.
+// It is unsafe since it assumes the lock is held, shard locks are acquired as required.
+func (db *MemDB) retainedSlotsUnsafe() int {
+	slots := make(map[uint64]struct{}, len(db.proDuties)+len(db.aggKeysBySlot))
+	for slot := range db.proDuties {
+		slots[slot] = struct{}{}
+	}
+	for _, shard := range db.attShards {
+		shard.mu.Lock()
+		for slot := range shard.attKeysBySlot {
+			slots[slot] = struct{}{}
+		}
+		shard.mu.Unlock()
+	}
+	for slot := range db.aggKeysBySlot {
+		slots[slot] = struct{}{}
+	}
+	for slot := range db.contribKeysBySlot {
+		slots[slot] = struct{}{}
+	}
+	for slot := range db.selKeysBySlot {
+		slots[slot] = struct{}{}
+	}
+
+	return len(slots)
+}
+
+// AwaitProposal implements core.DutyDB, see its godoc. It returns full (non-blinded) proposals only.
+// Only a single proposal is stored per slot (a different proposal for the same slot is rejected as a clash),
+// so an error is returned as soon as a blinded proposal is stored for the slot, rather than waiting for
+// a full proposal that would clash. Use AwaitProposalBlinded for blinded proposals or AwaitProposalAnyKind for either.
 func (db *MemDB) AwaitProposal(ctx context.Context, slot uint64) (*eth2api.VersionedProposal, error) {
+	return db.awaitProposalKind(ctx, slot, false)
+}
+
+// AwaitProposalBlinded blocks and returns the blinded proposal for the slot when available.
+// As for AwaitProposal, an error is returned as soon as a full proposal is stored for the slot.
+func (db *MemDB) AwaitProposalBlinded(ctx context.Context, slot uint64) (*eth2api.VersionedProposal, error) {
+	return db.awaitProposalKind(ctx, slot, true)
+}
+
+// AwaitProposalAnyKind implements core.DutyDB, see its godoc. It returns the proposal stored for the slot
+// irrespective of whether it is blinded or not, since the validator API serves both kinds.
+func (db *MemDB) AwaitProposalAnyKind(ctx context.Context, slot uint64) (*eth2api.VersionedProposal, error) {
+	return db.awaitProposal(ctx, slot)
+}
+
+// ProposalInfo returns whether the proposal stored for the slot is blinded, its fork version and its root,
+// without blocking or cloning the proposal. It returns false if no proposal is stored for the slot.
+func (db *MemDB) ProposalInfo(slot uint64) (blinded bool, version eth2spec.DataVersion, root eth2p0.Root, ok bool) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	proposal, ok := db.proDuties[slot]
+	if !ok {
+		return false, eth2spec.DataVersionUnknown, eth2p0.Root{}, false
+	}
+
+	return proposal.Blinded, proposal.Version, db.proRoots[slot], true
+}
+
+// awaitProposalKind blocks and returns the proposal for the slot when available,
+// returning an error if its blinded flag doesn't match the requested one.
+func (db *MemDB) awaitProposalKind(ctx context.Context, slot uint64, blinded bool) (*eth2api.VersionedProposal, error) {
+	proposal, err := db.awaitProposal(ctx, slot)
+	if err != nil {
+		return nil, err
+	}
+
+	if proposal.Blinded != blinded {
+		return nil, errors.New("mismatching proposal blinded flag",
+			z.U64("slot", slot), z.Bool("requested", blinded), z.Bool("stored", proposal.Blinded))
+	}
+
+	return proposal, nil
+}
+
+// awaitProposal blocks and returns the proposal for the slot when available.
+func (db *MemDB) awaitProposal(ctx context.Context, slot uint64) (_ *eth2api.VersionedProposal, err error) {
+	ctx, cancelCtx := db.awaitContext(ctx)
+	defer cancelCtx()
+
 	cancel := make(chan struct{})
 	defer close(cancel)
-	response := make(chan *eth2api.VersionedProposal, 1)
+	response := make(chan *eth2api.VersionedProposal, responseBuffer)
+	arrival := db.now()
 
 	db.mu.Lock()
 	db.proQueries = append(db.proQueries, proQuery{
@@ -154,54 +665,208 @@ func (db *MemDB) AwaitProposal(ctx context.Context, slot uint64) (*eth2api.Versi
 		Response: response,
 		Cancel:   cancel,
 	})
+	db.queues.observe(ctx, queueProposer, 0, len(db.proQueries))
 	db.resolveProQueriesUnsafe()
+	immediate := len(response) > 0
+	cancelAll := db.cancelAll
 	db.mu.Unlock()
 
+	defer func() { observeAwait(queueProposer, immediate, err) }()
+	defer parkAwait(queueProposer, immediate)()
+
 	select {
 	case <-db.shutdown:
-		return nil, errors.New("dutydb shutdown")
+		return nil, errors.Wrap(ErrShutdown, "await duty data")
+	case <-cancelAll:
+		return nil, errors.Wrap(ErrQueryCancelled, "await duty data")
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		return nil, awaitErr(ctx)
 	case block := <-response:
+		db.produce.awaited(core.NewProposerDuty(slot), arrival)
 		return block, nil
 	}
 }
 
 // AwaitAttestation implements core.DutyDB, see its godoc.
+// Since attestation data is also stored for committee index 0, see attShard.storeAttestationUnsafe, queries for
+// committee index 0 also resolve to attestation data stored for other committees of the slot, see AwaitAttestationStrict.
 func (db *MemDB) AwaitAttestation(ctx context.Context, slot uint64, commIdx uint64) (*eth2p0.AttestationData, error) {
+	return db.awaitAttestation(ctx, slot, commIdx, false)
+}
+
+// AwaitAttestationStrict is identical to AwaitAttestation but only resolves to attestation data stored for the exact
+// committee index, not to the committee index 0 alias of data stored for other committees. This allows detecting
+// validator clients requesting committee index 0 for duties of other committees.
+func (db *MemDB) AwaitAttestationStrict(ctx context.Context, slot uint64, commIdx uint64) (*eth2p0.AttestationData, error) {
+	return db.awaitAttestation(ctx, slot, commIdx, true)
+}
+
+// awaitAttestation blocks and returns the attestation data for the slot and committee index when available,
+// excluding committee index 0 aliases if strict.
+func (db *MemDB) awaitAttestation(ctx context.Context, slot uint64, commIdx uint64, strict bool) (_ *eth2p0.AttestationData, err error) {
+	ctx, cancelCtx := db.awaitContext(ctx)
+	defer cancelCtx()
+
 	cancel := make(chan struct{})
 	defer close(cancel)
-	response := make(chan *eth2p0.AttestationData, 1) // Instance of one so resolving never blocks
+	response := make(chan *eth2p0.AttestationData, responseBuffer)
+	arrival := db.now()
 
-	db.mu.Lock()
-	db.attQueries = append(db.attQueries, attQuery{
+	shard := db.attShard(slot)
+	shard.mu.Lock()
+	shard.attQueries = append(shard.attQueries, attQuery{
 		Key: attKey{
 			Slot:    slot,
 			CommIdx: commIdx,
 		},
+		Strict:   strict,
 		Response: response,
 		Cancel:   cancel,
 	})
-	db.resolveAttQueriesUnsafe()
-	db.mu.Unlock()
+	db.queues.observe(ctx, queueAttester, shard.idx, len(shard.attQueries))
+	shard.resolveAttQueriesUnsafe()
+	immediate := len(response) > 0
+	cancelAll := db.cancelAll
+	shard.mu.Unlock()
+
+	defer func() { observeAwait(queueAttester, immediate, err) }()
+	defer parkAwait(queueAttester, immediate)()
 
 	select {
 	case <-db.shutdown:
-		return nil, errors.New("dutydb shutdown")
+		return nil, errors.Wrap(ErrShutdown, "await duty data")
+	case <-cancelAll:
+		return nil, errors.Wrap(ErrQueryCancelled, "await duty data")
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		return nil, awaitErr(ctx)
 	case value := <-response:
+		db.produce.awaited(core.NewAttesterDuty(slot), arrival)
 		return value, nil
 	}
 }
 
+// MultiAwaitAttestation blocks and returns the attestation data for the slot and all the provided
+// committee indexes when available, keyed by committee index. It registers a single query for
+// all committees instead of one per committee. If the context is done before all committees
+// are available, the data available at that point is returned along with the context error.
+func (db *MemDB) MultiAwaitAttestation(ctx context.Context, slot uint64, commIdxs []uint64) (_ map[uint64]*eth2p0.AttestationData, err error) {
+	ctx, cancelCtx := db.awaitContext(ctx)
+	defer cancelCtx()
+
+	cancel := make(chan struct{})
+	defer close(cancel)
+	response := make(chan map[uint64]*eth2p0.AttestationData, responseBuffer)
+	arrival := db.now()
+
+	shard := db.attShard(slot)
+	shard.mu.Lock()
+	shard.attMultiQueries = append(shard.attMultiQueries, attMultiQuery{
+		Slot:     slot,
+		CommIdxs: commIdxs,
+		Response: response,
+		Cancel:   cancel,
+	})
+	db.queues.observe(ctx, queueAttesterMulti, shard.idx, len(shard.attMultiQueries))
+	shard.resolveAttMultiQueriesUnsafe()
+	immediate := len(response) > 0
+	cancelAll := db.cancelAll
+	shard.mu.Unlock()
+
+	defer func() { observeAwait(queueAttesterMulti, immediate, err) }()
+	defer parkAwait(queueAttesterMulti, immediate)()
+
+	select {
+	case <-db.shutdown:
+		return nil, errors.Wrap(ErrShutdown, "await duty data")
+	case <-cancelAll:
+		return nil, errors.Wrap(ErrQueryCancelled, "await duty data")
+	case <-ctx.Done():
+		shard.mu.Lock()
+		partial, _ := shard.attestationsUnsafe(slot, commIdxs)
+		shard.mu.Unlock()
+
+		return partial, awaitErr(ctx)
+	case values := <-response:
+		db.produce.awaited(core.NewAttesterDuty(slot), arrival)
+		return values, nil
+	}
+}
+
+// SetExpectedCommittees sets the committee indexes charon expects attestation data for at the slot,
+// replacing any previously set, see AwaitAllAttestations. The expectation is deleted with the slot's attester
+// duty when it expires, so expectations for expired duties are rejected.
+func (db *MemDB) SetExpectedCommittees(slot uint64, commIdxs []uint64) error {
+	if duty := core.NewAttesterDuty(slot); !db.deadliner.Add(duty) {
+		return errors.Wrap(ErrExpiredDuty, "not setting expected committees for expired duty", z.Any("duty", duty))
+	}
+
+	shard := db.attShard(slot)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	shard.attExpected[slot] = slices.Clone(commIdxs)
+	shard.resolveAttMultiQueriesUnsafe()
+
+	return nil
+}
+
+// AwaitAllAttestations blocks and returns the attestation data by committee index for all expected committees
+// of the slot when available, see SetExpectedCommittees. It blocks until the expected committees are set.
+// When the context is closed before all are available, it returns the partial results with the context error.
+func (db *MemDB) AwaitAllAttestations(ctx context.Context, slot uint64) (_ map[uint64]*eth2p0.AttestationData, err error) {
+	ctx, cancelCtx := db.awaitContext(ctx)
+	defer cancelCtx()
+
+	cancel := make(chan struct{})
+	defer close(cancel)
+	response := make(chan map[uint64]*eth2p0.AttestationData, responseBuffer)
+	arrival := db.now()
+
+	shard := db.attShard(slot)
+	shard.mu.Lock()
+	shard.attMultiQueries = append(shard.attMultiQueries, attMultiQuery{
+		Slot:     slot,
+		Expected: true,
+		Response: response,
+		Cancel:   cancel,
+	})
+	db.queues.observe(ctx, queueAttesterMulti, shard.idx, len(shard.attMultiQueries))
+	shard.resolveAttMultiQueriesUnsafe()
+	immediate := len(response) > 0
+	cancelAll := db.cancelAll
+	shard.mu.Unlock()
+
+	defer func() { observeAwait(queueAttesterMulti, immediate, err) }()
+	defer parkAwait(queueAttesterMulti, immediate)()
+
+	select {
+	case <-db.shutdown:
+		return nil, errors.Wrap(ErrShutdown, "await duty data")
+	case <-cancelAll:
+		return nil, errors.Wrap(ErrQueryCancelled, "await duty data")
+	case <-ctx.Done():
+		shard.mu.Lock()
+		partial, _ := shard.attestationsUnsafe(slot, shard.attExpected[slot])
+		shard.mu.Unlock()
+
+		return partial, awaitErr(ctx)
+	case values := <-response:
+		db.produce.awaited(core.NewAttesterDuty(slot), arrival)
+		return values, nil
+	}
+}
+
 // AwaitAggAttestation blocks and returns the aggregated attestation for the slot
 // and attestation when available.
 func (db *MemDB) AwaitAggAttestation(ctx context.Context, slot uint64, attestationRoot eth2p0.Root,
-) (*eth2spec.VersionedAttestation, error) {
+) (_ *eth2spec.VersionedAttestation, err error) {
+	ctx, cancelCtx := db.awaitContext(ctx)
+	defer cancelCtx()
+
 	cancel := make(chan struct{})
 	defer close(cancel)
-	response := make(chan core.VersionedAggregatedAttestation, 1) // Instance of one so resolving never blocks
+	response := make(chan core.VersionedAggregatedAttestation, responseBuffer)
+	arrival := db.now()
 
 	db.mu.Lock()
 	db.aggQueries = append(db.aggQueries, aggQuery{
@@ -212,15 +877,24 @@ func (db *MemDB) AwaitAggAttestation(ctx context.Context, slot uint64, attestati
 		Response: response,
 		Cancel:   cancel,
 	})
+	db.queues.observe(ctx, queueAggregator, 0, len(db.aggQueries))
 	db.resolveAggQueriesUnsafe()
+	immediate := len(response) > 0
+	cancelAll := db.cancelAll
 	db.mu.Unlock()
 
+	defer func() { observeAwait(queueAggregator, immediate, err) }()
+	defer parkAwait(queueAggregator, immediate)()
+
 	select {
 	case <-db.shutdown:
-		return nil, errors.New("dutydb shutdown")
+		return nil, errors.Wrap(ErrShutdown, "await duty data")
+	case <-cancelAll:
+		return nil, errors.Wrap(ErrQueryCancelled, "await duty data")
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		return nil, awaitErr(ctx)
 	case value := <-response:
+		db.produce.awaited(core.NewAggregatorDuty(slot), arrival)
 		// Clone before returning.
 		clone, err := value.Clone()
 		if err != nil {
@@ -235,12 +909,120 @@ func (db *MemDB) AwaitAggAttestation(ctx context.Context, slot uint64, attestati
 	}
 }
 
+// AwaitAnyAggAttestation blocks and returns the aggregated attestation for the slot when available,
+// for consumers that don't know the attestation root. It returns an error if multiple aggregated
+// attestations with distinct roots are stored for the slot, since the result would be ambiguous.
+func (db *MemDB) AwaitAnyAggAttestation(ctx context.Context, slot uint64) (_ *eth2spec.VersionedAttestation, err error) {
+	ctx, cancelCtx := db.awaitContext(ctx)
+	defer cancelCtx()
+
+	cancel := make(chan struct{})
+	defer close(cancel)
+	response := make(chan []core.VersionedAggregatedAttestation, responseBuffer)
+	arrival := db.now()
+
+	db.mu.Lock()
+	db.aggSlotQueries = append(db.aggSlotQueries, aggSlotQuery{
+		Key:      slot,
+		Response: response,
+		Cancel:   cancel,
+	})
+	db.queues.observe(ctx, queueAggregatorSlot, 0, len(db.aggSlotQueries))
+	db.resolveAggSlotQueriesUnsafe()
+	immediate := len(response) > 0
+	cancelAll := db.cancelAll
+	db.mu.Unlock()
+
+	defer func() { observeAwait(queueAggregatorSlot, immediate, err) }()
+	defer parkAwait(queueAggregatorSlot, immediate)()
+
+	select {
+	case <-db.shutdown:
+		return nil, errors.Wrap(ErrShutdown, "await duty data")
+	case <-cancelAll:
+		return nil, errors.Wrap(ErrQueryCancelled, "await duty data")
+	case <-ctx.Done():
+		return nil, awaitErr(ctx)
+	case values := <-response:
+		db.produce.awaited(core.NewAggregatorDuty(slot), arrival)
+		if len(values) > 1 {
+			return nil, errors.New("ambiguous aggregated attestations for slot", z.U64("slot", slot), z.Int("n", len(values)))
+		}
+
+		// Clone before returning.
+		clone, err := values[0].Clone()
+		if err != nil {
+			return nil, err
+		}
+		aggAtt, ok := clone.(core.VersionedAggregatedAttestation)
+		if !ok {
+			return nil, errors.New("invalid aggregated attestation")
+		}
+
+		return &aggAtt.VersionedAttestation, nil
+	}
+}
+
+// AggAttestationsForCommittee returns the aggregated attestations stored for the slot and committee index
+// without blocking, in the order they were first stored. It returns an empty slice if none are stored.
+// This aids debugging aggregation coverage gaps.
+func (db *MemDB) AggAttestationsForCommittee(slot, commIdx uint64) ([]core.VersionedAggregatedAttestation, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var resp []core.VersionedAggregatedAttestation
+	for _, key := range db.aggKeysByCommittee[slot][commIdx] {
+		// Clone before returning.
+		clone, err := db.aggDuties[key].Clone()
+		if err != nil {
+			return nil, err
+		}
+		aggAtt, ok := clone.(core.VersionedAggregatedAttestation)
+		if !ok {
+			return nil, errors.New("invalid aggregated attestation")
+		}
+
+		resp = append(resp, aggAtt)
+	}
+
+	return resp, nil
+}
+
+// SyncContributionsForSlot returns the sync committee contributions stored for the slot across all subcommittees
+// and beacon block roots without blocking, in the order they were stored. It returns an empty slice if none are stored.
+// This helps verifying that all subcommittees produced data.
+func (db *MemDB) SyncContributionsForSlot(slot uint64) ([]*altair.SyncCommitteeContribution, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var resp []*altair.SyncCommitteeContribution
+	for _, key := range db.contribKeysBySlot[slot] {
+		// Clone before returning.
+		clone, err := core.NewSyncContribution(db.contribDuties[key]).Clone()
+		if err != nil {
+			return nil, err
+		}
+		contrib, ok := clone.(core.SyncContribution)
+		if !ok {
+			return nil, errors.New("invalid sync committee contribution")
+		}
+
+		resp = append(resp, &contrib.SyncCommitteeContribution)
+	}
+
+	return resp, nil
+}
+
 // AwaitSyncContribution blocks and returns the sync committee contribution data for the slot and
 // the subcommittee and the beacon block root when available.
-func (db *MemDB) AwaitSyncContribution(ctx context.Context, slot, subcommIdx uint64, beaconBlockRoot eth2p0.Root) (*altair.SyncCommitteeContribution, error) {
+func (db *MemDB) AwaitSyncContribution(ctx context.Context, slot, subcommIdx uint64, beaconBlockRoot eth2p0.Root) (_ *altair.SyncCommitteeContribution, err error) {
+	ctx, cancelCtx := db.awaitContext(ctx)
+	defer cancelCtx()
+
 	cancel := make(chan struct{})
 	defer close(cancel)
-	response := make(chan *altair.SyncCommitteeContribution, 1) // Instance of one so resolving never blocks
+	response := make(chan *altair.SyncCommitteeContribution, responseBuffer)
+	arrival := db.now()
 
 	db.mu.Lock()
 	db.contribQueries = append(db.contribQueries, contribQuery{
@@ -252,23 +1034,337 @@ func (db *MemDB) AwaitSyncContribution(ctx context.Context, slot, subcommIdx uin
 		Response: response,
 		Cancel:   cancel,
 	})
+	db.queues.observe(ctx, queueSyncContribution, 0, len(db.contribQueries))
 	db.resolveContribQueriesUnsafe()
+	immediate := len(response) > 0
+	cancelAll := db.cancelAll
+	db.mu.Unlock()
+
+	defer func() { observeAwait(queueSyncContribution, immediate, err) }()
+	defer parkAwait(queueSyncContribution, immediate)()
+
+	select {
+	case <-db.shutdown:
+		return nil, errors.Wrap(ErrShutdown, "await duty data")
+	case <-cancelAll:
+		return nil, errors.Wrap(ErrQueryCancelled, "await duty data")
+	case <-ctx.Done():
+		return nil, awaitErr(ctx)
+	case value := <-response:
+		db.produce.awaited(core.NewSyncContributionDuty(slot), arrival)
+		return value, nil
+	}
+}
+
+// AwaitAggregatorSelection blocks and returns whether the validator is an aggregator for the slot and committee
+// and its aggregated selection proof when available. Selections are stored for both selected and not selected
+// validators, so this also returns for validators that aren't aggregators.
+func (db *MemDB) AwaitAggregatorSelection(ctx context.Context, slot, commIdx, valIdx uint64,
+) (_ bool, _ eth2p0.BLSSignature, err error) {
+	ctx, cancelCtx := db.awaitContext(ctx)
+	defer cancelCtx()
+
+	cancel := make(chan struct{})
+	defer close(cancel)
+	response := make(chan core.AggregatorSelection, responseBuffer)
+	arrival := db.now()
+
+	db.mu.Lock()
+	db.selQueries = append(db.selQueries, selQuery{
+		Key: selKey{
+			Slot:    slot,
+			CommIdx: commIdx,
+			ValIdx:  valIdx,
+		},
+		Response: response,
+		Cancel:   cancel,
+	})
+	db.queues.observe(ctx, queueAggregatorSelection, 0, len(db.selQueries))
+	db.resolveSelQueriesUnsafe()
+	immediate := len(response) > 0
+	cancelAll := db.cancelAll
 	db.mu.Unlock()
 
-	select {
-	case <-db.shutdown:
-		return nil, errors.New("dutydb shutdown")
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case value := <-response:
-		return value, nil
+	defer func() { observeAwait(queueAggregatorSelection, immediate, err) }()
+	defer parkAwait(queueAggregatorSelection, immediate)()
+
+	select {
+	case <-db.shutdown:
+		return false, eth2p0.BLSSignature{}, errors.Wrap(ErrShutdown, "await duty data")
+	case <-cancelAll:
+		return false, eth2p0.BLSSignature{}, errors.Wrap(ErrQueryCancelled, "await duty data")
+	case <-ctx.Done():
+		return false, eth2p0.BLSSignature{}, awaitErr(ctx)
+	case value := <-response:
+		db.produce.awaited(core.NewPrepareAggregatorDuty(slot), arrival)
+		return value.IsAggregator, value.Selection.SelectionProof, nil
+	}
+}
+
+// AwaitAnyDuty blocks until any unsigned data of the duty type is stored for the slot and returns true and
+// the number of entries stored for the slot at that time. Unlike the other await methods, it doesn't return
+// the unsigned data, so it is cheap for liveness checks. Attester duties are counted by committee.
+func (db *MemDB) AwaitAnyDuty(ctx context.Context, slot uint64, dutyType core.DutyType) (_ bool, _ int, err error) {
+	switch dutyType {
+	case core.DutyProposer, core.DutyAttester, core.DutyAggregator, core.DutySyncContribution, core.DutyPrepareAggregator:
+	default:
+		return false, 0, errors.New("unsupported duty type", z.Str("type", dutyType.String()))
+	}
+
+	ctx, cancelCtx := db.awaitContext(ctx)
+	defer cancelCtx()
+
+	cancel := make(chan struct{})
+	defer close(cancel)
+	response := make(chan int, responseBuffer)
+	arrival := db.now()
+
+	db.mu.Lock()
+	db.anyQueries = append(db.anyQueries, anyQuery{
+		Key:      core.Duty{Slot: slot, Type: dutyType},
+		Response: response,
+		Cancel:   cancel,
+	})
+	db.queues.observe(ctx, queueAnyDuty, 0, len(db.anyQueries))
+	db.resolveAnyQueriesUnsafe()
+	immediate := len(response) > 0
+	cancelAll := db.cancelAll
+	db.mu.Unlock()
+
+	defer func() { observeAwait(queueAnyDuty, immediate, err) }()
+	defer parkAwait(queueAnyDuty, immediate)()
+
+	select {
+	case <-db.shutdown:
+		return false, 0, errors.Wrap(ErrShutdown, "await duty data")
+	case <-cancelAll:
+		return false, 0, errors.Wrap(ErrQueryCancelled, "await duty data")
+	case <-ctx.Done():
+		return false, 0, awaitErr(ctx)
+	case count := <-response:
+		db.produce.awaited(core.Duty{Slot: slot, Type: dutyType}, arrival)
+		return true, count, nil
+	}
+}
+
+// PendingQueryCountForSlot returns the number of await queries currently blocked waiting for data of the slot
+// by duty type, omitting duty types without any. Slots with unusually many queries indicate stuck consumers.
+func (db *MemDB) PendingQueryCountForSlot(slot uint64) map[core.DutyType]int {
+	return db.pendingQueryCounts([]*attShard{db.attShard(slot)}, func(querySlot uint64) bool {
+		return querySlot == slot
+	})
+}
+
+// PendingQueryCount returns the total number of await queries currently blocked waiting for data of any slot.
+// A persistently high count indicates the DB is backed up, i.e. consumers are waiting on data that isn't stored.
+func (db *MemDB) PendingQueryCount() int {
+	var total int
+	for _, count := range db.pendingQueryCounts(db.attShards, func(uint64) bool { return true }) {
+		total += count
+	}
+
+	return total
+}
+
+// pendingQueryCounts returns the number of pending await queries by duty type for slots matching the filter,
+// only considering attester queries of the provided shards.
+func (db *MemDB) pendingQueryCounts(shards []*attShard, filter func(slot uint64) bool) map[core.DutyType]int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	counts := make(map[core.DutyType]int)
+	count := func(typ core.DutyType, querySlot uint64, cancel <-chan struct{}) {
+		if filter(querySlot) && !cancelled(cancel) {
+			counts[typ]++
+		}
+	}
+
+	for _, shard := range shards {
+		shard.mu.Lock()
+		for _, query := range shard.attQueries {
+			count(core.DutyAttester, query.Key.Slot, query.Cancel)
+		}
+		for _, query := range shard.attMultiQueries {
+			count(core.DutyAttester, query.Slot, query.Cancel)
+		}
+		shard.mu.Unlock()
+	}
+
+	for _, query := range db.proQueries {
+		count(core.DutyProposer, query.Key, query.Cancel)
+	}
+	for _, query := range db.aggQueries {
+		count(core.DutyAggregator, query.Key.Slot, query.Cancel)
+	}
+	for _, query := range db.aggSlotQueries {
+		count(core.DutyAggregator, query.Key, query.Cancel)
+	}
+	for _, query := range db.contribQueries {
+		count(core.DutySyncContribution, query.Key.Slot, query.Cancel)
+	}
+	for _, query := range db.contribMultiQueries {
+		count(core.DutySyncContribution, query.Slot, query.Cancel)
+	}
+	for _, query := range db.selQueries {
+		count(core.DutyPrepareAggregator, query.Key.Slot, query.Cancel)
+	}
+	for _, query := range db.anyQueries {
+		count(query.Key.Type, query.Key.Slot, query.Cancel)
+	}
+
+	return counts
+}
+
+// DeadlinerHorizon returns the horizon of the deadliner, i.e. how long after the start of their slot
+// duties are retained, or false if the deadliner doesn't expose it, see core.HorizonDeadliner.
+func (db *MemDB) DeadlinerHorizon() (time.Duration, bool) {
+	horizoner, ok := db.deadliner.(core.HorizonDeadliner)
+	if !ok {
+		return 0, false
+	}
+
+	return horizoner.Horizon(), true
+}
+
+// awaitContext returns a context capped at the default await timeout if configured
+// and the provided context has no deadline, otherwise it returns the provided context.
+func (db *MemDB) awaitContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || db.defaultAwaitTimeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeoutCause(ctx, db.defaultAwaitTimeout, ErrAwaitTimeout)
+}
+
+// awaitErr returns the error of a done await context, distinguishing default await timeouts.
+func awaitErr(ctx context.Context) error {
+	if cause := context.Cause(ctx); errors.Is(cause, ErrAwaitTimeout) {
+		return errors.Wrap(cause, "await duty data")
+	}
+
+	return ctx.Err()
+}
+
+// MultiAwaitSyncContribution blocks and returns the sync committee contributions for the slot, beacon block root
+// and all the provided subcommittee indexes when available, keyed by subcommittee index. It registers a single
+// query for all subcommittees instead of one per subcommittee. If the context is done before all subcommittees
+// are available, the contributions available at that point are returned along with the context error.
+func (db *MemDB) MultiAwaitSyncContribution(ctx context.Context, slot uint64, subcommIdxs []uint64, beaconBlockRoot eth2p0.Root,
+) (_ map[uint64]*altair.SyncCommitteeContribution, err error) {
+	ctx, cancelCtx := db.awaitContext(ctx)
+	defer cancelCtx()
+
+	cancel := make(chan struct{})
+	defer close(cancel)
+	response := make(chan map[uint64]*altair.SyncCommitteeContribution, responseBuffer)
+	arrival := db.now()
+
+	db.mu.Lock()
+	db.contribMultiQueries = append(db.contribMultiQueries, contribMultiQuery{
+		Slot:        slot,
+		SubcommIdxs: subcommIdxs,
+		Root:        beaconBlockRoot,
+		Response:    response,
+		Cancel:      cancel,
+	})
+	db.queues.observe(ctx, queueSyncContributionMulti, 0, len(db.contribMultiQueries))
+	db.resolveContribMultiQueriesUnsafe()
+	immediate := len(response) > 0
+	cancelAll := db.cancelAll
+	db.mu.Unlock()
+
+	defer func() { observeAwait(queueSyncContributionMulti, immediate, err) }()
+	defer parkAwait(queueSyncContributionMulti, immediate)()
+
+	select {
+	case <-db.shutdown:
+		return nil, errors.Wrap(ErrShutdown, "await duty data")
+	case <-cancelAll:
+		return nil, errors.Wrap(ErrQueryCancelled, "await duty data")
+	case <-ctx.Done():
+		db.mu.Lock()
+		partial, _ := db.syncContributionsUnsafe(slot, subcommIdxs, beaconBlockRoot)
+		db.mu.Unlock()
+
+		return partial, awaitErr(ctx)
+	case values := <-response:
+		db.produce.awaited(core.NewSyncContributionDuty(slot), arrival)
+		return values, nil
+	}
+}
+
+// AwaitArgs are the duty type specific arguments of AwaitDuty.
+type AwaitArgs struct {
+	// CommIdx is the attester or aggregator selection committee index.
+	CommIdx uint64
+	// ValIdx is the aggregator selection validator index.
+	ValIdx uint64
+	// Root is the aggregator attestation data root or the sync contribution beacon block root.
+	Root eth2p0.Root
+	// SubcommIdx is the sync contribution subcommittee index.
+	SubcommIdx uint64
+}
+
+// AwaitDuty blocks and returns the unsigned data of the duty when available, dispatching to the
+// await method of the duty type using the relevant args. Note that the returned attestation data
+// only includes the slot and committee index of the attester duty.
+func (db *MemDB) AwaitDuty(ctx context.Context, duty core.Duty, args AwaitArgs) (core.UnsignedData, error) {
+	switch duty.Type {
+	case core.DutyProposer:
+		proposal, err := db.AwaitProposalAnyKind(ctx, duty.Slot)
+		if err != nil {
+			return nil, err
+		}
+
+		return core.NewVersionedProposal(proposal)
+	case core.DutyAttester:
+		data, err := db.AwaitAttestation(ctx, duty.Slot, args.CommIdx)
+		if err != nil {
+			return nil, err
+		}
+
+		return core.AttestationData{
+			Data: *data,
+			Duty: eth2v1.AttesterDuty{
+				Slot:           eth2p0.Slot(duty.Slot),
+				CommitteeIndex: eth2p0.CommitteeIndex(args.CommIdx),
+			},
+		}, nil
+	case core.DutyAggregator:
+		att, err := db.AwaitAggAttestation(ctx, duty.Slot, args.Root)
+		if err != nil {
+			return nil, err
+		}
+
+		return core.NewVersionedAggregatedAttestation(att)
+	case core.DutySyncContribution:
+		contrib, err := db.AwaitSyncContribution(ctx, duty.Slot, args.SubcommIdx, args.Root)
+		if err != nil {
+			return nil, err
+		}
+
+		return core.NewSyncContribution(contrib), nil
+	case core.DutyPrepareAggregator:
+		isAggregator, proof, err := db.AwaitAggregatorSelection(ctx, duty.Slot, args.CommIdx, args.ValIdx)
+		if err != nil {
+			return nil, err
+		}
+
+		return core.NewAggregatorSelection(&eth2exp.BeaconCommitteeSelection{
+			ValidatorIndex: eth2p0.ValidatorIndex(args.ValIdx),
+			Slot:           eth2p0.Slot(duty.Slot),
+			SelectionProof: proof,
+		}, eth2p0.CommitteeIndex(args.CommIdx), isAggregator), nil
+	default:
+		return nil, errors.New("unsupported duty type", z.Str("type", duty.Type.String()))
 	}
 }
 
 // PubKeyByAttestation implements core.DutyDB, see its godoc.
 func (db *MemDB) PubKeyByAttestation(_ context.Context, slot, commIdx, valIdx uint64) (core.PubKey, error) {
-	db.mu.Lock()
-	defer db.mu.Unlock()
+	shard := db.attShard(slot)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
 
 	key := pkKey{
 		Slot:    slot,
@@ -276,7 +1372,7 @@ func (db *MemDB) PubKeyByAttestation(_ context.Context, slot, commIdx, valIdx ui
 		ValIdx:  valIdx,
 	}
 
-	pubkey, ok := db.attPubKeys[key]
+	pubkey, ok := shard.attPubKeys[key]
 	if !ok {
 		return "", errors.New("pubkey not found")
 	}
@@ -284,94 +1380,12 @@ func (db *MemDB) PubKeyByAttestation(_ context.Context, slot, commIdx, valIdx ui
 	return *pubkey, nil
 }
 
-// storeAttestationUnsafe stores the unsigned attestation. It is unsafe since it assumes the lock is held.
-func (db *MemDB) storeAttestationUnsafe(pubkey core.PubKey, unsignedData core.UnsignedData) error {
-	cloned, err := unsignedData.Clone() // Clone before storing.
-	if err != nil {
-		return err
-	}
-
-	attData, ok := cloned.(core.AttestationData)
-	if !ok {
-		return errors.New("invalid unsigned attestation data")
-	}
-
-	pubkeyStore := &pubkey
-
-	// Store key and value for PubKeyByAttestation
-	pKey := pkKey{
-		Slot:    uint64(attData.Data.Slot),
-		CommIdx: uint64(attData.Duty.CommitteeIndex),
-		ValIdx:  uint64(attData.Duty.ValidatorIndex),
-	}
-
-	if value, ok := db.attPubKeys[pKey]; ok {
-		if *value != *pubkeyStore {
-			return errors.New("clashing public key", z.Any("pKey", pKey))
-		}
-	} else {
-		db.attPubKeys[pKey] = pubkeyStore
-		db.attKeysBySlot[uint64(attData.Duty.Slot)] = append(db.attKeysBySlot[uint64(attData.Duty.Slot)], pKey)
-	}
-
-	// Store key and value for AwaitAttestation
-	aKey := attKey{
-		Slot:    uint64(attData.Data.Slot),
-		CommIdx: uint64(attData.Duty.CommitteeIndex),
-	}
-
-	if value, ok := db.attDuties[aKey]; ok {
-		if value.String() != attData.Data.String() {
-			return errors.New("clashing attestation data", z.Any("key", aKey))
-		}
-	} else {
-		db.attDuties[aKey] = &attData.Data
-	}
-
-	// TODO(kalo):
-	// Committee index 0 should be the default behaviour post-electra.
-	// However, some VCs are still requesting for attestation data with a committee index.
-	// Because of that on Charon side we are also saving attestation data with a committee index.
-	// VCs that work correctly and ask for the hardcoded committee index of 0 need the logic below in order to function properly.
-	// Once all VCs work correctly and ask for index 0, we can remove the logic below, as we will always receive committee index 0
-	// and write it as such from the logic on top.
-	// https://ethereum.github.io/beacon-APIs/#/Validator/produceAttestationData
-
-	// Store key and value for PubKeyByAttestation
-	pKeyCommIdx0 := pkKey{
-		Slot:    uint64(attData.Data.Slot),
-		CommIdx: 0,
-		ValIdx:  uint64(attData.Duty.ValidatorIndex),
-	}
-
-	if value, ok := db.attPubKeys[pKeyCommIdx0]; ok {
-		if *value != *pubkeyStore {
-			return errors.New("clashing public key", z.Any("pKey", pKeyCommIdx0))
-		}
-	} else {
-		db.attPubKeys[pKeyCommIdx0] = pubkeyStore
-		db.attKeysBySlot[uint64(attData.Duty.Slot)] = append(db.attKeysBySlot[uint64(attData.Duty.Slot)], pKeyCommIdx0)
-	}
-
-	// Store key and value for AwaitAttestation
-	aKeyCommIdx0 := attKey{
-		Slot:    uint64(attData.Data.Slot),
-		CommIdx: 0,
-	}
-
-	if value, ok := db.attDuties[aKeyCommIdx0]; ok {
-		if value.String() != attData.Data.String() {
-			return errors.New("clashing attestation data", z.Any("key", aKeyCommIdx0))
-		}
-	} else {
-		db.attDuties[aKeyCommIdx0] = &attData.Data
-	}
-
-	return nil
-}
-
-// storeAggAttestationUnsafe stores the unsigned aggregated attestation. It is unsafe since it assumes the lock is held.
-func (db *MemDB) storeAggAttestationUnsafe(unsignedData core.UnsignedData) error {
+// storeAggAttestationUnsafe stores the unsigned aggregated attestation. If a different aggregate is already stored for
+// the same data root, the one with more aggregation bits is kept since it maximises rewards, ties are replaced by the
+// provided aggregate. Electra aggregation bits span the committees of the committee bits, so aggregates for different
+// committees aren't comparable and are also replaced. Aggregates for a slot other than the duty's slot are rejected.
+// It is unsafe since it assumes the lock is held.
+func (db *MemDB) storeAggAttestationUnsafe(ctx context.Context, duty core.Duty, unsignedData core.UnsignedData) error {
 	cloned, err := unsignedData.Clone() // Clone before storing.
 	if err != nil {
 		return err
@@ -392,6 +1406,11 @@ func (db *MemDB) storeAggAttestationUnsafe(unsignedData core.UnsignedData) error
 	}
 
 	slot := uint64(aggAttData.Slot)
+	if slot != duty.Slot {
+		// Aggregates are deleted when their duty expires, so aggregates of other slots would never be deleted.
+		return errors.New("aggregated attestation slot mismatches duty slot",
+			z.U64("slot", slot), z.U64("duty_slot", duty.Slot))
+	}
 
 	// Store key and value for PubKeyByAttestation
 	key := aggKey{
@@ -419,18 +1438,94 @@ func (db *MemDB) storeAggAttestationUnsafe(unsignedData core.UnsignedData) error
 		}
 
 		if existingDataRoot != providedDataRoot {
+			clashCounter.WithLabelValues(clashAggregate).Inc()
 			return errors.New("clashing data root", z.Str("existing", hex.EncodeToString(existingDataRoot[:])), z.Str("provided", hex.EncodeToString(providedDataRoot[:])))
 		}
 
+		existingBits, existingComms, err := aggBits(existing)
+		if err != nil {
+			return errors.Wrap(err, "existing aggregation bits")
+		}
+		providedBits, providedComms, err := aggBits(provided)
+		if err != nil {
+			return errors.Wrap(err, "provided aggregation bits")
+		}
+
+		sameComms := bytes.Equal(existingComms, providedComms)
+		if !sameComms || !bytes.Equal(existingBits, providedBits) {
+			result := overwriteReplaced
+			if sameComms && providedBits.Count() < existingBits.Count() {
+				result = overwriteKept
+			}
+
+			aggOverwriteCounter.WithLabelValues(result).Inc()
+			log.Warn(ctx, "Clashing aggregated attestation aggregation bits", nil,
+				z.U64("slot", slot),
+				z.Str("root", hex.EncodeToString(aggRoot[:])),
+				z.U64("existing_bits", existingBits.Count()),
+				z.U64("provided_bits", providedBits.Count()),
+				z.Bool("same_committees", sameComms),
+				z.Str("result", result),
+			)
+
+			if result == overwriteKept {
+				return nil
+			}
+		}
+
 		db.aggDuties[key] = provided
 	} else {
 		db.aggDuties[key] = aggAtt
 		db.aggKeysBySlot[slot] = append(db.aggKeysBySlot[slot], key)
+		db.stored.add(core.DutyAggregator, 1)
 	}
 
+	db.indexAggCommitteeUnsafe(key, aggAtt)
+
 	return nil
 }
 
+// aggBits returns the aggregation bits and, since Electra, the committee bits of the aggregate.
+// The committee bits are nil for earlier versions, whose aggregation bits cover a single committee.
+func aggBits(agg core.VersionedAggregatedAttestation) (bitfield.Bitlist, bitfield.Bitvector64, error) {
+	bits, err := agg.AggregationBits()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if agg.Version < eth2spec.DataVersionElectra {
+		return bits, nil, nil
+	}
+
+	comms, err := agg.CommitteeBits()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return bits, comms, nil
+}
+
+// indexAggCommitteeUnsafe adds the aggregate key to the committee index. Aggregates without a single
+// committee index aren't indexed. It is unsafe since it assumes the lock is held.
+func (db *MemDB) indexAggCommitteeUnsafe(key aggKey, aggAtt core.VersionedAggregatedAttestation) {
+	commIdx, err := aggAtt.CommitteeIndex()
+	if err != nil {
+		return
+	}
+
+	byCommittee, ok := db.aggKeysByCommittee[key.Slot]
+	if !ok {
+		byCommittee = make(map[uint64][]aggKey)
+		db.aggKeysByCommittee[key.Slot] = byCommittee
+	}
+
+	if slices.Contains(byCommittee[uint64(commIdx)], key) {
+		return
+	}
+
+	byCommittee[uint64(commIdx)] = append(byCommittee[uint64(commIdx)], key)
+}
+
 // storeSyncContributionUnsafe stores the unsigned aggregated attestation. It is unsafe since it assumes the lock is held.
 func (db *MemDB) storeSyncContributionUnsafe(unsignedData core.UnsignedData) error {
 	cloned, err := unsignedData.Clone() // Clone before storing.
@@ -461,11 +1556,54 @@ func (db *MemDB) storeSyncContributionUnsafe(unsignedData core.UnsignedData) err
 		}
 
 		if existingRoot != contribRoot {
-			return errors.New("clashing sync contributions")
+			clashCounter.WithLabelValues(clashContribution).Inc()
+			return errors.New("clashing sync contributions",
+				z.U64("slot", key.Slot),
+				z.U64("subcommittee_index", key.SubcommIdx),
+				z.Str("existing", hex.EncodeToString(existingRoot[:])),
+				z.Str("provided", hex.EncodeToString(contribRoot[:])),
+			)
 		}
 	} else {
 		db.contribDuties[key] = &contrib.SyncCommitteeContribution
 		db.contribKeysBySlot[uint64(contrib.Slot)] = append(db.contribKeysBySlot[uint64(contrib.Slot)], key)
+		db.stored.add(core.DutySyncContribution, 1)
+	}
+
+	return nil
+}
+
+// storeAggSelectionUnsafe stores the unsigned aggregator selection. It is unsafe since it assumes the lock is held.
+func (db *MemDB) storeAggSelectionUnsafe(unsignedData core.UnsignedData) error {
+	cloned, err := unsignedData.Clone() // Clone before storing.
+	if err != nil {
+		return err
+	}
+
+	sel, ok := cloned.(core.AggregatorSelection)
+	if !ok {
+		return errors.New("invalid unsigned aggregator selection")
+	}
+
+	key := selKey{
+		Slot:    uint64(sel.Selection.Slot),
+		CommIdx: uint64(sel.CommitteeIndex),
+		ValIdx:  uint64(sel.Selection.ValidatorIndex),
+	}
+
+	if existing, ok := db.selDuties[key]; ok {
+		if existing.Selection.SelectionProof != sel.Selection.SelectionProof || existing.IsAggregator != sel.IsAggregator {
+			clashCounter.WithLabelValues(clashSelection).Inc()
+			return errors.New("clashing aggregator selections",
+				z.U64("slot", key.Slot),
+				z.U64("committee_index", key.CommIdx),
+				z.U64("validator_index", key.ValIdx),
+			)
+		}
+	} else {
+		db.selDuties[key] = sel
+		db.selKeysBySlot[key.Slot] = append(db.selKeysBySlot[key.Slot], key)
+		db.stored.add(core.DutyPrepareAggregator, 1)
 	}
 
 	return nil
@@ -473,6 +1611,12 @@ func (db *MemDB) storeSyncContributionUnsafe(unsignedData core.UnsignedData) err
 
 // storeProposalUnsafe stores the unsigned Proposal. It is unsafe since it assumes the lock is held.
 func (db *MemDB) storeProposalUnsafe(unsignedData core.UnsignedData) error {
+	// Values aren't retained when cloning, so they are taken from the provided proposal.
+	var providedValue *big.Int
+	if provided, ok := unsignedData.(core.VersionedProposal); ok {
+		providedValue = proposalValue(provided)
+	}
+
 	cloned, err := unsignedData.Clone() // Clone before storing.
 	if err != nil {
 		return err
@@ -488,46 +1632,89 @@ func (db *MemDB) storeProposalUnsafe(unsignedData core.UnsignedData) error {
 		return err
 	}
 
-	if existing, ok := db.proDuties[uint64(slot)]; ok {
-		existingRoot, err := existing.Root()
-		if err != nil {
-			return errors.Wrap(err, "proposal root")
-		}
+	if err := db.checkProposerUnsafe(uint64(slot), proposal); err != nil {
+		return err
+	}
 
-		providedRoot, err := proposal.Root()
-		if err != nil {
-			return errors.Wrap(err, "proposal root")
+	providedRoot, err := proposal.Root()
+	if err != nil {
+		return errors.Wrap(err, "proposal root")
+	}
+
+	if existingRoot, ok := db.proRoots[uint64(slot)]; ok {
+		if existingRoot == providedRoot {
+			return nil
 		}
 
-		if existingRoot != providedRoot {
+		existingValue := db.proValues[uint64(slot)]
+		if !db.valueAwareProposals || existingValue == nil || providedValue == nil {
 			return errors.New("clashing blocks")
 		}
+
+		result := overwriteKept
+		if providedValue.Cmp(existingValue) > 0 {
+			result = overwriteReplaced
+			db.proDuties[uint64(slot)] = &proposal.VersionedProposal
+			db.proRoots[uint64(slot)] = providedRoot
+			db.proValues[uint64(slot)] = providedValue
+		}
+		proposalOverwriteCounter.WithLabelValues(result).Inc()
 	} else {
 		db.proDuties[uint64(slot)] = &proposal.VersionedProposal
+		db.proRoots[uint64(slot)] = providedRoot
+		if providedValue != nil {
+			db.proValues[uint64(slot)] = providedValue
+		}
+		db.stored.add(core.DutyProposer, 1)
 	}
 
 	return nil
 }
 
-// resolveAttQueriesUnsafe resolve any attQuery to a result if found.
-// It is unsafe since it assume that the lock is held.
-func (db *MemDB) resolveAttQueriesUnsafe() {
-	var unresolved []attQuery
-	for _, query := range db.attQueries {
-		if cancelled(query.Cancel) {
-			continue // Drop cancelled queries.
-		}
+// proposalValue returns the total value of the proposal in wei, the sum of its execution and consensus values,
+// or nil if neither is available.
+func proposalValue(proposal core.VersionedProposal) *big.Int {
+	if proposal.ExecutionValue == nil && proposal.ConsensusValue == nil {
+		return nil
+	}
 
-		value, ok := db.attDuties[query.Key]
-		if !ok {
-			unresolved = append(unresolved, query)
-			continue
-		}
+	value := new(big.Int)
+	if proposal.ExecutionValue != nil {
+		value.Add(value, proposal.ExecutionValue)
+	}
+	if proposal.ConsensusValue != nil {
+		value.Add(value, proposal.ConsensusValue)
+	}
+
+	return value
+}
+
+// checkProposerUnsafe returns an error if the proposal's proposer index doesn't match the expected proposer
+// of the slot, see WithExpectedProposer. It is unsafe since it assumes the lock is held.
+func (db *MemDB) checkProposerUnsafe(slot uint64, proposal core.VersionedProposal) error {
+	if db.expectedProposer == nil {
+		return nil
+	}
 
-		query.Response <- value
+	expected, ok := db.expectedProposer(slot)
+	if !ok {
+		return nil
+	}
+
+	proposerIdx, err := proposal.ProposerIndex()
+	if err != nil {
+		return errors.Wrap(err, "proposal proposer index")
 	}
 
-	db.attQueries = unresolved
+	if proposerIdx != expected {
+		return errors.New("proposal proposer index mismatches expected proposer",
+			z.U64("slot", slot),
+			z.U64("proposer_index", uint64(proposerIdx)),
+			z.U64("expected", uint64(expected)),
+		)
+	}
+
+	return nil
 }
 
 // resolveProQueriesUnsafe resolve any proQuery to a result if found.
@@ -545,7 +1732,7 @@ func (db *MemDB) resolveProQueriesUnsafe() {
 			continue
 		}
 
-		query.Response <- value
+		respond(query.Response, value)
 	}
 
 	db.proQueries = unresolved
@@ -566,12 +1753,38 @@ func (db *MemDB) resolveAggQueriesUnsafe() {
 			continue
 		}
 
-		query.Response <- value
+		respond(query.Response, value)
 	}
 
 	db.aggQueries = unresolved
 }
 
+// resolveAggSlotQueriesUnsafe resolves any aggSlotQuery to all aggregated attestations of the slot if any are found.
+// It is unsafe since it assumes that the lock is held.
+func (db *MemDB) resolveAggSlotQueriesUnsafe() {
+	var unresolved []aggSlotQuery
+	for _, query := range db.aggSlotQueries {
+		if cancelled(query.Cancel) {
+			continue // Drop cancelled queries.
+		}
+
+		keys := db.aggKeysBySlot[query.Key]
+		if len(keys) == 0 {
+			unresolved = append(unresolved, query)
+			continue
+		}
+
+		var values []core.VersionedAggregatedAttestation
+		for _, key := range keys {
+			values = append(values, db.aggDuties[key])
+		}
+
+		respond(query.Response, values)
+	}
+
+	db.aggSlotQueries = unresolved
+}
+
 // resolveContribQueriesUnsafe resolves any contribQuery to a result if found.
 // It is unsafe since it assumes that the lock is held.
 func (db *MemDB) resolveContribQueriesUnsafe() {
@@ -587,42 +1800,202 @@ func (db *MemDB) resolveContribQueriesUnsafe() {
 			continue
 		}
 
-		query.Response <- contribution
+		respond(query.Response, contribution)
 	}
 
 	db.contribQueries = unresolved
 }
 
+// resolveSelQueriesUnsafe resolves any selQuery to a result if found.
+// It is unsafe since it assumes that the lock is held.
+func (db *MemDB) resolveSelQueriesUnsafe() {
+	var unresolved []selQuery
+	for _, query := range db.selQueries {
+		if cancelled(query.Cancel) {
+			continue // Drop cancelled queries.
+		}
+
+		sel, ok := db.selDuties[query.Key]
+		if !ok {
+			unresolved = append(unresolved, query)
+			continue
+		}
+
+		respond(query.Response, sel)
+	}
+
+	db.selQueries = unresolved
+}
+
+// resolveAnyQueriesUnsafe resolves any anyQuery to the number of entries stored for its duty if any.
+// It is unsafe since it assumes that the lock is held, shard locks are acquired as required.
+func (db *MemDB) resolveAnyQueriesUnsafe() {
+	if len(db.anyQueries) == 0 {
+		return
+	}
+
+	var unresolved []anyQuery
+	for _, query := range db.anyQueries {
+		if cancelled(query.Cancel) {
+			continue // Drop cancelled queries.
+		}
+
+		count := db.countDutyUnsafe(query.Key)
+		if count == 0 {
+			unresolved = append(unresolved, query)
+			continue
+		}
+
+		respond(query.Response, count)
+	}
+
+	db.anyQueries = unresolved
+}
+
+// countDutyUnsafe returns the number of entries stored for the duty, counting attester duties by committee.
+// It is unsafe since it assumes that the lock is held, shard locks are acquired as required.
+func (db *MemDB) countDutyUnsafe(duty core.Duty) int {
+	switch duty.Type {
+	case core.DutyProposer:
+		if _, ok := db.proDuties[duty.Slot]; ok {
+			return 1
+		}
+
+		return 0
+	case core.DutyAttester:
+		// Attestations are sharded by attestation data slot which may differ from the duty slot.
+		committees := make(map[uint64]bool)
+		for _, shard := range db.attShards {
+			shard.mu.Lock()
+			for _, key := range shard.attKeysBySlot[duty.Slot] {
+				if !shard.attAliases[attKey{Slot: key.Slot, CommIdx: key.CommIdx}] {
+					committees[key.CommIdx] = true
+				}
+			}
+			shard.mu.Unlock()
+		}
+
+		return len(committees)
+	case core.DutyAggregator:
+		return len(db.aggKeysBySlot[duty.Slot])
+	case core.DutySyncContribution:
+		return len(db.contribKeysBySlot[duty.Slot])
+	case core.DutyPrepareAggregator:
+		return len(db.selKeysBySlot[duty.Slot])
+	default:
+		return 0
+	}
+}
+
+// resolveContribMultiQueriesUnsafe resolves any contribMultiQuery to a result if all its subcommittees are found.
+// It is unsafe since it assumes that the lock is held.
+func (db *MemDB) resolveContribMultiQueriesUnsafe() {
+	var unresolved []contribMultiQuery
+	for _, query := range db.contribMultiQueries {
+		if cancelled(query.Cancel) {
+			continue // Drop cancelled queries.
+		}
+
+		values, ok := db.syncContributionsUnsafe(query.Slot, query.SubcommIdxs, query.Root)
+		if !ok {
+			unresolved = append(unresolved, query)
+			continue
+		}
+
+		respond(query.Response, values)
+	}
+
+	db.contribMultiQueries = unresolved
+}
+
+// syncContributionsUnsafe returns the sync committee contributions available for the slot, subcommittee indexes
+// and beacon block root and true if all are available. It is unsafe since it assumes that the lock is held.
+func (db *MemDB) syncContributionsUnsafe(slot uint64, subcommIdxs []uint64, root eth2p0.Root,
+) (map[uint64]*altair.SyncCommitteeContribution, bool) {
+	values := make(map[uint64]*altair.SyncCommitteeContribution)
+	complete := true
+	for _, subcommIdx := range subcommIdxs {
+		value, ok := db.contribDuties[contribKey{Slot: slot, SubcommIdx: subcommIdx, Root: root}]
+		if !ok {
+			complete = false
+			continue
+		}
+		values[subcommIdx] = value
+	}
+
+	return values, complete
+}
+
+// attShard returns the attester shard of the attestation data slot.
+func (db *MemDB) attShard(slot uint64) *attShard {
+	return db.attShards[slot%uint64(len(db.attShards))]
+}
+
+// lockAttShards locks all attester shards in order and returns a function unlocking them.
+func (db *MemDB) lockAttShards() func() {
+	for _, shard := range db.attShards {
+		shard.mu.Lock()
+	}
+
+	return func() {
+		for _, shard := range db.attShards {
+			shard.mu.Unlock()
+		}
+	}
+}
+
 // deleteDutyUnsafe deletes the duty from the database. It is unsafe since it assumes the lock is held.
 func (db *MemDB) deleteDutyUnsafe(duty core.Duty) error {
 	switch duty.Type {
 	case core.DutyProposer:
+		if _, ok := db.proDuties[duty.Slot]; ok {
+			db.stored.add(core.DutyProposer, -1)
+		}
 		delete(db.proDuties, duty.Slot)
+		delete(db.proRoots, duty.Slot)
+		delete(db.proValues, duty.Slot)
 	case core.DutyBuilderProposer:
 		return core.ErrDeprecatedDutyBuilderProposer
 	case core.DutyAttester:
-		for _, key := range db.attKeysBySlot[duty.Slot] {
-			delete(db.attPubKeys, key)
-			delete(db.attDuties, attKey{Slot: key.Slot, CommIdx: key.CommIdx})
+		// Attestations are sharded by attestation data slot which may differ from the duty slot.
+		for _, shard := range db.attShards {
+			shard.mu.Lock()
+			shard.deleteSlotUnsafe(duty.Slot)
+			shard.mu.Unlock()
 		}
-		delete(db.attKeysBySlot, duty.Slot)
 	case core.DutyAggregator:
 		for _, key := range db.aggKeysBySlot[duty.Slot] {
 			delete(db.aggDuties, key)
 		}
+		db.stored.add(core.DutyAggregator, -len(db.aggKeysBySlot[duty.Slot]))
 		delete(db.aggKeysBySlot, duty.Slot)
+		delete(db.aggKeysByCommittee, duty.Slot)
 	case core.DutySyncContribution:
 		for _, key := range db.contribKeysBySlot[duty.Slot] {
 			delete(db.contribDuties, key)
 		}
+		db.stored.add(core.DutySyncContribution, -len(db.contribKeysBySlot[duty.Slot]))
 		delete(db.contribKeysBySlot, duty.Slot)
+	case core.DutyPrepareAggregator:
+		for _, key := range db.selKeysBySlot[duty.Slot] {
+			delete(db.selDuties, key)
+		}
+		db.stored.add(core.DutyPrepareAggregator, -len(db.selKeysBySlot[duty.Slot]))
+		delete(db.selKeysBySlot, duty.Slot)
 	default:
 		return errors.New("unknown duty type")
 	}
 
+	db.produce.delete(duty)
+
 	return nil
 }
 
+// reader is a read-only view of a MemDB, restricting access to the core.DutyDBReader methods.
+type reader struct {
+	core.DutyDBReader
+}
+
 // attKey is the key to lookup an attester value in the DB.
 type attKey struct {
 	Slot    uint64
@@ -649,13 +2022,33 @@ type contribKey struct {
 	Root       eth2p0.Root
 }
 
+// selKey is the key to look up an aggregator selection by committee and validator index in the DB.
+type selKey struct {
+	Slot    uint64
+	CommIdx uint64
+	ValIdx  uint64
+}
+
+// Queries are appended in registration order. Resolve passes iterate in that order and retain the relative
+// order of unresolved queries, so queries for the same key are always resolved first in, first out.
+
 // attQuery is a waiting attQuery with a response channel.
 type attQuery struct {
 	Key      attKey
+	Strict   bool // Excludes committee index 0 aliases.
 	Response chan<- *eth2p0.AttestationData
 	Cancel   <-chan struct{}
 }
 
+// attMultiQuery is a waiting attMultiQuery for multiple committees with a response channel.
+type attMultiQuery struct {
+	Slot     uint64
+	CommIdxs []uint64
+	Expected bool // Awaits the expected committees of the slot instead of CommIdxs, see MemDB.AwaitAllAttestations.
+	Response chan<- map[uint64]*eth2p0.AttestationData
+	Cancel   <-chan struct{}
+}
+
 // proQuery is a waiting proQuery with a response channel.
 type proQuery struct {
 	Key      uint64
@@ -670,6 +2063,13 @@ type aggQuery struct {
 	Cancel   <-chan struct{}
 }
 
+// aggSlotQuery is a waiting aggSlotQuery by slot with a response channel.
+type aggSlotQuery struct {
+	Key      uint64
+	Response chan<- []core.VersionedAggregatedAttestation
+	Cancel   <-chan struct{}
+}
+
 // contribQuery is a waiting contribQuery with a response channel.
 type contribQuery struct {
 	Key      contribKey
@@ -677,6 +2077,56 @@ type contribQuery struct {
 	Cancel   <-chan struct{}
 }
 
+// contribMultiQuery is a waiting contribMultiQuery for multiple subcommittees with a response channel.
+type contribMultiQuery struct {
+	Slot        uint64
+	SubcommIdxs []uint64
+	Root        eth2p0.Root
+	Response    chan<- map[uint64]*altair.SyncCommitteeContribution
+	Cancel      <-chan struct{}
+}
+
+// selQuery is a waiting selQuery with a response channel.
+type selQuery struct {
+	Key      selKey
+	Response chan<- core.AggregatorSelection
+	Cancel   <-chan struct{}
+}
+
+// anyQuery is a waiting anyQuery for any entry of a duty with a response channel.
+type anyQuery struct {
+	Key      core.Duty
+	Response chan<- int
+	Cancel   <-chan struct{}
+}
+
+// responseBuffer is the buffer size of query response channels. Each query has its own response channel
+// and is removed once resolved, so exactly one response is sent per channel and resolving never blocks.
+const responseBuffer = 1
+
+// respond sends the value on the query response channel without blocking, since resolving holds the lock.
+// It returns false if the channel is unexpectedly full or closed, which indicates a bug since each query is only
+// resolved once and owns its channel. Sends to closed channels are recovered so they don't crash the resolving store.
+func respond[T any](response chan<- T, value T) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			responseAnomalyCounter.WithLabelValues(anomalyClosed).Inc()
+			log.Error(context.Background(), "Dutydb query response channel closed, dropping response", nil,
+				z.Any("recovered", r))
+			ok = false
+		}
+	}()
+
+	select {
+	case response <- value:
+		return true
+	default:
+		responseAnomalyCounter.WithLabelValues(anomalyFull).Inc()
+		log.Error(context.Background(), "Dutydb query response channel full, dropping response", nil)
+		return false
+	}
+}
+
 // cancelled returns true if channel has been closed.
 func cancelled(cancel <-chan struct{}) bool {
 	select {
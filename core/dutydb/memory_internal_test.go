@@ -3,13 +3,37 @@
 package dutydb
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	eth2api "github.com/attestantio/go-eth2-client/api"
+	eth2v1 "github.com/attestantio/go-eth2-client/api/v1"
+	eth2spec "github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/electra"
 	eth2p0 "github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jonboulle/clockwork"
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	pb "github.com/prometheus/client_model/go"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"golang.org/x/sync/errgroup"
 
+	"github.com/obolnetwork/charon/app/errors"
+	"github.com/obolnetwork/charon/app/log"
+	"github.com/obolnetwork/charon/app/z"
 	"github.com/obolnetwork/charon/core"
+	"github.com/obolnetwork/charon/testutil"
 )
 
 func TestCancelledQueries(t *testing.T) {
@@ -33,25 +57,1387 @@ func TestCancelledQueries(t *testing.T) {
 	_, err = db.AwaitSyncContribution(ctx, slot, 0, eth2p0.Root{})
 	require.ErrorContains(t, err, "shutdown")
 
+	shard := db.attShard(slot)
+
 	// Ensure all queries are preset.
 	require.NotEmpty(t, db.contribQueries)
-	require.NotEmpty(t, db.attQueries)
+	require.NotEmpty(t, shard.attQueries)
 	require.NotEmpty(t, db.proQueries)
 	require.NotEmpty(t, db.aggQueries)
 
 	// Resolve queries
 	db.resolveAggQueriesUnsafe()
-	db.resolveAttQueriesUnsafe()
+	shard.resolveAttQueriesUnsafe()
 	db.resolveContribQueriesUnsafe()
 	db.resolveProQueriesUnsafe()
 
 	// Ensure all queries are gone.
 	require.Empty(t, db.contribQueries)
-	require.Empty(t, db.attQueries)
+	require.Empty(t, shard.attQueries)
 	require.Empty(t, db.proQueries)
 	require.Empty(t, db.aggQueries)
 }
 
+func TestProposalRootIndex(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemDB(noopDeadliner{})
+
+	const slot = 123
+
+	proposal := testutil.RandomCapellaCoreVersionedProposal()
+	proposal.Capella.Slot = slot
+	root, err := proposal.Root()
+	require.NoError(t, err)
+
+	duty := core.NewProposerDuty(slot)
+	err = db.Store(ctx, duty, core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+	require.NoError(t, err)
+	require.Equal(t, root, db.proRoots[slot])
+
+	// Re-storing the same proposal compares against the cached root.
+	err = db.Store(ctx, duty, core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+	require.NoError(t, err)
+
+	// A different proposal for the same slot clashes with the cached root.
+	clash := testutil.RandomCapellaCoreVersionedProposal()
+	clash.Capella.Slot = slot
+	err = db.Store(ctx, duty, core.UnsignedDataSet{testutil.RandomCorePubKey(t): clash})
+	require.ErrorContains(t, err, "clashing blocks")
+	require.Equal(t, root, db.proRoots[slot])
+
+	// Deleting the duty also cleans the index.
+	require.NoError(t, db.deleteDutyUnsafe(duty))
+	require.Empty(t, db.proRoots)
+	require.Empty(t, db.proDuties)
+}
+
+func TestAttShards(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemDB(noopDeadliner{}, WithAttesterShards(4))
+	require.Len(t, db.attShards, 4)
+
+	// Store and await attestations for different slots concurrently.
+	const slots = 16
+	var eg errgroup.Group
+	for slot := range uint64(slots) {
+		eg.Go(func() error {
+			_, err := db.AwaitAttestation(ctx, slot, 0)
+			return err
+		})
+		eg.Go(func() error {
+			return db.Store(ctx, core.NewAttesterDuty(slot), core.UnsignedDataSet{
+				testutil.RandomCorePubKey(t): core.AttestationData{
+					Data: eth2p0.AttestationData{
+						Slot:   eth2p0.Slot(slot),
+						Source: &eth2p0.Checkpoint{},
+						Target: &eth2p0.Checkpoint{},
+					},
+					Duty: eth2v1.AttesterDuty{
+						Slot:             eth2p0.Slot(slot),
+						CommitteeLength:  8,
+						CommitteesAtSlot: 8,
+					},
+				},
+			})
+		})
+	}
+	require.NoError(t, eg.Wait())
+
+	// Each slot is stored in its own shard only.
+	for slot := range uint64(slots) {
+		for i, shard := range db.attShards {
+			_, ok := shard.attKeysBySlot[slot]
+			require.Equal(t, i == int(slot%4), ok)
+		}
+	}
+
+	// Deleting a duty only affects its shard.
+	require.NoError(t, db.deleteDutyUnsafe(core.NewAttesterDuty(1)))
+	require.NotContains(t, db.attShard(1).attKeysBySlot, uint64(1))
+	require.Contains(t, db.attShard(5).attKeysBySlot, uint64(5))
+}
+
+func TestCancelAllQueries(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemDB(noopDeadliner{})
+
+	const (
+		slot = 99
+		n    = 3
+	)
+
+	errCh := make(chan error, 4*n)
+	for range n {
+		go func() {
+			_, err := db.AwaitAttestation(ctx, slot, 0)
+			errCh <- err
+		}()
+		go func() {
+			_, err := db.AwaitAggAttestation(ctx, slot, eth2p0.Root{})
+			errCh <- err
+		}()
+		go func() {
+			_, err := db.AwaitProposal(ctx, slot)
+			errCh <- err
+		}()
+		go func() {
+			_, err := db.AwaitSyncContribution(ctx, slot, 0, eth2p0.Root{})
+			errCh <- err
+		}()
+	}
+
+	shard := db.attShard(slot)
+
+	// Wait for all queries to be enqueued.
+	require.Eventually(t, func() bool {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+		shard.mu.Lock()
+		defer shard.mu.Unlock()
+
+		return len(shard.attQueries) == n && len(db.aggQueries) == n &&
+			len(db.proQueries) == n && len(db.contribQueries) == n
+	}, time.Second, time.Millisecond)
+
+	db.CancelAllQueries()
+
+	for range 4 * n {
+		require.ErrorIs(t, <-errCh, ErrQueryCancelled)
+	}
+
+	require.Empty(t, shard.attQueries)
+	require.Empty(t, db.aggQueries)
+	require.Empty(t, db.proQueries)
+	require.Empty(t, db.contribQueries)
+
+	// The DB remains usable after cancelling queries.
+	proposal := testutil.RandomCapellaCoreVersionedProposal()
+	proposal.Capella.Slot = slot
+	err := db.Store(ctx, core.NewProposerDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+	require.NoError(t, err)
+
+	db.CancelAllQueries() // Stored duties are not affected.
+
+	resp, err := db.AwaitProposal(ctx, slot)
+	require.NoError(t, err)
+	require.Equal(t, proposal.Capella, resp.Capella)
+}
+
+func TestQueueHighWater(t *testing.T) {
+	var buf zaptest.Buffer
+	log.InitLogfmtForT(t, &buf)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	db := NewMemDB(noopDeadliner{}, WithQueueWarnThreshold(2))
+
+	const n = 3
+
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = db.AwaitProposal(ctx, 99)
+		}()
+	}
+
+	require.Eventually(t, func() bool {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+
+		return len(db.proQueries) == n
+	}, time.Second, time.Millisecond)
+
+	require.InDelta(t, n, promtestutil.ToFloat64(queueHighWaterGauge.WithLabelValues(queueProposer)), 0)
+	require.Contains(t, buf.String(), "Dutydb query queue exceeds threshold")
+	require.Contains(t, buf.String(), "type=proposer")
+	require.NotContains(t, buf.String(), "type=attester")
+
+	cancel()
+	wg.Wait()
+
+	// The high-water mark remains after the queries are cancelled.
+	db.mu.Lock()
+	db.resolveProQueriesUnsafe()
+	db.mu.Unlock()
+	require.InDelta(t, n, promtestutil.ToFloat64(queueHighWaterGauge.WithLabelValues(queueProposer)), 0)
+}
+
+func TestLoadFromExpired(t *testing.T) {
+	ctx := context.Background()
+
+	proposal := func(slot uint64) core.UnsignedDataSet {
+		proposal := testutil.RandomCapellaCoreVersionedProposal()
+		proposal.Capella.Slot = eth2p0.Slot(slot)
+
+		return core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal}
+	}
+
+	var buf bytes.Buffer
+	for _, slot := range []uint64{1, 2, 5} {
+		require.NoError(t, json.NewEncoder(&buf).Encode(struct {
+			Duty core.Duty            `json:"duty"`
+			Set  core.UnsignedDataSet `json:"unsigned_data_set"`
+		}{Duty: core.NewProposerDuty(slot), Set: proposal(slot)}))
+	}
+
+	// Duties before slot 3 are expired.
+	deadliner := &minSlotDeadliner{min: 3, ch: make(chan core.Duty, 1)}
+	db := NewMemDB(deadliner)
+	require.NoError(t, db.LoadFrom(&buf))
+	require.EqualValues(t, 3, db.Stats().Proposals)
+	require.Equal(t, map[core.Duty]bool{core.NewProposerDuty(1): true, core.NewProposerDuty(2): true}, db.preloadedExpired)
+
+	// Expiry of a later duty of the type deletes the preloaded expired duties.
+	require.NoError(t, db.Store(ctx, core.NewProposerDuty(3), proposal(3)))
+	deadliner.ch <- core.NewProposerDuty(3)
+	require.NoError(t, db.deleteExpired())
+
+	require.Empty(t, db.preloadedExpired)
+	require.NotContains(t, db.proDuties, uint64(1))
+	require.NotContains(t, db.proDuties, uint64(2))
+	require.Contains(t, db.proDuties, uint64(5))
+	require.EqualValues(t, 1, db.Stats().Proposals)
+}
+
+// minSlotDeadliner is a mock deadliner considering duties before the minimum slot expired.
+type minSlotDeadliner struct {
+	min uint64
+	ch  chan core.Duty
+}
+
+func (d *minSlotDeadliner) Add(duty core.Duty) bool {
+	return duty.Slot >= d.min
+}
+
+func (d *minSlotDeadliner) C() <-chan core.Duty {
+	return d.ch
+}
+
+func TestQueueHighWaterShards(t *testing.T) {
+	var buf zaptest.Buffer
+	log.InitLogfmtForT(t, &buf)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	db := NewMemDB(noopDeadliner{}, WithAttesterShards(2), WithQueueWarnThreshold(3))
+
+	pending := func() int {
+		var n int
+		for _, shard := range db.attShards {
+			shard.mu.Lock()
+			n += len(shard.attQueries)
+			shard.mu.Unlock()
+		}
+
+		return n
+	}
+
+	// Queries for consecutive slots are enqueued on different shards, neither reaching the threshold.
+	var wg sync.WaitGroup
+	await := func(slots ...uint64) {
+		for _, slot := range slots {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = db.AwaitAttestation(ctx, slot, 0)
+			}()
+		}
+	}
+
+	await(1, 1, 2, 2)
+	require.Eventually(t, func() bool { return pending() == 4 }, time.Second, time.Millisecond)
+
+	require.InDelta(t, 4, promtestutil.ToFloat64(queueHighWaterGauge.WithLabelValues(queueAttester)), 0)
+	require.Equal(t, 1, strings.Count(buf.String(), "msg=\"Dutydb query queue exceeds threshold"))
+	require.Contains(t, buf.String(), "type=attester")
+
+	// Drained shards don't count towards the total, so crossing the threshold again warns again.
+	cancel()
+	wg.Wait()
+	db.mu.Lock()
+	for _, shard := range db.attShards {
+		shard.mu.Lock()
+		shard.resolveAttQueriesUnsafe()
+		shard.mu.Unlock()
+	}
+	db.mu.Unlock()
+	require.Zero(t, pending())
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	await(1, 2, 3)
+	require.Eventually(t, func() bool { return pending() == 3 }, time.Second, time.Millisecond)
+	require.Equal(t, 2, strings.Count(buf.String(), "msg=\"Dutydb query queue exceeds threshold"))
+}
+
+func TestEvictionLag(t *testing.T) {
+	ctx := context.Background()
+	genesis := time.Date(2020, 12, 1, 12, 0, 23, 0, time.UTC)
+	slotDuration := 12 * time.Second
+
+	const slot = 5
+
+	clock := clockwork.NewFakeClockAt(genesis.Add(slot*slotDuration + time.Second))
+	deadliner := chanDeadliner(make(chan core.Duty, 1))
+	db := NewMemDB(deadliner, WithSlotClock(clock, genesis, slotDuration))
+
+	store := func(slot uint64) {
+		proposal := testutil.RandomCapellaCoreVersionedProposal()
+		proposal.Capella.Slot = eth2p0.Slot(slot)
+		err := db.Store(ctx, core.NewProposerDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+		require.NoError(t, err)
+	}
+
+	sample := func() (uint64, float64) {
+		var m pb.Metric
+		require.NoError(t, evictionLagHistogram.WithLabelValues(core.DutyProposer.String()).(prometheus.Histogram).Write(&m))
+
+		return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+	}
+
+	store(slot)
+	countBefore, sumBefore := sample()
+
+	// The duty expires and is deleted by the next store, 3s after the end of its slot.
+	clock.Advance(slotDuration + 2*time.Second)
+	deadliner <- core.NewProposerDuty(slot)
+	store(slot + 1)
+
+	count, sum := sample()
+	require.EqualValues(t, 1, count-countBefore)
+	require.InDelta(t, 3, sum-sumBefore, 0)
+	require.NotContains(t, db.proDuties, uint64(slot))
+}
+
+func TestProduceToAwait(t *testing.T) {
+	ctx := context.Background()
+	genesis := time.Date(2020, 12, 1, 12, 0, 23, 0, time.UTC)
+	slotDuration := 12 * time.Second
+
+	const slot = 5
+
+	clock := clockwork.NewFakeClockAt(genesis.Add(slot*slotDuration + time.Second))
+	deadliner := chanDeadliner(make(chan core.Duty, 1))
+	db := NewMemDB(deadliner, WithSlotClock(clock, genesis, slotDuration))
+
+	store := func(slot uint64) {
+		proposal := testutil.RandomCapellaCoreVersionedProposal()
+		proposal.Capella.Slot = eth2p0.Slot(slot)
+		err := db.Store(ctx, core.NewProposerDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+		require.NoError(t, err)
+	}
+
+	await := func(slot uint64) {
+		_, err := db.AwaitProposal(ctx, slot)
+		require.NoError(t, err)
+	}
+
+	sample := func() (uint64, float64) {
+		var m pb.Metric
+		require.NoError(t, produceToAwaitHistogram.WithLabelValues(core.DutyProposer.String()).(prometheus.Histogram).Write(&m))
+
+		return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+	}
+
+	t.Run("produce first", func(t *testing.T) {
+		countBefore, sumBefore := sample()
+
+		store(slot)
+		clock.Advance(2 * time.Second)
+		await(slot)
+
+		count, sum := sample()
+		require.EqualValues(t, 1, count-countBefore)
+		require.InDelta(t, 2, sum-sumBefore, 1e-9)
+
+		// Only the first await is observed.
+		clock.Advance(time.Second)
+		await(slot)
+		count, _ = sample()
+		require.EqualValues(t, 1, count-countBefore)
+	})
+
+	t.Run("await first", func(t *testing.T) {
+		countBefore, sumBefore := sample()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, err := db.AwaitProposal(ctx, slot+1)
+			assert.NoError(t, err)
+		}()
+
+		require.Eventually(t, func() bool {
+			return db.PendingQueryCountForSlot(slot + 1)[core.DutyProposer] == 1
+		}, time.Second, time.Millisecond)
+
+		clock.Advance(3 * time.Second)
+		store(slot + 1)
+		<-done
+
+		count, sum := sample()
+		require.EqualValues(t, 1, count-countBefore)
+		require.InDelta(t, -3, sum-sumBefore, 1e-9)
+	})
+
+	// Timestamps are deleted with their duty, unmatched earlier timestamps with it.
+	db.produce.awaited(core.NewProposerDuty(slot-1), clock.Now())
+	clock.Advance(slotDuration)
+	deadliner <- core.NewProposerDuty(slot)
+	store(slot + 2)
+	require.NotContains(t, db.produce.duties, core.NewProposerDuty(slot))
+	require.NotContains(t, db.produce.duties, core.NewProposerDuty(slot-1))
+	require.Contains(t, db.produce.duties, core.NewProposerDuty(slot+1))
+}
+
+func TestRetainedSlots(t *testing.T) {
+	ctx := context.Background()
+	deadliner := chanDeadliner(make(chan core.Duty, 10))
+	db := NewMemDB(deadliner)
+
+	retained := func() float64 {
+		return promtestutil.ToFloat64(retainedSlotsGauge)
+	}
+
+	proposal := testutil.RandomCapellaCoreVersionedProposal()
+	proposal.Capella.Slot = 1
+	err := db.Store(ctx, core.NewProposerDuty(1), core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+	require.NoError(t, err)
+	require.InDelta(t, 1, retained(), 0)
+
+	// Duties of different types for the same slot are counted once.
+	contrib := testutil.RandomSyncCommitteeContribution()
+	contrib.Slot = 1
+	err = db.Store(ctx, core.NewSyncContributionDuty(1), core.UnsignedDataSet{testutil.RandomCorePubKey(t): core.NewSyncContribution(contrib)})
+	require.NoError(t, err)
+	require.InDelta(t, 1, retained(), 0)
+
+	agg := testutil.RandomDenebCoreVersionedAggregateAttestation()
+	agg.Deneb.Data.Slot = 2
+	err = db.Store(ctx, core.NewAggregatorDuty(2), core.UnsignedDataSet{testutil.RandomCorePubKey(t): agg})
+	require.NoError(t, err)
+	require.InDelta(t, 2, retained(), 0)
+
+	expire := func(duty core.Duty, expect float64) {
+		t.Helper()
+		deadliner <- duty
+		require.NoError(t, db.deleteExpired())
+		require.InDelta(t, expect, retained(), 0)
+	}
+
+	expire(core.NewProposerDuty(1), 2)
+	expire(core.NewSyncContributionDuty(1), 1)
+	expire(core.NewAggregatorDuty(2), 0)
+}
+
+func TestStreamStoresDropped(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemDB(noopDeadliner{})
+
+	events, stop := db.StreamStores(ctx)
+	defer stop()
+
+	before := promtestutil.ToFloat64(streamDroppedCounter)
+
+	// Stores don't block if the stream isn't consumed, excess events are dropped.
+	const n = streamBuffer + 2
+	for slot := range uint64(n) {
+		proposal := testutil.RandomCapellaCoreVersionedProposal()
+		proposal.Capella.Slot = eth2p0.Slot(slot)
+		err := db.Store(ctx, core.NewProposerDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+		require.NoError(t, err)
+	}
+
+	require.Len(t, events, streamBuffer)
+	require.InDelta(t, n-streamBuffer, promtestutil.ToFloat64(streamDroppedCounter)-before, 0)
+
+	// The stream delivers copies of the stored data.
+	event := <-events
+	require.Equal(t, core.NewProposerDuty(0), event.Duty)
+	for _, data := range event.UnsignedSet {
+		proposal, ok := data.(core.VersionedProposal)
+		require.True(t, ok)
+		require.NotSame(t, db.proDuties[0].Capella, proposal.Capella)
+		require.Equal(t, *db.proDuties[0], proposal.VersionedProposal)
+	}
+}
+
+// unclonableData is unsigned data failing to clone.
+type unclonableData struct {
+	core.UnsignedData
+}
+
+func (unclonableData) Clone() (core.UnsignedData, error) {
+	return nil, errors.New("clone error")
+}
+
+func TestStreamStoresCloneError(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemDB(noopDeadliner{})
+
+	events1, stop1 := db.StreamStores(ctx)
+	defer stop1()
+	events2, stop2 := db.StreamStores(ctx)
+	defer stop2()
+
+	before := promtestutil.ToFloat64(streamDroppedCounter)
+
+	// A clone error drops the event for all streams.
+	db.publishStore(ctx, core.NewProposerDuty(1), core.UnsignedDataSet{testutil.RandomCorePubKey(t): unclonableData{}})
+	require.Empty(t, events1)
+	require.Empty(t, events2)
+	require.InDelta(t, 2, promtestutil.ToFloat64(streamDroppedCounter)-before, 0)
+
+	proposal := testutil.RandomCapellaCoreVersionedProposal()
+	db.publishStore(ctx, core.NewProposerDuty(2), core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+	require.Len(t, events1, 1)
+	require.Len(t, events2, 1)
+}
+
+func TestStreamStoresClosedContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	db := NewMemDB(noopDeadliner{})
+
+	// The stream is closed by the context callback, which may race with returning the stop function.
+	for range 100 {
+		events, stop := db.StreamStores(ctx)
+		_, ok := <-events
+		require.False(t, ok)
+		stop()
+	}
+
+	db.streamsMu.Lock()
+	defer db.streamsMu.Unlock()
+	require.Empty(t, db.streams)
+}
+
+func TestFIFOResolution(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemDB(noopDeadliner{})
+
+	const (
+		slot = 99
+		n    = 64
+	)
+
+	// Register queries concurrently, interleaved with cancelled queries and queries for other keys.
+	responses := make([]chan *eth2api.VersionedProposal, n)
+	order := make(chan int, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			cancel := make(chan struct{})
+			if i%3 == 0 {
+				close(cancel)
+			}
+			key := uint64(slot)
+			if i%5 == 0 {
+				key++
+			}
+
+			responses[i] = make(chan *eth2api.VersionedProposal, responseBuffer)
+
+			db.mu.Lock()
+			db.proQueries = append(db.proQueries, proQuery{Key: key, Response: responses[i], Cancel: cancel})
+			order <- i
+			db.mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	close(order)
+
+	var expect, unresolved []int
+	for i := range order {
+		switch {
+		case i%3 == 0:
+		case i%5 == 0:
+			unresolved = append(unresolved, i)
+		default:
+			expect = append(expect, i)
+		}
+	}
+
+	proposal := testutil.RandomCapellaCoreVersionedProposal()
+	proposal.Capella.Slot = slot
+
+	require.NoError(t, db.Store(ctx, core.NewProposerDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal}))
+
+	// All matching queries are resolved in the same pass, so no waiter registered earlier is left behind.
+	for i := range n {
+		var want int
+		if slices.Contains(expect, i) {
+			want = 1
+		}
+		require.Len(t, responses[i], want, "query %d", i)
+	}
+
+	// Unresolved queries retain their registration order.
+	require.Len(t, db.proQueries, len(unresolved))
+	for j, i := range unresolved {
+		require.Equal(t, (chan<- *eth2api.VersionedProposal)(responses[i]), db.proQueries[j].Response)
+	}
+}
+
+func TestAwaitOutcomes(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemDB(noopDeadliner{})
+
+	count := func(outcome string) float64 {
+		return promtestutil.ToFloat64(awaitCounter.WithLabelValues(queueProposer, outcome))
+	}
+	store := func(slot uint64) {
+		proposal := testutil.RandomCapellaCoreVersionedProposal()
+		proposal.Capella.Slot = eth2p0.Slot(slot)
+		err := db.Store(ctx, core.NewProposerDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+		require.NoError(t, err)
+	}
+	await := func(ctx context.Context, slot uint64) chan error {
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := db.AwaitProposal(ctx, slot)
+			errCh <- err
+		}()
+
+		require.Eventually(t, func() bool {
+			db.mu.Lock()
+			defer db.mu.Unlock()
+
+			return len(db.proQueries) == 1
+		}, time.Second, time.Millisecond)
+
+		return errCh
+	}
+
+	t.Run("immediate", func(t *testing.T) {
+		before := count(awaitImmediate)
+		store(1)
+		_, err := db.AwaitProposal(ctx, 1)
+		require.NoError(t, err)
+		require.InDelta(t, 1, count(awaitImmediate)-before, 0)
+	})
+
+	t.Run("blocked resolved", func(t *testing.T) {
+		before := count(awaitBlockedResolved)
+		errCh := await(ctx, 2)
+		store(2)
+		require.NoError(t, <-errCh)
+		require.InDelta(t, 1, count(awaitBlockedResolved)-before, 0)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		before := count(awaitTimeout)
+		timeoutCtx, cancel := context.WithTimeout(ctx, time.Millisecond)
+		defer cancel()
+		_, err := db.AwaitProposal(timeoutCtx, 3)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.InDelta(t, 1, count(awaitTimeout)-before, 0)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		before := count(awaitCancelled)
+		errCh := await(ctx, 4)
+		db.CancelAllQueries()
+		require.ErrorIs(t, <-errCh, ErrQueryCancelled)
+
+		cancelCtx, cancel := context.WithCancel(ctx)
+		errCh = await(cancelCtx, 4)
+		cancel()
+		require.ErrorIs(t, <-errCh, context.Canceled)
+		require.InDelta(t, 2, count(awaitCancelled)-before, 0)
+	})
+
+	t.Run("shutdown", func(t *testing.T) {
+		before := count(awaitShutdown)
+		db.Shutdown()
+		_, err := db.AwaitProposal(ctx, 5)
+		require.ErrorIs(t, err, ErrShutdown)
+		require.InDelta(t, 1, count(awaitShutdown)-before, 0)
+	})
+}
+
+func TestBlockedAwaits(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemDB(noopDeadliner{})
+
+	const n = 10
+
+	blocked := func() float64 {
+		return promtestutil.ToFloat64(blockedAwaitsGauge.WithLabelValues(queueProposer))
+	}
+	before := blocked()
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	var eg errgroup.Group
+	for i := range n {
+		slot := uint64(i % (n / 2))
+		eg.Go(func() error {
+			_, err := db.AwaitProposal(ctx, slot)
+			return err
+		})
+	}
+	eg.Go(func() error {
+		_, err := db.AwaitProposal(cancelCtx, n)
+		if !errors.Is(err, context.Canceled) {
+			return err
+		}
+
+		return nil
+	})
+
+	require.Eventually(t, func() bool {
+		return blocked()-before == n+1
+	}, time.Second, time.Millisecond)
+
+	for slot := range uint64(n / 2) {
+		proposal := testutil.RandomCapellaCoreVersionedProposal()
+		proposal.Capella.Slot = eth2p0.Slot(slot)
+		err := db.Store(ctx, core.NewProposerDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+		require.NoError(t, err)
+	}
+	cancel()
+
+	require.NoError(t, eg.Wait())
+	require.InDelta(t, 0, blocked()-before, 0)
+
+	// Awaits resolved immediately aren't counted as blocked.
+	_, err := db.AwaitProposal(ctx, 0)
+	require.NoError(t, err)
+	require.InDelta(t, 0, blocked()-before, 0)
+
+	done := parkAwait(queueProposer, true)
+	require.InDelta(t, 0, blocked()-before, 0)
+	done()
+	require.InDelta(t, 0, blocked()-before, 0)
+
+	done = parkAwait(queueProposer, false)
+	require.InDelta(t, 1, blocked()-before, 0)
+	done()
+	require.InDelta(t, 0, blocked()-before, 0)
+}
+
+func TestAggSlotMismatch(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemDB(noopDeadliner{})
+
+	const slot = 123
+
+	agg := testutil.RandomDenebCoreVersionedAggregateAttestation()
+	agg.Deneb.Data.Slot = slot + 1
+
+	err := db.Store(ctx, core.NewAggregatorDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): agg})
+	require.ErrorContains(t, err, "aggregated attestation slot mismatches duty slot")
+	require.True(t, z.ContainsField(err, z.U64("duty_slot", slot)))
+
+	require.Empty(t, db.aggDuties)
+	require.Empty(t, db.aggKeysBySlot)
+	require.Empty(t, db.aggKeysByCommittee)
+}
+
+func TestValueAwareProposals(t *testing.T) {
+	ctx := context.Background()
+
+	const slot = 123
+
+	newProposal := func(value int64) core.VersionedProposal {
+		proposal := testutil.RandomCapellaCoreVersionedProposal()
+		proposal.Capella.Slot = eth2p0.Slot(slot)
+		proposal.ExecutionValue = big.NewInt(value)
+		proposal.ConsensusValue = big.NewInt(1)
+
+		return proposal
+	}
+
+	store := func(db *MemDB, proposal core.VersionedProposal) error {
+		return db.Store(ctx, core.NewProposerDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+	}
+
+	overwrites := func(result string) float64 {
+		return promtestutil.ToFloat64(proposalOverwriteCounter.WithLabelValues(result))
+	}
+
+	low, high := newProposal(10), newProposal(20)
+	lowRoot, err := low.Root()
+	require.NoError(t, err)
+	highRoot, err := high.Root()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		first    core.VersionedProposal
+		second   core.VersionedProposal
+		expected eth2p0.Root
+		result   string
+	}{
+		{name: "higher value replaces", first: low, second: high, expected: highRoot, result: overwriteReplaced},
+		{name: "lower value kept", first: high, second: low, expected: highRoot, result: overwriteKept},
+		{name: "equal value kept", first: low, second: newProposal(10), expected: lowRoot, result: overwriteKept},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := NewMemDB(noopDeadliner{}, WithValueAwareProposals(true))
+			before := overwrites(test.result)
+
+			require.NoError(t, store(db, test.first))
+			require.NoError(t, store(db, test.second))
+
+			_, _, root, ok := db.ProposalInfo(slot)
+			require.True(t, ok)
+			require.Equal(t, test.expected, root)
+			require.InDelta(t, 1, overwrites(test.result)-before, 0)
+			require.EqualValues(t, 1, db.Stats().Proposals)
+
+			proposal, err := db.AwaitProposal(ctx, slot)
+			require.NoError(t, err)
+			root, err = proposal.Root()
+			require.NoError(t, err)
+			require.Equal(t, test.expected, root)
+		})
+	}
+
+	t.Run("imported", func(t *testing.T) {
+		db := NewMemDB(noopDeadliner{}, WithValueAwareProposals(true))
+		require.NoError(t, store(db, newProposal(15)))
+
+		var state bytes.Buffer
+		require.NoError(t, db.MarshalState(&state))
+
+		imported := NewMemDB(noopDeadliner{}, WithValueAwareProposals(true))
+		require.NoError(t, imported.UnmarshalState(&state))
+
+		// Values are imported, so lower value proposals are kept rather than clashing.
+		require.NoError(t, store(imported, low))
+		require.NoError(t, store(imported, high))
+
+		_, _, root, ok := imported.ProposalInfo(slot)
+		require.True(t, ok)
+		require.Equal(t, highRoot, root)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		db := NewMemDB(noopDeadliner{})
+		require.NoError(t, store(db, low))
+		require.ErrorContains(t, store(db, high), "clashing blocks")
+	})
+
+	t.Run("value unavailable", func(t *testing.T) {
+		db := NewMemDB(noopDeadliner{}, WithValueAwareProposals(true))
+		unvalued := testutil.RandomCapellaCoreVersionedProposal()
+		unvalued.Capella.Slot = eth2p0.Slot(slot)
+		require.NoError(t, store(db, unvalued))
+		require.ErrorContains(t, store(db, high), "clashing blocks")
+	})
+}
+
+func TestAggOverwrite(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemDB(noopDeadliner{})
+
+	base := testutil.RandomDenebCoreVersionedAggregateAttestation()
+	slot := uint64(base.Deneb.Data.Slot)
+	root, err := base.Deneb.Data.HashTreeRoot()
+	require.NoError(t, err)
+
+	// withBits returns a copy of the base aggregate with the provided aggregation bits set.
+	withBits := func(indices []uint64) core.VersionedAggregatedAttestation {
+		clone, err := base.Clone()
+		require.NoError(t, err)
+		agg := clone.(core.VersionedAggregatedAttestation)
+
+		agg.Deneb.AggregationBits = bitfield.NewBitlist(64)
+		for _, i := range indices {
+			agg.Deneb.AggregationBits.SetBitAt(i, true)
+		}
+
+		return agg
+	}
+
+	count := func(result string) float64 {
+		return promtestutil.ToFloat64(aggOverwriteCounter.WithLabelValues(result))
+	}
+
+	tests := []struct {
+		name     string
+		bits     []uint64
+		result   string // Empty if not overwritten.
+		expected uint64 // Number of aggregation bits stored afterwards.
+	}{
+		{name: "first", bits: []uint64{0, 1}, expected: 2},
+		{name: "identical", bits: []uint64{0, 1}, expected: 2},
+		{name: "more bits", bits: []uint64{0, 1, 2}, result: overwriteReplaced, expected: 3},
+		{name: "fewer bits", bits: []uint64{0}, result: overwriteKept, expected: 3},
+		{name: "equal bits", bits: []uint64{1, 2, 10}, result: overwriteReplaced, expected: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			replaced, kept := count(overwriteReplaced), count(overwriteKept)
+
+			provided := withBits(test.bits)
+			err := db.Store(ctx, core.NewAggregatorDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): provided})
+			require.NoError(t, err)
+
+			var expectReplaced, expectKept float64
+			switch test.result {
+			case overwriteReplaced:
+				expectReplaced = 1
+			case overwriteKept:
+				expectKept = 1
+			}
+			require.InDelta(t, expectReplaced, count(overwriteReplaced)-replaced, 0)
+			require.InDelta(t, expectKept, count(overwriteKept)-kept, 0)
+
+			stored, err := db.AwaitAggAttestation(ctx, slot, root)
+			require.NoError(t, err)
+			bits, err := stored.AggregationBits()
+			require.NoError(t, err)
+			require.Equal(t, test.expected, bits.Count())
+
+			if test.result != overwriteKept {
+				require.Equal(t, provided.Deneb.AggregationBits, bits)
+			}
+		})
+	}
+
+	require.Len(t, db.aggKeysBySlot[slot], 1)
+}
+
+func TestAggOverwriteOrder(t *testing.T) {
+	ctx := context.Background()
+
+	bitlist := func(n uint64) bitfield.Bitlist {
+		bits := bitfield.NewBitlist(64)
+		for i := range n {
+			bits.SetBitAt(i, true)
+		}
+
+		return bits
+	}
+	committees := func(indices ...uint64) bitfield.Bitvector64 {
+		bits := bitfield.NewBitvector64()
+		for _, i := range indices {
+			bits.SetBitAt(i, true)
+		}
+
+		return bits
+	}
+
+	denebAgg := func(data *eth2p0.AttestationData, n uint64) core.VersionedAggregatedAttestation {
+		agg := testutil.RandomDenebCoreVersionedAggregateAttestation()
+		agg.Deneb.Data = data
+		agg.Deneb.AggregationBits = bitlist(n)
+
+		return agg
+	}
+	electraAgg := func(data *eth2p0.AttestationData, n uint64, comms bitfield.Bitvector64) core.VersionedAggregatedAttestation {
+		return core.VersionedAggregatedAttestation{
+			VersionedAttestation: eth2spec.VersionedAttestation{
+				Version: eth2spec.DataVersionElectra,
+				Electra: &electra.Attestation{
+					AggregationBits: bitlist(n),
+					Data:            data,
+					Signature:       testutil.RandomEth2Signature(),
+					CommitteeBits:   comms,
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		stores func(data *eth2p0.AttestationData) []core.VersionedAggregatedAttestation
+		expect int // Index of the expected retained aggregate.
+	}{
+		{
+			name: "deneb ascending",
+			stores: func(data *eth2p0.AttestationData) []core.VersionedAggregatedAttestation {
+				return []core.VersionedAggregatedAttestation{denebAgg(data, 1), denebAgg(data, 5), denebAgg(data, 3)}
+			},
+			expect: 1,
+		},
+		{
+			name: "deneb descending",
+			stores: func(data *eth2p0.AttestationData) []core.VersionedAggregatedAttestation {
+				return []core.VersionedAggregatedAttestation{denebAgg(data, 5), denebAgg(data, 3), denebAgg(data, 1)}
+			},
+			expect: 0,
+		},
+		{
+			name: "electra same committee ascending",
+			stores: func(data *eth2p0.AttestationData) []core.VersionedAggregatedAttestation {
+				return []core.VersionedAggregatedAttestation{electraAgg(data, 2, committees(3)), electraAgg(data, 4, committees(3))}
+			},
+			expect: 1,
+		},
+		{
+			name: "electra same committee descending",
+			stores: func(data *eth2p0.AttestationData) []core.VersionedAggregatedAttestation {
+				return []core.VersionedAggregatedAttestation{electraAgg(data, 4, committees(3)), electraAgg(data, 2, committees(3))}
+			},
+			expect: 0,
+		},
+		{
+			name: "electra different committees",
+			stores: func(data *eth2p0.AttestationData) []core.VersionedAggregatedAttestation {
+				return []core.VersionedAggregatedAttestation{electraAgg(data, 4, committees(3)), electraAgg(data, 2, committees(5))}
+			},
+			expect: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := NewMemDB(noopDeadliner{})
+
+			data := testutil.RandomAttestationDataPhase0()
+			slot := uint64(data.Slot)
+			root, err := data.HashTreeRoot()
+			require.NoError(t, err)
+
+			aggs := test.stores(data)
+			for _, agg := range aggs {
+				err := db.Store(ctx, core.NewAggregatorDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): agg})
+				require.NoError(t, err)
+			}
+
+			stored, err := db.AwaitAggAttestation(ctx, slot, root)
+			require.NoError(t, err)
+			require.Equal(t, aggs[test.expect].VersionedAttestation, *stored)
+		})
+	}
+}
+
+func TestContributionClash(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemDB(noopDeadliner{})
+
+	const (
+		slot       = 123
+		subcommIdx = 1
+	)
+
+	beaconBlockRoot := testutil.RandomRoot()
+	newContrib := func() core.SyncContribution {
+		contrib := testutil.RandomSyncCommitteeContribution()
+		contrib.Slot = slot
+		contrib.SubcommitteeIndex = subcommIdx
+		contrib.BeaconBlockRoot = beaconBlockRoot
+
+		return core.NewSyncContribution(contrib)
+	}
+
+	existing, provided := newContrib(), newContrib()
+	existingRoot, err := existing.HashTreeRoot()
+	require.NoError(t, err)
+	providedRoot, err := provided.HashTreeRoot()
+	require.NoError(t, err)
+
+	duty := core.NewSyncContributionDuty(slot)
+	err = db.Store(ctx, duty, core.UnsignedDataSet{testutil.RandomCorePubKey(t): existing})
+	require.NoError(t, err)
+
+	before := promtestutil.ToFloat64(clashCounter.WithLabelValues(clashContribution))
+
+	err = db.Store(ctx, duty, core.UnsignedDataSet{testutil.RandomCorePubKey(t): provided})
+	require.ErrorContains(t, err, "clashing sync contributions")
+	require.True(t, z.ContainsField(err, z.Str("existing", hex.EncodeToString(existingRoot[:]))))
+	require.True(t, z.ContainsField(err, z.Str("provided", hex.EncodeToString(providedRoot[:]))))
+	require.True(t, z.ContainsField(err, z.U64("subcommittee_index", subcommIdx)))
+	require.InDelta(t, 1, promtestutil.ToFloat64(clashCounter.WithLabelValues(clashContribution))-before, 0)
+
+	// Re-storing the existing contribution isn't a clash.
+	err = db.Store(ctx, duty, core.UnsignedDataSet{testutil.RandomCorePubKey(t): existing})
+	require.NoError(t, err)
+	require.InDelta(t, 1, promtestutil.ToFloat64(clashCounter.WithLabelValues(clashContribution))-before, 0)
+}
+
+func TestAttestationClash(t *testing.T) {
+	ctx := context.Background()
+
+	const slot = 123
+
+	attData := func(commIdx, valIdx uint64) core.AttestationData {
+		return core.AttestationData{
+			Data: eth2p0.AttestationData{
+				Slot:            slot,
+				Index:           eth2p0.CommitteeIndex(commIdx),
+				BeaconBlockRoot: testutil.RandomRoot(),
+				Source:          &eth2p0.Checkpoint{},
+				Target:          &eth2p0.Checkpoint{},
+			},
+			Duty: eth2v1.AttesterDuty{
+				Slot:             slot,
+				CommitteeIndex:   eth2p0.CommitteeIndex(commIdx),
+				CommitteeLength:  8,
+				CommitteesAtSlot: 8,
+				ValidatorIndex:   eth2p0.ValidatorIndex(valIdx),
+			},
+		}
+	}
+
+	count := func(kind string) float64 {
+		return promtestutil.ToFloat64(attClashCounter.WithLabelValues(kind))
+	}
+
+	tests := []struct {
+		name   string
+		second core.AttestationData // Stored after attestation data for committee 1.
+		kind   string
+		err    string
+	}{
+		{
+			name:   "committee",
+			second: attData(1, 2),
+			kind:   attClashCommittee,
+			err:    "clashing attestation data",
+		},
+		{
+			name:   "committee 0 alias",
+			second: attData(2, 2),
+			kind:   attClashAlias,
+			err:    "clashing attestation data for committee index 0 alias",
+		},
+		{
+			name:   "committee 0 after alias",
+			second: attData(0, 2),
+			kind:   attClashAlias,
+			err:    "clashing attestation data for committee index 0 alias",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := NewMemDB(noopDeadliner{})
+
+			err := db.Store(ctx, core.NewAttesterDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): attData(1, 1)})
+			require.NoError(t, err)
+
+			committee, alias := count(attClashCommittee), count(attClashAlias)
+
+			err = db.Store(ctx, core.NewAttesterDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): test.second})
+			require.ErrorContains(t, err, test.err)
+
+			var expectCommittee, expectAlias float64
+			if test.kind == attClashCommittee {
+				expectCommittee = 1
+			} else {
+				expectAlias = 1
+			}
+			require.InDelta(t, expectCommittee, count(attClashCommittee)-committee, 0)
+			require.InDelta(t, expectAlias, count(attClashAlias)-alias, 0)
+		})
+	}
+}
+
+func TestIdenticalAttestationRestore(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemDB(noopDeadliner{})
+
+	const slot = 123
+
+	attData := core.AttestationData{
+		Data: eth2p0.AttestationData{
+			Slot:            slot,
+			BeaconBlockRoot: testutil.RandomRoot(),
+			Source:          &eth2p0.Checkpoint{Epoch: 1, Root: testutil.RandomRoot()},
+			Target:          &eth2p0.Checkpoint{Epoch: 2, Root: testutil.RandomRoot()},
+		},
+		Duty: eth2v1.AttesterDuty{
+			Slot:             slot,
+			CommitteeIndex:   3,
+			CommitteeLength:  8,
+			CommitteesAtSlot: 8,
+			ValidatorIndex:   4,
+		},
+	}
+	pubkey := testutil.RandomCorePubKey(t)
+	duty := core.NewAttesterDuty(slot)
+
+	require.NoError(t, db.Store(ctx, duty, core.UnsignedDataSet{pubkey: attData}))
+
+	shard := db.attShard(slot)
+	stored := shard.attDuties[attKey{Slot: slot, CommIdx: 3}]
+	keys := slices.Clone(shard.attKeysBySlot[slot])
+	require.Len(t, keys, 2) // Including the committee index 0 alias.
+
+	// Identical re-stores don't mutate the DB.
+	for range 10 {
+		require.NoError(t, db.Store(ctx, duty, core.UnsignedDataSet{pubkey: attData}))
+	}
+	require.Same(t, stored, shard.attDuties[attKey{Slot: slot, CommIdx: 3}])
+	require.Equal(t, keys, shard.attKeysBySlot[slot])
+
+	// Re-storing with a different pubkey still clashes.
+	err := db.Store(ctx, duty, core.UnsignedDataSet{testutil.RandomCorePubKey(t): attData})
+	require.ErrorContains(t, err, "clashing public key")
+
+	// Re-storing different data still clashes.
+	clash := attData
+	clash.Data.Target = &eth2p0.Checkpoint{Epoch: 2, Root: testutil.RandomRoot()}
+	err = db.Store(ctx, duty, core.UnsignedDataSet{pubkey: clash})
+	require.ErrorContains(t, err, "clashing attestation data")
+}
+
+func TestEqualAttData(t *testing.T) {
+	data := func() *eth2p0.AttestationData {
+		return &eth2p0.AttestationData{
+			Slot:            1,
+			Index:           2,
+			BeaconBlockRoot: eth2p0.Root{3},
+			Source:          &eth2p0.Checkpoint{Epoch: 4, Root: eth2p0.Root{5}},
+			Target:          &eth2p0.Checkpoint{Epoch: 6, Root: eth2p0.Root{7}},
+		}
+	}
+
+	require.True(t, equalAttData(data(), data()))
+
+	for _, mutate := range []func(*eth2p0.AttestationData){
+		func(d *eth2p0.AttestationData) { d.Slot++ },
+		func(d *eth2p0.AttestationData) { d.Index++ },
+		func(d *eth2p0.AttestationData) { d.BeaconBlockRoot[0]++ },
+		func(d *eth2p0.AttestationData) { d.Source.Epoch++ },
+		func(d *eth2p0.AttestationData) { d.Target.Root[0]++ },
+		func(d *eth2p0.AttestationData) { d.Source = nil },
+	} {
+		mutated := data()
+		mutate(mutated)
+		require.False(t, equalAttData(data(), mutated))
+		require.False(t, equalAttData(mutated, data()))
+	}
+
+	nilCheckpoints := &eth2p0.AttestationData{}
+	require.True(t, equalAttData(nilCheckpoints, &eth2p0.AttestationData{}))
+}
+
+func TestRespond(t *testing.T) {
+	anomalies := func(anomaly string) float64 {
+		return promtestutil.ToFloat64(responseAnomalyCounter.WithLabelValues(anomaly))
+	}
+	beforeFull, beforeClosed := anomalies(anomalyFull), anomalies(anomalyClosed)
+
+	response := make(chan int, responseBuffer)
+	require.True(t, respond(response, 1))
+
+	// A full channel never blocks.
+	require.False(t, respond(response, 2))
+	require.Equal(t, 1, <-response)
+	require.InDelta(t, 1, anomalies(anomalyFull)-beforeFull, 0)
+
+	// A closed channel doesn't panic.
+	close(response)
+	require.False(t, respond(response, 3))
+	require.InDelta(t, 1, anomalies(anomalyClosed)-beforeClosed, 0)
+}
+
+func TestResolveClosedResponse(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemDB(noopDeadliner{})
+
+	const slot = 123
+
+	before := promtestutil.ToFloat64(responseAnomalyCounter.WithLabelValues(anomalyClosed))
+
+	// Register a query whose response channel is closed by a buggy caller, followed by a valid query.
+	closed := make(chan *eth2api.VersionedProposal, responseBuffer)
+	close(closed)
+	valid := make(chan *eth2api.VersionedProposal, responseBuffer)
+
+	db.mu.Lock()
+	db.proQueries = append(db.proQueries,
+		proQuery{Key: slot, Response: closed, Cancel: make(chan struct{})},
+		proQuery{Key: slot, Response: valid, Cancel: make(chan struct{})},
+	)
+	db.mu.Unlock()
+
+	proposal := testutil.RandomCapellaCoreVersionedProposal()
+	proposal.Capella.Slot = slot
+	err := db.Store(ctx, core.NewProposerDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+	require.NoError(t, err)
+
+	// The resolve pass survives, resolves the valid query and reports the anomaly.
+	require.Len(t, valid, 1)
+	require.Empty(t, db.proQueries)
+	require.InDelta(t, 1, promtestutil.ToFloat64(responseAnomalyCounter.WithLabelValues(anomalyClosed))-before, 0)
+}
+
+func TestResolveStress(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	db := NewMemDB(noopDeadliner{})
+
+	const (
+		slots   = 16
+		waiters = 32
+	)
+
+	// Many queries for the same keys are resolved simultaneously by concurrent stores.
+	var eg errgroup.Group
+	for slot := range uint64(slots) {
+		for range waiters {
+			eg.Go(func() error {
+				_, err := db.AwaitProposal(ctx, slot)
+				return err
+			})
+			eg.Go(func() error {
+				_, err := db.AwaitAttestation(ctx, slot, 0)
+				return err
+			})
+			eg.Go(func() error {
+				_, err := db.MultiAwaitAttestation(ctx, slot, []uint64{0})
+				return err
+			})
+		}
+	}
+
+	for slot := range uint64(slots) {
+		eg.Go(func() error {
+			proposal := testutil.RandomCapellaCoreVersionedProposal()
+			proposal.Capella.Slot = eth2p0.Slot(slot)
+
+			return db.Store(ctx, core.NewProposerDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+		})
+		eg.Go(func() error {
+			return db.Store(ctx, core.NewAttesterDuty(slot), core.UnsignedDataSet{
+				testutil.RandomCorePubKey(t): core.AttestationData{
+					Data: eth2p0.AttestationData{
+						Slot:   eth2p0.Slot(slot),
+						Source: &eth2p0.Checkpoint{},
+						Target: &eth2p0.Checkpoint{},
+					},
+					Duty: eth2v1.AttesterDuty{
+						Slot:             eth2p0.Slot(slot),
+						CommitteeLength:  8,
+						CommitteesAtSlot: 8,
+					},
+				},
+			})
+		})
+	}
+
+	require.NoError(t, eg.Wait())
+}
+
+// chanDeadliner is a deadliner that expires the duties sent on the channel.
+type chanDeadliner chan core.Duty
+
+func (chanDeadliner) Add(core.Duty) bool {
+	return true
+}
+
+func (d chanDeadliner) C() <-chan core.Duty {
+	return d
+}
+
 type noopDeadliner struct{}
 
 func (t noopDeadliner) Add(duty core.Duty) bool {
@@ -3,17 +3,29 @@
 package dutydb_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	eth2api "github.com/attestantio/go-eth2-client/api"
 	eth2v1 "github.com/attestantio/go-eth2-client/api/v1"
 	eth2spec "github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
 	eth2p0 "github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
 
+	"github.com/obolnetwork/charon/app/errors"
+	"github.com/obolnetwork/charon/app/z"
 	"github.com/obolnetwork/charon/core"
 	"github.com/obolnetwork/charon/core/dutydb"
 	"github.com/obolnetwork/charon/testutil"
@@ -34,6 +46,53 @@ func TestShutdown(t *testing.T) {
 	err := <-errChan
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "shutdown")
+	require.ErrorIs(t, err, dutydb.ErrShutdown)
+}
+
+func TestShutdownAwaits(t *testing.T) {
+	ctx := context.Background()
+	db := dutydb.NewMemDB(new(testDeadliner))
+	db.Shutdown()
+
+	awaits := map[string]func() error{
+		"proposal": func() error {
+			_, err := db.AwaitProposal(ctx, 1)
+			return err
+		},
+		"attestation": func() error {
+			_, err := db.AwaitAttestation(ctx, 1, 0)
+			return err
+		},
+		"multi attestation": func() error {
+			_, err := db.MultiAwaitAttestation(ctx, 1, []uint64{0})
+			return err
+		},
+		"aggregated attestation": func() error {
+			_, err := db.AwaitAggAttestation(ctx, 1, testutil.RandomRoot())
+			return err
+		},
+		"any aggregated attestation": func() error {
+			_, err := db.AwaitAnyAggAttestation(ctx, 1)
+			return err
+		},
+		"sync contribution": func() error {
+			_, err := db.AwaitSyncContribution(ctx, 1, 0, testutil.RandomRoot())
+			return err
+		},
+		"multi sync contribution": func() error {
+			_, err := db.MultiAwaitSyncContribution(ctx, 1, []uint64{0}, testutil.RandomRoot())
+			return err
+		},
+	}
+
+	for name, await := range awaits {
+		t.Run(name, func(t *testing.T) {
+			err := await()
+			require.ErrorIs(t, err, dutydb.ErrShutdown)
+			require.NotErrorIs(t, err, dutydb.ErrQueryCancelled)
+			require.NotErrorIs(t, err, dutydb.ErrAwaitTimeout)
+		})
+	}
 }
 
 func TestMemDB(t *testing.T) {
@@ -145,7 +204,6 @@ func TestMemDBStoreUnsupported(t *testing.T) {
 		core.DutyExit,
 		core.DutyBuilderRegistration,
 		core.DutyRandao,
-		core.DutyPrepareAggregator,
 		core.DutySyncMessage,
 		core.DutyPrepareSyncContribution,
 		core.DutyInfoSync,
@@ -421,6 +479,170 @@ func TestMemDBClashProposer(t *testing.T) {
 	require.ErrorContains(t, err, "clashing blocks")
 }
 
+func TestMemDBProposalBlinded(t *testing.T) {
+	ctx := context.Background()
+
+	store := func(t *testing.T, db *dutydb.MemDB, slot uint64, proposal core.VersionedProposal) {
+		t.Helper()
+
+		err := db.Store(ctx, core.NewProposerDuty(slot), core.UnsignedDataSet{
+			testutil.RandomCorePubKey(t): proposal,
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("blinded only", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner))
+
+		const slot = 123
+		proposal := testutil.RandomCapellaVersionedBlindedProposal()
+		proposal.CapellaBlinded.Slot = slot
+
+		errCh := make(chan error, 1)
+		go func() {
+			resp, err := db.AwaitProposalBlinded(ctx, slot)
+			if err == nil && !resp.Blinded {
+				err = errors.New("expected blinded proposal")
+			}
+			errCh <- err
+		}()
+
+		store(t, db, slot, proposal)
+		require.NoError(t, <-errCh)
+
+		resp, err := db.AwaitProposalAnyKind(ctx, slot)
+		require.NoError(t, err)
+		require.True(t, resp.Blinded)
+	})
+
+	t.Run("full only", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner))
+
+		const slot = 123
+		proposal := testutil.RandomCapellaCoreVersionedProposal()
+		proposal.Capella.Slot = slot
+		store(t, db, slot, proposal)
+
+		resp, err := db.AwaitProposal(ctx, slot)
+		require.NoError(t, err)
+		require.False(t, resp.Blinded)
+		require.Equal(t, proposal.Capella, resp.Capella)
+
+		resp, err = db.AwaitProposalAnyKind(ctx, slot)
+		require.NoError(t, err)
+		require.False(t, resp.Blinded)
+	})
+
+	t.Run("mismatched", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner))
+
+		const (
+			blindedSlot = 123
+			fullSlot    = 124
+		)
+		blinded := testutil.RandomCapellaVersionedBlindedProposal()
+		blinded.CapellaBlinded.Slot = blindedSlot
+		store(t, db, blindedSlot, blinded)
+
+		full := testutil.RandomCapellaCoreVersionedProposal()
+		full.Capella.Slot = fullSlot
+		store(t, db, fullSlot, full)
+
+		_, err := db.AwaitProposal(ctx, blindedSlot)
+		require.ErrorContains(t, err, "mismatching proposal blinded flag")
+
+		_, err = db.AwaitProposalBlinded(ctx, fullSlot)
+		require.ErrorContains(t, err, "mismatching proposal blinded flag")
+	})
+
+	t.Run("mismatched while blocked", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner))
+
+		const slot = 123
+
+		// Blocked awaits fail as soon as a proposal of the other kind is stored, rather than waiting until cancelled.
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := db.AwaitProposal(ctx, slot)
+			errCh <- err
+		}()
+
+		require.Eventually(t, func() bool {
+			return db.PendingQueryCountForSlot(slot)[core.DutyProposer] == 1
+		}, time.Second, time.Millisecond)
+
+		blinded := testutil.RandomCapellaVersionedBlindedProposal()
+		blinded.CapellaBlinded.Slot = slot
+		store(t, db, slot, blinded)
+
+		require.ErrorContains(t, <-errCh, "mismatching proposal blinded flag")
+	})
+}
+
+func TestProposalInfo(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		proposal core.VersionedProposal
+		version  eth2spec.DataVersion
+		blinded  bool
+	}{
+		{
+			name:     "bellatrix full",
+			proposal: testutil.RandomBellatrixCoreVersionedProposal(),
+			version:  eth2spec.DataVersionBellatrix,
+		},
+		{
+			name:     "bellatrix blinded",
+			proposal: testutil.RandomBellatrixVersionedBlindedProposal(),
+			version:  eth2spec.DataVersionBellatrix,
+			blinded:  true,
+		},
+		{
+			name:     "capella full",
+			proposal: testutil.RandomCapellaCoreVersionedProposal(),
+			version:  eth2spec.DataVersionCapella,
+		},
+		{
+			name:     "capella blinded",
+			proposal: testutil.RandomCapellaVersionedBlindedProposal(),
+			version:  eth2spec.DataVersionCapella,
+			blinded:  true,
+		},
+		{
+			name:     "deneb full",
+			proposal: core.VersionedProposal{VersionedProposal: *testutil.RandomDenebVersionedProposal()},
+			version:  eth2spec.DataVersionDeneb,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := dutydb.NewMemDB(new(testDeadliner))
+
+			slot, err := test.proposal.Slot()
+			require.NoError(t, err)
+			root, err := test.proposal.Root()
+			require.NoError(t, err)
+
+			_, _, _, ok := db.ProposalInfo(uint64(slot))
+			require.False(t, ok)
+
+			err = db.Store(ctx, core.NewProposerDuty(uint64(slot)), core.UnsignedDataSet{
+				testutil.RandomCorePubKey(t): test.proposal,
+			})
+			require.NoError(t, err)
+
+			blinded, version, actualRoot, ok := db.ProposalInfo(uint64(slot))
+			require.True(t, ok)
+			require.Equal(t, test.blinded, blinded)
+			require.Equal(t, test.version, version)
+			require.Equal(t, eth2p0.Root(root), actualRoot)
+		})
+	}
+}
+
 func TestDutyExpiry(t *testing.T) {
 	ctx := context.Background()
 	deadliner := &testDeadliner{ch: make(chan core.Duty, 10)}
@@ -456,6 +678,1603 @@ func TestDutyExpiry(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestStats(t *testing.T) {
+	ctx := context.Background()
+
+	const n = 50
+	deadliner := &testDeadliner{ch: make(chan core.Duty, 2*n+1)}
+	db := dutydb.NewMemDB(deadliner)
+	require.Equal(t, dutydb.Stats{}, db.Stats())
+
+	// Read stats concurrently with stores, counts only increase while nothing expires.
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+
+		var prev dutydb.Stats
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			stats := db.Stats()
+			assert.GreaterOrEqual(t, stats.Proposals, prev.Proposals)
+			assert.GreaterOrEqual(t, stats.Attestations, prev.Attestations)
+			prev = stats
+		}
+	}()
+
+	var stores sync.WaitGroup
+	for i := range n {
+		slot := uint64(i + 1)
+		stores.Add(2)
+
+		go func() {
+			defer stores.Done()
+
+			block := testutil.RandomBellatrixBeaconBlock()
+			block.Slot = eth2p0.Slot(slot)
+			proposal, err := core.NewVersionedProposal(&eth2api.VersionedProposal{
+				Version:   eth2spec.DataVersionBellatrix,
+				Bellatrix: block,
+			})
+			assert.NoError(t, err)
+
+			err = db.Store(ctx, core.NewProposerDuty(slot), core.UnsignedDataSet{
+				testutil.RandomCorePubKey(t): proposal,
+			})
+			assert.NoError(t, err)
+		}()
+
+		go func() {
+			defer stores.Done()
+
+			att := testutil.RandomCoreAttestationData(t)
+			att.Data.Slot = eth2p0.Slot(slot)
+			att.Duty.Slot = eth2p0.Slot(slot)
+			att.Duty.CommitteeIndex = 1
+
+			err := db.Store(ctx, core.NewAttesterDuty(slot), core.UnsignedDataSet{
+				testutil.RandomCorePubKey(t): att,
+			})
+			assert.NoError(t, err)
+		}()
+	}
+
+	stores.Wait()
+	close(done)
+	readers.Wait()
+
+	// Each attestation is stored for its committee index and the committee index 0 alias.
+	require.Equal(t, dutydb.Stats{Proposals: n, Attestations: 2 * n}, db.Stats())
+
+	// Expire all duties, the next store deletes them.
+	deadliner.expire()
+
+	att := testutil.RandomCoreAttestationData(t)
+	att.Data.Slot = eth2p0.Slot(n + 1)
+	att.Duty.Slot = eth2p0.Slot(n + 1)
+	att.Duty.CommitteeIndex = 0
+
+	err := db.Store(ctx, core.NewAttesterDuty(n+1), core.UnsignedDataSet{
+		testutil.RandomCorePubKey(t): att,
+	})
+	require.NoError(t, err)
+	require.Equal(t, dutydb.Stats{Attestations: 1}, db.Stats())
+}
+
+func TestDeleteDuty(t *testing.T) {
+	ctx := context.Background()
+
+	const slot = 123
+
+	tests := []struct {
+		typ   core.DutyType
+		data  func() core.UnsignedData
+		count func(dutydb.Stats) int64
+	}{
+		{
+			typ: core.DutyProposer,
+			data: func() core.UnsignedData {
+				proposal := testutil.RandomCapellaCoreVersionedProposal()
+				proposal.Capella.Slot = slot
+
+				return proposal
+			},
+			count: func(s dutydb.Stats) int64 { return s.Proposals },
+		},
+		{
+			typ:   core.DutyAttester,
+			data:  func() core.UnsignedData { return attestationDataForT(slot, 1, 2) },
+			count: func(s dutydb.Stats) int64 { return s.Attestations },
+		},
+		{
+			typ: core.DutyAggregator,
+			data: func() core.UnsignedData {
+				agg := testutil.RandomDenebCoreVersionedAggregateAttestation()
+				agg.Deneb.Data.Slot = slot
+
+				return agg
+			},
+			count: func(s dutydb.Stats) int64 { return s.Aggregates },
+		},
+		{
+			typ: core.DutySyncContribution,
+			data: func() core.UnsignedData {
+				contrib := testutil.RandomSyncCommitteeContribution()
+				contrib.Slot = slot
+
+				return core.NewSyncContribution(contrib)
+			},
+			count: func(s dutydb.Stats) int64 { return s.Contributions },
+		},
+		{
+			typ: core.DutyPrepareAggregator,
+			data: func() core.UnsignedData {
+				sel := testutil.RandomBeaconCommitteeSelection()
+				sel.Slot = slot
+
+				return core.NewAggregatorSelection(sel, 1, true)
+			},
+			count: func(s dutydb.Stats) int64 { return s.Selections },
+		},
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner))
+		require.ErrorContains(t, db.DeleteDuty(core.NewRandaoDuty(slot)), "unknown duty type")
+	})
+
+	for i, test := range tests {
+		t.Run(test.typ.String(), func(t *testing.T) {
+			db := dutydb.NewMemDB(new(testDeadliner))
+			duty := core.Duty{Slot: slot, Type: test.typ}
+
+			// Deleting a duty that isn't stored is a no-op.
+			require.NoError(t, db.DeleteDuty(duty))
+
+			err := db.Store(ctx, duty, core.UnsignedDataSet{testutil.RandomCorePubKey(t): test.data()})
+			require.NoError(t, err)
+			require.Positive(t, test.count(db.Stats()))
+
+			// Other slots and duty types aren't deleted.
+			require.NoError(t, db.DeleteDuty(core.Duty{Slot: slot + 1, Type: test.typ}))
+			require.NoError(t, db.DeleteDuty(core.Duty{Slot: slot, Type: tests[(i+1)%len(tests)].typ}))
+			require.Positive(t, test.count(db.Stats()))
+
+			require.NoError(t, db.DeleteDuty(duty))
+			require.Zero(t, test.count(db.Stats()))
+
+			timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+			defer cancel()
+			_, _, err = db.AwaitAnyDuty(timeoutCtx, slot, test.typ)
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+
+			// The duty can be stored again.
+			err = db.Store(ctx, duty, core.UnsignedDataSet{testutil.RandomCorePubKey(t): test.data()})
+			require.NoError(t, err)
+
+			ok, _, err := db.AwaitAnyDuty(ctx, slot, test.typ)
+			require.NoError(t, err)
+			require.True(t, ok)
+		})
+	}
+}
+
+func TestTrim(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := clockwork.NewFakeClock()
+	deadlineFunc := func(duty core.Duty) (time.Time, bool) {
+		return clock.Now().Add(time.Minute), true
+	}
+	db := dutydb.NewMemDB(core.NewDeadlinerForT(ctx, t, deadlineFunc, clock))
+	go db.Trim(ctx)
+
+	const slot = uint64(123)
+	att := testutil.RandomCoreAttestationData(t)
+	att.Duty.Slot = eth2p0.Slot(slot)
+	err := db.Store(ctx, core.NewAttesterDuty(slot), core.UnsignedDataSet{
+		testutil.RandomCorePubKey(t): att,
+	})
+	require.NoError(t, err)
+
+	_, err = db.PubKeyByAttestation(ctx, uint64(att.Data.Slot), uint64(att.Duty.CommitteeIndex), uint64(att.Duty.ValidatorIndex))
+	require.NoError(t, err)
+
+	// The duty is deleted after its deadline without any further stores.
+	// The clock is advanced repeatedly since the deadliner resets its timer asynchronously.
+	require.Eventually(t, func() bool {
+		clock.Advance(time.Minute)
+
+		_, err := db.PubKeyByAttestation(ctx, uint64(att.Data.Slot), uint64(att.Duty.CommitteeIndex), uint64(att.Duty.ValidatorIndex))
+		return err != nil
+	}, time.Second, time.Millisecond)
+}
+
+func TestDefaultAwaitTimeout(t *testing.T) {
+	const timeout = 10 * time.Millisecond
+
+	t.Run("deadline absent", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner), dutydb.WithDefaultAwaitTimeout(timeout))
+
+		_, err := db.AwaitAttestation(context.Background(), 123, 0)
+		require.ErrorIs(t, err, dutydb.ErrAwaitTimeout)
+
+		_, err = db.AwaitProposal(context.Background(), 123)
+		require.ErrorIs(t, err, dutydb.ErrAwaitTimeout)
+	})
+
+	t.Run("deadline present", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner), dutydb.WithDefaultAwaitTimeout(time.Hour))
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		_, err := db.AwaitSyncContribution(ctx, 123, 0, eth2p0.Root{})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.NotErrorIs(t, err, dutydb.ErrAwaitTimeout)
+	})
+
+	t.Run("resolved before timeout", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner), dutydb.WithDefaultAwaitTimeout(time.Hour))
+
+		agg := testutil.RandomDenebCoreVersionedAggregateAttestation()
+		slot := uint64(agg.Deneb.Data.Slot)
+		err := db.Store(context.Background(), core.NewAggregatorDuty(slot), core.UnsignedDataSet{
+			testutil.RandomCorePubKey(t): agg,
+		})
+		require.NoError(t, err)
+
+		root, err := agg.Deneb.Data.HashTreeRoot()
+		require.NoError(t, err)
+
+		_, err = db.AwaitAggAttestation(context.Background(), slot, root)
+		require.NoError(t, err)
+	})
+}
+
+func TestMultiAwaitAttestation(t *testing.T) {
+	ctx := context.Background()
+
+	const slot = 123
+	commIdxs := []uint64{1, 2, 3}
+
+	t.Run("full", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner))
+
+		type result struct {
+			values map[uint64]*eth2p0.AttestationData
+			err    error
+		}
+		resultCh := make(chan result, 1)
+		go func() {
+			values, err := db.MultiAwaitAttestation(ctx, slot, commIdxs)
+			resultCh <- result{values: values, err: err}
+		}()
+
+		set := make(core.UnsignedDataSet)
+		for i, commIdx := range commIdxs {
+			set[testutil.RandomCorePubKey(t)] = attestationDataForT(slot, commIdx, uint64(i))
+		}
+		err := db.Store(ctx, core.NewAttesterDuty(slot), set)
+		require.NoError(t, err)
+
+		res := <-resultCh
+		require.NoError(t, res.err)
+		require.Len(t, res.values, len(commIdxs))
+		for _, commIdx := range commIdxs {
+			require.EqualValues(t, slot, res.values[commIdx].Slot)
+		}
+
+		// Committee index 0 resolves via the index 0 alias.
+		values, err := db.MultiAwaitAttestation(ctx, slot, []uint64{0, 1})
+		require.NoError(t, err)
+		require.Len(t, values, 2)
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner))
+
+		err := db.Store(ctx, core.NewAttesterDuty(slot), core.UnsignedDataSet{
+			testutil.RandomCorePubKey(t): attestationDataForT(slot, commIdxs[0], 0),
+		})
+		require.NoError(t, err)
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		values, err := db.MultiAwaitAttestation(timeoutCtx, slot, commIdxs)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Len(t, values, 1)
+		require.Contains(t, values, commIdxs[0])
+	})
+}
+
+func TestAwaitAllAttestations(t *testing.T) {
+	ctx := context.Background()
+	deadliner := &testDeadliner{ch: make(chan core.Duty, 10)}
+	db := dutydb.NewMemDB(deadliner)
+
+	const slot = 123
+
+	// Since Electra, the attestation data is identical for all committees.
+	data := testutil.RandomCoreAttestationData(t).Data
+	data.Slot = slot
+	data.Index = 0
+
+	store := func(commIdx uint64) {
+		att := testutil.RandomCoreAttestationData(t)
+		att.Data = data
+		att.Duty.Slot = slot
+		att.Duty.CommitteeIndex = eth2p0.CommitteeIndex(commIdx)
+
+		err := db.Store(ctx, core.NewAttesterDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): att})
+		require.NoError(t, err)
+	}
+
+	type result struct {
+		values map[uint64]*eth2p0.AttestationData
+		err    error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		values, err := db.AwaitAllAttestations(ctx, slot)
+		resultCh <- result{values: values, err: err}
+	}()
+
+	// pending returns true if the await is still blocked.
+	pending := func() bool {
+		return db.PendingQueryCountForSlot(slot)[core.DutyAttester] == 1
+	}
+	require.Eventually(t, pending, time.Second, time.Millisecond)
+
+	// The await blocks until the expected committees are set.
+	store(1)
+	require.True(t, pending())
+
+	require.NoError(t, db.SetExpectedCommittees(slot, []uint64{1, 2, 3}))
+	require.True(t, pending())
+
+	// Committees arriving incrementally don't resolve the await until the last one.
+	store(3)
+	require.True(t, pending())
+	require.Empty(t, resultCh)
+
+	store(2)
+	res := <-resultCh
+	require.NoError(t, res.err)
+	require.Len(t, res.values, 3)
+	for _, commIdx := range []uint64{1, 2, 3} {
+		require.Equal(t, data.String(), res.values[commIdx].String())
+	}
+
+	// Already available attestations resolve immediately.
+	values, err := db.AwaitAllAttestations(ctx, slot)
+	require.NoError(t, err)
+	require.Len(t, values, 3)
+
+	// Expectations are deleted with the expired attester duty.
+	deadliner.expire()
+	err = db.Store(ctx, core.NewProposerDuty(slot+1), core.UnsignedDataSet{
+		testutil.RandomCorePubKey(t): core.VersionedProposal{VersionedProposal: *testutil.RandomDenebVersionedProposal()},
+	})
+	require.NoError(t, err)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	values, err = db.AwaitAllAttestations(timeoutCtx, slot)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Empty(t, values)
+
+	// Expectations aren't set for expired duties.
+	err = dutydb.NewMemDB(expiredDeadliner{}).SetExpectedCommittees(slot, []uint64{1})
+	require.ErrorIs(t, err, dutydb.ErrExpiredDuty)
+}
+
+func TestMultiAwaitSyncContribution(t *testing.T) {
+	ctx := context.Background()
+
+	const slot = 123
+	var (
+		root        = testutil.RandomRoot()
+		subcommIdxs = []uint64{0, 1, 2}
+	)
+
+	newContrib := func(subcommIdx uint64) core.SyncContribution {
+		contrib := testutil.RandomSyncCommitteeContribution()
+		contrib.Slot = slot
+		contrib.SubcommitteeIndex = subcommIdx
+		contrib.BeaconBlockRoot = root
+
+		return core.NewSyncContribution(contrib)
+	}
+
+	t.Run("full", func(t *testing.T) {
+		deadliner := &testDeadliner{ch: make(chan core.Duty, 10)}
+		db := dutydb.NewMemDB(deadliner)
+
+		type result struct {
+			values map[uint64]*altair.SyncCommitteeContribution
+			err    error
+		}
+		resultCh := make(chan result, 1)
+		go func() {
+			values, err := db.MultiAwaitSyncContribution(ctx, slot, subcommIdxs, root)
+			resultCh <- result{values: values, err: err}
+		}()
+
+		// Store subcommittees one by one.
+		for _, subcommIdx := range subcommIdxs {
+			err := db.Store(ctx, core.NewSyncContributionDuty(slot), core.UnsignedDataSet{
+				testutil.RandomCorePubKey(t): newContrib(subcommIdx),
+			})
+			require.NoError(t, err)
+		}
+
+		res := <-resultCh
+		require.NoError(t, res.err)
+		require.Len(t, res.values, len(subcommIdxs))
+		for _, subcommIdx := range subcommIdxs {
+			require.Equal(t, subcommIdx, res.values[subcommIdx].SubcommitteeIndex)
+		}
+
+		// Expire the duty and ensure all subcommittees are deleted.
+		deadliner.expire()
+		err := db.Store(ctx, core.NewProposerDuty(slot+1), core.UnsignedDataSet{
+			testutil.RandomCorePubKey(t): core.VersionedProposal{VersionedProposal: *testutil.RandomDenebVersionedProposal()},
+		})
+		require.NoError(t, err)
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		values, err := db.MultiAwaitSyncContribution(timeoutCtx, slot, subcommIdxs, root)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Empty(t, values)
+	})
+
+	t.Run("subcommittee never arrives", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner))
+
+		for _, subcommIdx := range subcommIdxs[:2] {
+			err := db.Store(ctx, core.NewSyncContributionDuty(slot), core.UnsignedDataSet{
+				testutil.RandomCorePubKey(t): newContrib(subcommIdx),
+			})
+			require.NoError(t, err)
+		}
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		values, err := db.MultiAwaitSyncContribution(timeoutCtx, slot, subcommIdxs, root)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Len(t, values, 2)
+		require.NotContains(t, values, subcommIdxs[2])
+	})
+}
+
+func TestAwaitAnyAggAttestation(t *testing.T) {
+	ctx := context.Background()
+
+	const slot = 123
+
+	newAgg := func() core.VersionedAggregatedAttestation {
+		agg := testutil.RandomDenebCoreVersionedAggregateAttestation()
+		agg.Deneb.Data.Slot = slot
+
+		return agg
+	}
+
+	t.Run("zero", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner))
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		_, err := db.AwaitAnyAggAttestation(timeoutCtx, slot)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("one", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner))
+		agg := newAgg()
+
+		type result struct {
+			att *eth2spec.VersionedAttestation
+			err error
+		}
+		resultCh := make(chan result, 1)
+		go func() {
+			att, err := db.AwaitAnyAggAttestation(ctx, slot)
+			resultCh <- result{att: att, err: err}
+		}()
+
+		err := db.Store(ctx, core.NewAggregatorDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): agg})
+		require.NoError(t, err)
+
+		res := <-resultCh
+		require.NoError(t, res.err)
+		require.Equal(t, agg.Deneb, res.att.Deneb)
+	})
+
+	t.Run("multiple", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner))
+
+		err := db.Store(ctx, core.NewAggregatorDuty(slot), core.UnsignedDataSet{
+			testutil.RandomCorePubKey(t): newAgg(),
+			testutil.RandomCorePubKey(t): newAgg(),
+		})
+		require.NoError(t, err)
+
+		_, err = db.AwaitAnyAggAttestation(ctx, slot)
+		require.ErrorContains(t, err, "ambiguous aggregated attestations for slot")
+	})
+}
+
+func TestAggAttestationsForCommittee(t *testing.T) {
+	ctx := context.Background()
+	deadliner := &testDeadliner{ch: make(chan core.Duty, 10)}
+	db := dutydb.NewMemDB(deadliner)
+
+	const slot = 123
+
+	newAgg := func(slot, commIdx uint64) core.VersionedAggregatedAttestation {
+		agg := testutil.RandomDenebCoreVersionedAggregateAttestation()
+		agg.Deneb.Data.Slot = eth2p0.Slot(slot)
+		agg.Deneb.Data.Index = eth2p0.CommitteeIndex(commIdx)
+
+		return agg
+	}
+
+	comm1a, comm1b := newAgg(slot, 1), newAgg(slot, 1)
+	comm2 := newAgg(slot, 2)
+	otherSlot := newAgg(slot+1, 1)
+
+	err := db.Store(ctx, core.NewAggregatorDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): comm1a})
+	require.NoError(t, err)
+	err = db.Store(ctx, core.NewAggregatorDuty(slot), core.UnsignedDataSet{
+		testutil.RandomCorePubKey(t): comm2,
+		testutil.RandomCorePubKey(t): comm1b,
+	})
+	require.NoError(t, err)
+	err = db.Store(ctx, core.NewAggregatorDuty(slot+1), core.UnsignedDataSet{testutil.RandomCorePubKey(t): otherSlot})
+	require.NoError(t, err)
+
+	// Re-storing an aggregate doesn't duplicate it in the index.
+	err = db.Store(ctx, core.NewAggregatorDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): comm1a})
+	require.NoError(t, err)
+
+	aggs, err := db.AggAttestationsForCommittee(slot, 1)
+	require.NoError(t, err)
+	require.Len(t, aggs, 2)
+	require.Equal(t, comm1a.Deneb, aggs[0].Deneb)
+	require.Equal(t, comm1b.Deneb, aggs[1].Deneb)
+
+	aggs, err = db.AggAttestationsForCommittee(slot, 2)
+	require.NoError(t, err)
+	require.Len(t, aggs, 1)
+	require.Equal(t, comm2.Deneb, aggs[0].Deneb)
+
+	aggs, err = db.AggAttestationsForCommittee(slot+1, 1)
+	require.NoError(t, err)
+	require.Len(t, aggs, 1)
+	require.Equal(t, otherSlot.Deneb, aggs[0].Deneb)
+
+	aggs, err = db.AggAttestationsForCommittee(slot, 3)
+	require.NoError(t, err)
+	require.Empty(t, aggs)
+
+	// Returned aggregates are clones.
+	aggs, err = db.AggAttestationsForCommittee(slot+1, 1)
+	require.NoError(t, err)
+	aggs[0].Deneb.Data.Index = 99
+	aggs, err = db.AggAttestationsForCommittee(slot+1, 1)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, aggs[0].Deneb.Data.Index)
+
+	// Expired duties are removed from the index.
+	deadliner.expire()
+	err = db.Store(ctx, core.NewProposerDuty(slot+2), core.UnsignedDataSet{
+		testutil.RandomCorePubKey(t): core.VersionedProposal{VersionedProposal: *testutil.RandomDenebVersionedProposal()},
+	})
+	require.NoError(t, err)
+
+	for _, s := range []uint64{slot, slot + 1} {
+		aggs, err = db.AggAttestationsForCommittee(s, 1)
+		require.NoError(t, err)
+		require.Empty(t, aggs)
+	}
+}
+
+func TestSyncContributionsForSlot(t *testing.T) {
+	ctx := context.Background()
+	db := dutydb.NewMemDB(new(testDeadliner))
+
+	const slot = 123
+
+	newContrib := func(slot, subcommIdx uint64, root eth2p0.Root) *altair.SyncCommitteeContribution {
+		contrib := testutil.RandomSyncCommitteeContribution()
+		contrib.Slot = eth2p0.Slot(slot)
+		contrib.SubcommitteeIndex = subcommIdx
+		contrib.BeaconBlockRoot = root
+
+		return contrib
+	}
+
+	root1, root2 := testutil.RandomRoot(), testutil.RandomRoot()
+	expected := []*altair.SyncCommitteeContribution{
+		newContrib(slot, 0, root1),
+		newContrib(slot, 1, root1),
+		newContrib(slot, 3, root1),
+		newContrib(slot, 1, root2),
+	}
+	for _, contrib := range expected {
+		err := db.Store(ctx, core.NewSyncContributionDuty(slot), core.UnsignedDataSet{
+			testutil.RandomCorePubKey(t): core.NewSyncContribution(contrib),
+		})
+		require.NoError(t, err)
+	}
+
+	err := db.Store(ctx, core.NewSyncContributionDuty(slot+1), core.UnsignedDataSet{
+		testutil.RandomCorePubKey(t): core.NewSyncContribution(newContrib(slot+1, 0, root1)),
+	})
+	require.NoError(t, err)
+
+	contribs, err := db.SyncContributionsForSlot(slot)
+	require.NoError(t, err)
+	require.Equal(t, expected, contribs)
+
+	// Returned contributions are clones.
+	contribs[0].SubcommitteeIndex = 99
+	contribs, err = db.SyncContributionsForSlot(slot)
+	require.NoError(t, err)
+	require.Equal(t, expected, contribs)
+
+	contribs, err = db.SyncContributionsForSlot(slot + 1)
+	require.NoError(t, err)
+	require.Len(t, contribs, 1)
+
+	contribs, err = db.SyncContributionsForSlot(slot + 2)
+	require.NoError(t, err)
+	require.Empty(t, contribs)
+}
+
+func TestAwaitAggregatorSelection(t *testing.T) {
+	ctx := context.Background()
+
+	const (
+		slot    = 123
+		commIdx = 2
+	)
+
+	newSelection := func(valIdx uint64, isAggregator bool) core.AggregatorSelection {
+		sel := testutil.RandomBeaconCommitteeSelection()
+		sel.Slot = slot
+		sel.ValidatorIndex = eth2p0.ValidatorIndex(valIdx)
+
+		return core.NewAggregatorSelection(sel, commIdx, isAggregator)
+	}
+
+	deadliner := &testDeadliner{ch: make(chan core.Duty, 10)}
+	db := dutydb.NewMemDB(deadliner)
+
+	type result struct {
+		isAggregator bool
+		proof        eth2p0.BLSSignature
+		err          error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		isAggregator, proof, err := db.AwaitAggregatorSelection(ctx, slot, commIdx, 1)
+		resultCh <- result{isAggregator: isAggregator, proof: proof, err: err}
+	}()
+
+	selected, notSelected := newSelection(1, true), newSelection(2, false)
+	err := db.Store(ctx, core.NewPrepareAggregatorDuty(slot), core.UnsignedDataSet{
+		testutil.RandomCorePubKey(t): selected,
+		testutil.RandomCorePubKey(t): notSelected,
+	})
+	require.NoError(t, err)
+
+	t.Run("selected", func(t *testing.T) {
+		res := <-resultCh
+		require.NoError(t, res.err)
+		require.True(t, res.isAggregator)
+		require.Equal(t, selected.Selection.SelectionProof, res.proof)
+	})
+
+	t.Run("not selected", func(t *testing.T) {
+		isAggregator, proof, err := db.AwaitAggregatorSelection(ctx, slot, commIdx, 2)
+		require.NoError(t, err)
+		require.False(t, isAggregator)
+		require.Equal(t, notSelected.Selection.SelectionProof, proof)
+	})
+
+	t.Run("clash", func(t *testing.T) {
+		clashing := newSelection(1, false)
+		clashing.Selection.SelectionProof = selected.Selection.SelectionProof
+		err := db.Store(ctx, core.NewPrepareAggregatorDuty(slot), core.UnsignedDataSet{
+			testutil.RandomCorePubKey(t): clashing,
+		})
+		require.ErrorContains(t, err, "clashing aggregator selections")
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		deadliner.expire()
+		err := db.Store(ctx, core.NewProposerDuty(slot+1), core.UnsignedDataSet{
+			testutil.RandomCorePubKey(t): core.VersionedProposal{VersionedProposal: *testutil.RandomDenebVersionedProposal()},
+		})
+		require.NoError(t, err)
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, _, err = db.AwaitAggregatorSelection(timeoutCtx, slot, commIdx, 1)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestAwaitAnyDuty(t *testing.T) {
+	ctx := context.Background()
+
+	type result struct {
+		ok    bool
+		count int
+		err   error
+	}
+
+	// awaitAsync awaits any duty of the type at the slot in a goroutine, returning the result channel.
+	awaitAsync := func(db *dutydb.MemDB, slot uint64, typ core.DutyType) <-chan result {
+		resultCh := make(chan result, 1)
+		go func() {
+			ok, count, err := db.AwaitAnyDuty(ctx, slot, typ)
+			resultCh <- result{ok: ok, count: count, err: err}
+		}()
+
+		return resultCh
+	}
+
+	t.Run("proposer", func(t *testing.T) {
+		const slot = 123
+		db := dutydb.NewMemDB(new(testDeadliner))
+		resultCh := awaitAsync(db, slot, core.DutyProposer)
+
+		block := testutil.RandomBellatrixBeaconBlock()
+		block.Slot = slot
+		proposal, err := core.NewVersionedProposal(&eth2api.VersionedProposal{
+			Version:   eth2spec.DataVersionBellatrix,
+			Bellatrix: block,
+		})
+		require.NoError(t, err)
+
+		err = db.Store(ctx, core.NewProposerDuty(slot), core.UnsignedDataSet{
+			testutil.RandomCorePubKey(t): proposal,
+		})
+		require.NoError(t, err)
+
+		res := <-resultCh
+		require.NoError(t, res.err)
+		require.True(t, res.ok)
+		require.Equal(t, 1, res.count)
+
+		// Already stored duties resolve immediately.
+		ok, count, err := db.AwaitAnyDuty(ctx, slot, core.DutyProposer)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("attester", func(t *testing.T) {
+		const slot = 123
+		db := dutydb.NewMemDB(new(testDeadliner))
+		resultCh := awaitAsync(db, slot, core.DutyAttester)
+
+		// Other duty types for the slot don't resolve the query.
+		sel := testutil.RandomBeaconCommitteeSelection()
+		sel.Slot = slot
+		err := db.Store(ctx, core.NewPrepareAggregatorDuty(slot), core.UnsignedDataSet{
+			testutil.RandomCorePubKey(t): core.NewAggregatorSelection(sel, 1, true),
+		})
+		require.NoError(t, err)
+		require.Empty(t, resultCh)
+
+		// Since Electra, the attestation data is identical for all committees.
+		data := testutil.RandomCoreAttestationData(t).Data
+		data.Slot = slot
+		data.Index = 0
+
+		set := make(core.UnsignedDataSet)
+		for i, commIdx := range []eth2p0.CommitteeIndex{1, 2, 2} {
+			att := testutil.RandomCoreAttestationData(t)
+			att.Data = data
+			att.Duty.Slot = slot
+			att.Duty.CommitteeIndex = commIdx
+			att.Duty.ValidatorIndex = eth2p0.ValidatorIndex(i)
+			set[testutil.RandomCorePubKey(t)] = att
+		}
+
+		err = db.Store(ctx, core.NewAttesterDuty(slot), set)
+		require.NoError(t, err)
+
+		// Attester duties are counted by committee, excluding the committee index 0 alias.
+		res := <-resultCh
+		require.NoError(t, res.err)
+		require.True(t, res.ok)
+		require.Equal(t, 2, res.count)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner))
+		_, _, err := db.AwaitAnyDuty(ctx, 123, core.DutyRandao)
+		require.ErrorContains(t, err, "unsupported duty type")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner))
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		ok, _, err := db.AwaitAnyDuty(timeoutCtx, 123, core.DutyAggregator)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.False(t, ok)
+	})
+}
+
+func TestPendingQueryCountForSlot(t *testing.T) {
+	ctx := context.Background()
+	db := dutydb.NewMemDB(new(testDeadliner))
+
+	const slot = 123
+	require.Empty(t, db.PendingQueryCountForSlot(slot))
+
+	var eg errgroup.Group
+	await := func(fn func() error) {
+		eg.Go(func() error {
+			err := fn()
+			if !errors.Is(err, dutydb.ErrQueryCancelled) {
+				return err
+			}
+
+			return nil
+		})
+	}
+
+	for range 2 {
+		await(func() error {
+			_, err := db.AwaitProposal(ctx, slot)
+			return err
+		})
+	}
+	await(func() error {
+		_, _, err := db.AwaitAnyDuty(ctx, slot, core.DutyProposer)
+		return err
+	})
+	for commIdx := range uint64(3) {
+		await(func() error {
+			_, err := db.AwaitAttestation(ctx, slot, commIdx)
+			return err
+		})
+	}
+	await(func() error {
+		_, err := db.MultiAwaitAttestation(ctx, slot, []uint64{1, 2})
+		return err
+	})
+	await(func() error {
+		_, err := db.AwaitAnyAggAttestation(ctx, slot)
+		return err
+	})
+	await(func() error {
+		_, _, err := db.AwaitAggregatorSelection(ctx, slot, 1, 2)
+		return err
+	})
+
+	// Queries for other slots aren't counted.
+	await(func() error {
+		_, err := db.AwaitProposal(ctx, slot+1)
+		return err
+	})
+
+	expected := map[core.DutyType]int{
+		core.DutyProposer:          3,
+		core.DutyAttester:          4,
+		core.DutyAggregator:        1,
+		core.DutyPrepareAggregator: 1,
+	}
+	require.Eventually(t, func() bool {
+		return maps.Equal(expected, db.PendingQueryCountForSlot(slot))
+	}, time.Second, time.Millisecond)
+	require.Equal(t, map[core.DutyType]int{core.DutyProposer: 1}, db.PendingQueryCountForSlot(slot+1))
+	require.Equal(t, 10, db.PendingQueryCount())
+
+	db.CancelAllQueries()
+	require.NoError(t, eg.Wait())
+	require.Empty(t, db.PendingQueryCountForSlot(slot))
+	require.Zero(t, db.PendingQueryCount())
+}
+
+func TestLastStore(t *testing.T) {
+	ctx := context.Background()
+	clock := clockwork.NewFakeClockAt(time.Unix(1000, 0))
+	db := dutydb.NewMemDB(new(testDeadliner), dutydb.WithSlotClock(clock, clock.Now(), time.Second))
+
+	_, ok := db.LastStore()
+	require.False(t, ok)
+
+	clock.Advance(time.Second)
+	proposal := testutil.RandomCapellaCoreVersionedProposal()
+	proposal.Capella.Slot = 1
+	err := db.Store(ctx, core.NewProposerDuty(1), core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+	require.NoError(t, err)
+
+	at, ok := db.LastStore()
+	require.True(t, ok)
+	require.True(t, clock.Now().Equal(at))
+
+	// Failed stores don't update the last store time.
+	clock.Advance(time.Second)
+	err = db.Store(ctx, core.NewProposerDuty(100), core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+	require.Error(t, err)
+
+	last, ok := db.LastStore()
+	require.True(t, ok)
+	require.Equal(t, at, last)
+}
+
+func TestStoreCancelled(t *testing.T) {
+	const slot = 123
+
+	deadliner := new(testDeadliner)
+	db := dutydb.NewMemDB(deadliner)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	proposal := testutil.RandomCapellaCoreVersionedProposal()
+	proposal.Capella.Slot = slot
+	agg := testutil.RandomDenebCoreVersionedAggregateAttestation()
+	agg.Deneb.Data.Slot = slot
+	contrib := testutil.RandomSyncCommitteeContribution()
+	contrib.Slot = slot
+
+	stores := []struct {
+		duty core.Duty
+		data core.UnsignedData
+	}{
+		{duty: core.NewProposerDuty(slot), data: proposal},
+		{duty: core.NewAttesterDuty(slot), data: attestationDataForT(slot, 1, 2)},
+		{duty: core.NewAggregatorDuty(slot), data: agg},
+		{duty: core.NewSyncContributionDuty(slot), data: core.NewSyncContribution(contrib)},
+	}
+
+	for _, store := range stores {
+		err := db.Store(ctx, store.duty, core.UnsignedDataSet{testutil.RandomCorePubKey(t): store.data})
+		require.ErrorIs(t, err, context.Canceled, store.duty.String())
+	}
+
+	// Nothing was stored or added to the deadliner.
+	require.Zero(t, db.SlotFootprint(slot))
+	require.Empty(t, deadliner.added)
+
+	contribs, err := db.SyncContributionsForSlot(slot)
+	require.NoError(t, err)
+	require.Empty(t, contribs)
+}
+
+func TestAwaitAttestationStrict(t *testing.T) {
+	ctx := context.Background()
+	db := dutydb.NewMemDB(new(testDeadliner))
+
+	const slot = 123
+
+	// Committee 0 only exists as the alias of committee 1.
+	att := attestationDataForT(slot, 1, 2)
+	err := db.Store(ctx, core.NewAttesterDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): att})
+	require.NoError(t, err)
+
+	// Lenient queries resolve the alias.
+	data, err := db.AwaitAttestation(ctx, slot, 0)
+	require.NoError(t, err)
+	require.Equal(t, att.Data.String(), data.String())
+
+	// Strict queries resolve the exact committee only.
+	data, err = db.AwaitAttestationStrict(ctx, slot, 1)
+	require.NoError(t, err)
+	require.Equal(t, att.Data.String(), data.String())
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = db.AwaitAttestationStrict(timeoutCtx, slot, 0)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Strict queries for committee 0 resolve once data is stored for committee 0 itself.
+	type result struct {
+		data *eth2p0.AttestationData
+		err  error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		data, err := db.AwaitAttestationStrict(ctx, slot, 0)
+		resultCh <- result{data: data, err: err}
+	}()
+
+	err = db.Store(ctx, core.NewAttesterDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): attestationDataForT(slot, 0, 3)})
+	require.NoError(t, err)
+
+	res := <-resultCh
+	require.NoError(t, res.err)
+	require.Equal(t, att.Data.String(), res.data.String())
+}
+
+func TestStoreExpiredDuty(t *testing.T) {
+	ctx := context.Background()
+	db := dutydb.NewMemDB(expiredDeadliner{})
+
+	const slot = 123
+
+	duty := core.NewAttesterDuty(slot)
+	err := db.Store(ctx, duty, core.UnsignedDataSet{testutil.RandomCorePubKey(t): attestationDataForT(slot, 1, 2)})
+	require.ErrorIs(t, err, dutydb.ErrExpiredDuty)
+	require.ErrorContains(t, err, "not storing unsigned data for expired duty")
+	require.True(t, z.ContainsField(err, z.Any("duty", duty)))
+
+	// Other store failures aren't reported as expired.
+	err = dutydb.NewMemDB(new(testDeadliner)).Store(ctx, core.NewRandaoDuty(slot), core.UnsignedDataSet{})
+	require.Error(t, err)
+	require.NotErrorIs(t, err, dutydb.ErrExpiredDuty)
+}
+
+func TestReader(t *testing.T) {
+	ctx := context.Background()
+	db := dutydb.NewMemDB(new(testDeadliner))
+	reader := db.Reader()
+
+	_, ok := reader.(core.DutyDB)
+	require.False(t, ok, "reader must not allow storing")
+
+	const slot = 123
+	att := attestationDataForT(slot, 1, 2)
+	pubkey := testutil.RandomCorePubKey(t)
+
+	// Stored after creating the reader, so it reads the live DB.
+	err := db.Store(ctx, core.NewAttesterDuty(slot), core.UnsignedDataSet{pubkey: att})
+	require.NoError(t, err)
+
+	data, err := reader.AwaitAttestation(ctx, slot, 1)
+	require.NoError(t, err)
+	require.Equal(t, att.Data.String(), data.String())
+
+	actual, err := reader.PubKeyByAttestation(ctx, slot, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, pubkey, actual)
+}
+
+func TestFakeDB(t *testing.T) {
+	ctx := context.Background()
+
+	var db core.DutyDB = dutydb.NewFakeDB()
+
+	// Slot zero duties are accepted since the fake never rejects duties as expired.
+	const slot = 0
+
+	proposal := testutil.RandomCapellaCoreVersionedProposal()
+	proposal.Capella.Slot = slot
+
+	// Await before storing to exercise blocking queries.
+	resp := make(chan *eth2api.VersionedProposal, 1)
+	go func() {
+		block, err := db.AwaitProposal(ctx, slot)
+		require.NoError(t, err)
+		resp <- block
+	}()
+
+	err := db.Store(ctx, core.NewProposerDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+	require.NoError(t, err)
+	require.Equal(t, proposal.Capella, (<-resp).Capella)
+
+	att := attestationDataForT(slot, 1, 2)
+	pubkey := testutil.RandomCorePubKey(t)
+	err = db.Store(ctx, core.NewAttesterDuty(slot), core.UnsignedDataSet{pubkey: att})
+	require.NoError(t, err)
+
+	data, err := db.AwaitAttestation(ctx, slot, 1)
+	require.NoError(t, err)
+	require.Equal(t, att.Data.String(), data.String())
+
+	actual, err := db.PubKeyByAttestation(ctx, slot, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, pubkey, actual)
+}
+
+func TestLoadFrom(t *testing.T) {
+	ctx := context.Background()
+
+	const slot = 123
+
+	att := attestationDataForT(slot, 1, 2)
+	attPubKey := testutil.RandomCorePubKey(t)
+
+	proposal := testutil.RandomCapellaCoreVersionedProposal()
+	proposal.Capella.Slot = slot
+
+	agg := testutil.RandomDenebCoreVersionedAggregateAttestation()
+	agg.Deneb.Data.Slot = slot
+	aggRoot, err := agg.Deneb.Data.HashTreeRoot()
+	require.NoError(t, err)
+
+	contrib := testutil.RandomSyncCommitteeContribution()
+	contrib.Slot = slot
+
+	type entry struct {
+		Duty core.Duty            `json:"duty"`
+		Set  core.UnsignedDataSet `json:"unsigned_data_set"`
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range []entry{
+		{Duty: core.NewAttesterDuty(slot), Set: core.UnsignedDataSet{attPubKey: att}},
+		{Duty: core.NewProposerDuty(slot), Set: core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal}},
+		{Duty: core.NewAggregatorDuty(slot), Set: core.UnsignedDataSet{testutil.RandomCorePubKey(t): agg}},
+		{Duty: core.NewSyncContributionDuty(slot), Set: core.UnsignedDataSet{testutil.RandomCorePubKey(t): core.NewSyncContribution(contrib)}},
+	} {
+		require.NoError(t, enc.Encode(e))
+	}
+
+	// Duties are loaded even though the deadliner considers them expired.
+	db := dutydb.NewMemDB(expiredDeadliner{})
+	require.NoError(t, db.LoadFrom(&buf))
+
+	attData, err := db.AwaitAttestation(ctx, slot, 1)
+	require.NoError(t, err)
+	require.Equal(t, att.Data.String(), attData.String())
+
+	pubkey, err := db.PubKeyByAttestation(ctx, slot, 1, 2)
+	require.NoError(t, err)
+	require.Equal(t, attPubKey, pubkey)
+
+	actualProposal, err := db.AwaitProposal(ctx, slot)
+	require.NoError(t, err)
+	require.Equal(t, proposal.Capella, actualProposal.Capella)
+
+	actualAgg, err := db.AwaitAggAttestation(ctx, slot, aggRoot)
+	require.NoError(t, err)
+	require.Equal(t, agg.Deneb, actualAgg.Deneb)
+
+	actualContrib, err := db.AwaitSyncContribution(ctx, slot, contrib.SubcommitteeIndex, contrib.BeaconBlockRoot)
+	require.NoError(t, err)
+	require.Equal(t, contrib, actualContrib)
+
+	// Invalid entries are reported.
+	buf.Reset()
+	require.NoError(t, enc.Encode(entry{Duty: core.NewAttesterDuty(slot), Set: core.UnsignedDataSet{attPubKey: att}}))
+	require.NoError(t, enc.Encode(entry{Duty: core.NewRandaoDuty(slot), Set: core.UnsignedDataSet{attPubKey: att}}))
+
+	err = dutydb.NewMemDB(new(testDeadliner)).LoadFrom(&buf)
+	require.ErrorContains(t, err, "invalid duty entry")
+	require.ErrorContains(t, err, "unsupported duty type")
+}
+
+func TestAwaitDuty(t *testing.T) {
+	ctx := context.Background()
+	db := dutydb.NewMemDB(new(testDeadliner))
+
+	const slot = 123
+
+	att := attestationDataForT(slot, 1, 2)
+	proposal := testutil.RandomCapellaCoreVersionedProposal()
+	proposal.Capella.Slot = slot
+	agg := testutil.RandomDenebCoreVersionedAggregateAttestation()
+	agg.Deneb.Data.Slot = slot
+	aggRoot, err := agg.Deneb.Data.HashTreeRoot()
+	require.NoError(t, err)
+	contrib := testutil.RandomSyncCommitteeContribution()
+	contrib.Slot = slot
+	sel := testutil.RandomCoreAggregatorSelection()
+	sel.Selection.Slot = slot
+
+	for duty, data := range map[core.Duty]core.UnsignedData{
+		core.NewAttesterDuty(slot):          att,
+		core.NewProposerDuty(slot):          proposal,
+		core.NewAggregatorDuty(slot):        agg,
+		core.NewSyncContributionDuty(slot):  core.NewSyncContribution(contrib),
+		core.NewPrepareAggregatorDuty(slot): sel,
+	} {
+		err := db.Store(ctx, duty, core.UnsignedDataSet{testutil.RandomCorePubKey(t): data})
+		require.NoError(t, err)
+	}
+
+	t.Run("attester", func(t *testing.T) {
+		resp, err := db.AwaitDuty(ctx, core.NewAttesterDuty(slot), dutydb.AwaitArgs{CommIdx: 1})
+		require.NoError(t, err)
+		require.IsType(t, core.AttestationData{}, resp)
+		attData := resp.(core.AttestationData)
+		require.Equal(t, att.Data.String(), attData.Data.String())
+		require.EqualValues(t, 1, attData.Duty.CommitteeIndex)
+	})
+
+	t.Run("proposer", func(t *testing.T) {
+		resp, err := db.AwaitDuty(ctx, core.NewProposerDuty(slot), dutydb.AwaitArgs{})
+		require.NoError(t, err)
+		require.IsType(t, core.VersionedProposal{}, resp)
+		require.Equal(t, proposal.Capella, resp.(core.VersionedProposal).Capella)
+	})
+
+	t.Run("aggregator", func(t *testing.T) {
+		resp, err := db.AwaitDuty(ctx, core.NewAggregatorDuty(slot), dutydb.AwaitArgs{Root: aggRoot})
+		require.NoError(t, err)
+		require.IsType(t, core.VersionedAggregatedAttestation{}, resp)
+		require.Equal(t, agg.Deneb, resp.(core.VersionedAggregatedAttestation).Deneb)
+	})
+
+	t.Run("sync contribution", func(t *testing.T) {
+		resp, err := db.AwaitDuty(ctx, core.NewSyncContributionDuty(slot), dutydb.AwaitArgs{
+			SubcommIdx: contrib.SubcommitteeIndex,
+			Root:       contrib.BeaconBlockRoot,
+		})
+		require.NoError(t, err)
+		require.Equal(t, core.NewSyncContribution(contrib), resp)
+	})
+
+	t.Run("aggregator selection", func(t *testing.T) {
+		resp, err := db.AwaitDuty(ctx, core.NewPrepareAggregatorDuty(slot), dutydb.AwaitArgs{
+			CommIdx: uint64(sel.CommitteeIndex),
+			ValIdx:  uint64(sel.Selection.ValidatorIndex),
+		})
+		require.NoError(t, err)
+		require.Equal(t, sel, resp)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := db.AwaitDuty(ctx, core.NewRandaoDuty(slot), dutydb.AwaitArgs{})
+		require.ErrorContains(t, err, "unsupported duty type")
+	})
+}
+
+func TestFutureSlotRejection(t *testing.T) {
+	ctx := context.Background()
+	genesis := time.Date(2020, 12, 1, 12, 0, 23, 0, time.UTC)
+	slotDuration := 12 * time.Second
+
+	const current = 10
+
+	clock := clockwork.NewFakeClockAt(genesis.Add(current*slotDuration + time.Second))
+	db := dutydb.NewMemDB(new(testDeadliner), dutydb.WithSlotClock(clock, genesis, slotDuration))
+
+	store := func(slot uint64) error {
+		return db.Store(ctx, core.NewAttesterDuty(slot), core.UnsignedDataSet{
+			testutil.RandomCorePubKey(t): attestationDataForT(slot, 0, 0),
+		})
+	}
+
+	require.NoError(t, store(current))
+	require.NoError(t, store(current+1))
+	require.ErrorContains(t, store(current+2), "not storing unsigned data for future duty")
+
+	// The duty is accepted once the clock reaches the previous slot.
+	clock.Advance(slotDuration)
+	require.NoError(t, store(current+2))
+}
+
+func TestRejectSlotZero(t *testing.T) {
+	ctx := context.Background()
+
+	store := func(db *dutydb.MemDB) error {
+		return db.Store(ctx, core.NewAttesterDuty(0), core.UnsignedDataSet{
+			testutil.RandomCorePubKey(t): attestationDataForT(0, 0, 0),
+		})
+	}
+
+	// Slot zero duties are stored by default.
+	require.NoError(t, store(dutydb.NewMemDB(new(testDeadliner))))
+
+	db := dutydb.NewMemDB(new(testDeadliner), dutydb.WithRejectSlotZero(true))
+	require.ErrorContains(t, store(db), "not storing unsigned data for slot zero duty")
+
+	// The rejected data isn't stored.
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err := db.AwaitAttestation(timeoutCtx, 0, 0)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Slot zero duties are accepted within the genesis window.
+	genesis := time.Date(2020, 12, 1, 12, 0, 23, 0, time.UTC)
+	slotDuration := 12 * time.Second
+	clock := clockwork.NewFakeClockAt(genesis.Add(time.Second))
+	db = dutydb.NewMemDB(new(testDeadliner), dutydb.WithRejectSlotZero(true), dutydb.WithSlotClock(clock, genesis, slotDuration))
+	require.NoError(t, store(db))
+
+	clock.Advance(3 * slotDuration)
+	require.ErrorContains(t, store(db), "not storing unsigned data for slot zero duty")
+}
+
+func TestExpectedProposer(t *testing.T) {
+	ctx := context.Background()
+
+	const (
+		slot        = 123
+		expectedIdx = eth2p0.ValidatorIndex(7)
+	)
+
+	store := func(db *dutydb.MemDB, slot uint64, proposerIdx eth2p0.ValidatorIndex) error {
+		block := testutil.RandomBellatrixBeaconBlock()
+		block.Slot = eth2p0.Slot(slot)
+		block.ProposerIndex = proposerIdx
+		proposal, err := core.NewVersionedProposal(&eth2api.VersionedProposal{
+			Version:   eth2spec.DataVersionBellatrix,
+			Bellatrix: block,
+		})
+		require.NoError(t, err)
+
+		return db.Store(ctx, core.NewProposerDuty(slot), core.UnsignedDataSet{
+			testutil.RandomCorePubKey(t): proposal,
+		})
+	}
+
+	expected := func(s uint64) (eth2p0.ValidatorIndex, bool) {
+		return expectedIdx, s == slot
+	}
+
+	// Proposer indices aren't validated by default.
+	require.NoError(t, store(dutydb.NewMemDB(new(testDeadliner)), slot, expectedIdx+1))
+
+	t.Run("match", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner), dutydb.WithExpectedProposer(expected))
+		require.NoError(t, store(db, slot, expectedIdx))
+
+		_, _, _, ok := db.ProposalInfo(slot)
+		require.True(t, ok)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner), dutydb.WithExpectedProposer(expected))
+		err := store(db, slot, expectedIdx+1)
+		require.ErrorContains(t, err, "proposal proposer index mismatches expected proposer")
+		require.True(t, z.ContainsField(err, z.U64("expected", uint64(expectedIdx))))
+
+		// The rejected proposal isn't stored.
+		_, _, _, ok := db.ProposalInfo(slot)
+		require.False(t, ok)
+	})
+
+	t.Run("no expectation", func(t *testing.T) {
+		db := dutydb.NewMemDB(new(testDeadliner), dutydb.WithExpectedProposer(expected))
+		require.NoError(t, store(db, slot+1, expectedIdx+1))
+	})
+}
+
+func TestDeadlinerHorizon(t *testing.T) {
+	db := dutydb.NewMemDB(new(testDeadliner))
+	_, ok := db.DeadlinerHorizon()
+	require.False(t, ok)
+
+	const horizon = 25 * time.Second
+	db = dutydb.NewMemDB(&horizonDeadliner{horizon: horizon})
+	actual, ok := db.DeadlinerHorizon()
+	require.True(t, ok)
+	require.Equal(t, horizon, actual)
+}
+
+func TestSlotFootprint(t *testing.T) {
+	ctx := context.Background()
+	deadliner := &testDeadliner{ch: make(chan core.Duty, 10)}
+	db := dutydb.NewMemDB(deadliner)
+
+	const slot = 123
+	require.Zero(t, db.SlotFootprint(slot))
+
+	// The footprint grows as more committees are stored for the slot.
+	prev := 0
+	for commIdx := range uint64(4) {
+		err := db.Store(ctx, core.NewAttesterDuty(slot), core.UnsignedDataSet{
+			testutil.RandomCorePubKey(t): attestationDataForT(slot, commIdx, commIdx),
+		})
+		require.NoError(t, err)
+
+		footprint := db.SlotFootprint(slot)
+		require.Greater(t, footprint, prev)
+		prev = footprint
+	}
+
+	proposal := testutil.RandomCapellaCoreVersionedProposal()
+	proposal.Capella.Slot = slot
+	err := db.Store(ctx, core.NewProposerDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+	require.NoError(t, err)
+	require.Greater(t, db.SlotFootprint(slot), prev)
+	prev = db.SlotFootprint(slot)
+
+	sel := testutil.RandomCoreAggregatorSelection()
+	sel.Selection.Slot = slot
+	err = db.Store(ctx, core.NewPrepareAggregatorDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): sel})
+	require.NoError(t, err)
+	require.Greater(t, db.SlotFootprint(slot), prev)
+	require.Zero(t, db.SlotFootprint(slot+1))
+
+	// The footprint drops to zero after the duties are deleted.
+	deadliner.expire()
+	err = db.Store(ctx, core.NewAttesterDuty(slot+1), core.UnsignedDataSet{
+		testutil.RandomCorePubKey(t): attestationDataForT(slot+1, 0, 0),
+	})
+	require.NoError(t, err)
+	require.Zero(t, db.SlotFootprint(slot))
+}
+
+func TestStreamStores(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	active := dutydb.NewMemDB(new(testDeadliner))
+	replica := dutydb.NewMemDB(new(testDeadliner))
+
+	events, stop := active.StreamStores(ctx)
+	done := make(chan struct{})
+	go func() {
+		replica.ApplyStores(ctx, events)
+		close(done)
+	}()
+
+	const slot = 123
+
+	proposal := testutil.RandomCapellaCoreVersionedProposal()
+	proposal.Capella.Slot = slot
+	err := active.Store(ctx, core.NewProposerDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+	require.NoError(t, err)
+
+	att := attestationDataForT(slot, 2, 5)
+	err = active.Store(ctx, core.NewAttesterDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): att})
+	require.NoError(t, err)
+
+	agg := testutil.RandomDenebCoreVersionedAggregateAttestation()
+	agg.Deneb.Data.Slot = slot
+	err = active.Store(ctx, core.NewAggregatorDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): agg})
+	require.NoError(t, err)
+
+	contrib := testutil.RandomSyncCommitteeContribution()
+	contrib.Slot = slot
+	err = active.Store(ctx, core.NewSyncContributionDuty(slot), core.UnsignedDataSet{
+		testutil.RandomCorePubKey(t): core.NewSyncContribution(contrib),
+	})
+	require.NoError(t, err)
+
+	// The replica answers the same queries as the active DB.
+	for _, db := range []*dutydb.MemDB{active, replica} {
+		resp, err := db.AwaitProposal(ctx, slot)
+		require.NoError(t, err)
+		require.Equal(t, proposal.Capella, resp.Capella)
+
+		attData, err := db.AwaitAttestation(ctx, slot, 2)
+		require.NoError(t, err)
+		require.Equal(t, att.Data, *attData)
+
+		root, err := agg.Deneb.Data.HashTreeRoot()
+		require.NoError(t, err)
+		aggResp, err := db.AwaitAggAttestation(ctx, slot, root)
+		require.NoError(t, err)
+		require.Equal(t, agg.Deneb, aggResp.Deneb)
+
+		contribResp, err := db.AwaitSyncContribution(ctx, slot, contrib.SubcommitteeIndex, contrib.BeaconBlockRoot)
+		require.NoError(t, err)
+		require.Equal(t, contrib, contribResp)
+	}
+
+	// Stopping the stream closes the channel, stopping the replica.
+	stop()
+	<-done
+	stop() // Stopping twice is a noop.
+}
+
+func BenchmarkMemDBStoreProposal(b *testing.B) {
+	ctx := context.Background()
+	db := dutydb.NewMemDB(new(testDeadliner))
+
+	const slot = 123
+
+	proposal := testutil.RandomCapellaCoreVersionedProposal()
+	proposal.Capella.Slot = slot
+	pubkey, err := core.PubKeyFromBytes(testutil.RandomBytes48())
+	require.NoError(b, err)
+	set := core.UnsignedDataSet{pubkey: proposal}
+	duty := core.NewProposerDuty(slot)
+
+	for b.Loop() {
+		// All but the first iteration are retries of the same proposal.
+		err := db.Store(ctx, duty, set)
+		require.NoError(b, err)
+	}
+}
+
+func BenchmarkMemDBStoreIdenticalAttestation(b *testing.B) {
+	ctx := context.Background()
+	db := dutydb.NewMemDB(new(testDeadliner))
+
+	const slot = 123
+
+	pubkey, err := core.PubKeyFromBytes(testutil.RandomBytes48())
+	require.NoError(b, err)
+	set := core.UnsignedDataSet{pubkey: attestationDataForT(slot, 1, 2)}
+	duty := core.NewAttesterDuty(slot)
+
+	for b.Loop() {
+		// All but the first iteration are VC retries of the same attestation data.
+		err := db.Store(ctx, duty, set)
+		require.NoError(b, err)
+	}
+}
+
+func BenchmarkMemDBStoreAttestationsParallel(b *testing.B) {
+	for _, shards := range []int{1, 8} {
+		b.Run(fmt.Sprintf("shards_%d", shards), func(b *testing.B) {
+			ctx := context.Background()
+			db := dutydb.NewMemDB(new(testDeadliner), dutydb.WithAttesterShards(shards))
+
+			pubkeys := make([]core.PubKey, 64)
+			for i := range pubkeys {
+				var err error
+				pubkeys[i], err = core.PubKeyFromBytes(testutil.RandomBytes48())
+				require.NoError(b, err)
+			}
+
+			var valIdx atomic.Uint64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					idx := valIdx.Add(1)
+					slot := idx % 32
+
+					err := db.Store(ctx, core.NewAttesterDuty(slot), core.UnsignedDataSet{
+						pubkeys[idx%64]: attestationDataForT(slot, 0, idx),
+					})
+					require.NoError(b, err)
+				}
+			})
+		})
+	}
+}
+
+// attestationDataForT returns attestation data for the slot and committee. The data itself is
+// identical for all committees (as post-electra) so that it doesn't clash on the committee index 0 alias.
+func attestationDataForT(slot, commIdx, valIdx uint64) core.AttestationData {
+	return core.AttestationData{
+		Data: eth2p0.AttestationData{
+			Slot:   eth2p0.Slot(slot),
+			Source: &eth2p0.Checkpoint{},
+			Target: &eth2p0.Checkpoint{},
+		},
+		Duty: eth2v1.AttesterDuty{
+			Slot:             eth2p0.Slot(slot),
+			CommitteeIndex:   eth2p0.CommitteeIndex(commIdx),
+			CommitteeLength:  8,
+			CommitteesAtSlot: 8,
+			ValidatorIndex:   eth2p0.ValidatorIndex(valIdx),
+		},
+	}
+}
+
+// expiredDeadliner is a mock deadliner considering all duties expired.
+type expiredDeadliner struct{}
+
+func (expiredDeadliner) Add(core.Duty) bool {
+	return false
+}
+
+func (expiredDeadliner) C() <-chan core.Duty {
+	return nil
+}
+
+// horizonDeadliner is a mock deadliner implementation exposing its horizon.
+type horizonDeadliner struct {
+	testDeadliner
+
+	horizon time.Duration
+}
+
+func (d *horizonDeadliner) Horizon() time.Duration {
+	return d.horizon
+}
+
 // testDeadliner is a mock deadliner implementation.
 type testDeadliner struct {
 	mu    sync.Mutex
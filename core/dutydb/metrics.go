@@ -0,0 +1,335 @@
+// Copyright © 2022-2025 Obol Labs Inc. Licensed under the terms of a Business Source License 1.1
+
+package dutydb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/obolnetwork/charon/app/errors"
+	"github.com/obolnetwork/charon/app/log"
+	"github.com/obolnetwork/charon/app/promauto"
+	"github.com/obolnetwork/charon/app/z"
+	"github.com/obolnetwork/charon/core"
+)
+
+// defaultQueueWarnThreshold is the default number of pending queries per queue triggering a warning.
+const defaultQueueWarnThreshold = 1000
+
+// Query queue types used as metric labels.
+const (
+	queueProposer              = "proposer"
+	queueAttester              = "attester"
+	queueAttesterMulti         = "attester_multi"
+	queueAggregator            = "aggregator"
+	queueAggregatorSlot        = "aggregator_slot"
+	queueSyncContribution      = "sync_contribution"
+	queueSyncContributionMulti = "sync_contribution_multi"
+	queueAggregatorSelection   = "aggregator_selection"
+	queueAnyDuty               = "any_duty"
+)
+
+// Await outcomes used as metric labels.
+const (
+	awaitImmediate       = "immediate"
+	awaitBlockedResolved = "blocked_resolved"
+	awaitTimeout         = "timeout"
+	awaitCancelled       = "cancelled"
+	awaitShutdown        = "shutdown"
+)
+
+// Aggregate and proposal overwrite results used as metric labels.
+const (
+	overwriteReplaced = "replaced"
+	overwriteKept     = "kept"
+)
+
+// Clash types used as metric labels.
+const (
+	clashAggregate    = "aggregate"
+	clashContribution = "contribution"
+	clashSelection    = "selection"
+)
+
+// Query response anomalies used as metric labels, see respond.
+const (
+	anomalyFull   = "full"
+	anomalyClosed = "closed"
+)
+
+// Attestation clash kinds used as metric labels, see attShard.storeAttestationUnsafe.
+const (
+	attClashCommittee = "committee"
+	attClashAlias     = "committee_0_alias"
+)
+
+var awaitCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "core",
+	Subsystem: "dutydb",
+	Name:      "await_total",
+	Help:      "Total number of await calls by query type and outcome, immediate if the data was already stored",
+}, []string{"type", "outcome"})
+
+var aggOverwriteCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "core",
+	Subsystem: "dutydb",
+	Name:      "aggregate_overwrite_total",
+	Help:      "Total number of aggregated attestations stored with different aggregation bits for an existing data root by result, replaced if the provided aggregate has at least as many bits, otherwise kept",
+}, []string{"result"})
+
+var proposalOverwriteCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "core",
+	Subsystem: "dutydb",
+	Name:      "proposal_overwrite_total",
+	Help:      "Total number of different proposals stored for a slot with a stored proposal by result if value aware proposals are enabled, replaced if the provided proposal has a higher value, otherwise kept",
+}, []string{"result"})
+
+var clashCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "core",
+	Subsystem: "dutydb",
+	Name:      "clash_total",
+	Help:      "Total number of rejected stores of data clashing with different data already stored for the same key by type, indicating a misbehaving beacon node",
+}, []string{"type"})
+
+var attClashCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "core",
+	Subsystem: "dutydb",
+	Name:      "attestation_clash_total",
+	Help:      "Total number of rejected attestation data stores clashing with stored data by kind, committee for the duty's committee index or committee_0_alias for the committee index 0 compatibility alias",
+}, []string{"kind"})
+
+var responseAnomalyCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "core",
+	Subsystem: "dutydb",
+	Name:      "response_anomaly_total",
+	Help:      "Total number of dropped query responses by anomaly, full or closed response channel, indicating a bug",
+}, []string{"anomaly"})
+
+var queueHighWaterGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "core",
+	Subsystem: "dutydb",
+	Name:      "query_queue_high_water",
+	Help:      "The maximum observed number of pending queries by type since startup",
+}, []string{"type"})
+
+var evictionLagHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "core",
+	Subsystem: "dutydb",
+	Name:      "eviction_lag_seconds",
+	Help:      "Delay in seconds between the end of an expired duty's slot and its deletion by duty type. Large values indicate that expired duties are not deleted promptly",
+	Buckets:   []float64{1, 6, 12, 24, 36, 48, 60, 120, 300},
+}, []string{"type"})
+
+var blockedAwaitsGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "core",
+	Subsystem: "dutydb",
+	Name:      "blocked_awaits",
+	Help:      "The number of await calls currently blocked waiting for data by query type",
+}, []string{"type"})
+
+var retainedSlotsGauge = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: "core",
+	Subsystem: "dutydb",
+	Name:      "retained_slots",
+	Help:      "The number of distinct slots with duties stored across all duty types, sampled on each store and deletion",
+})
+
+var storedGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "core",
+	Subsystem: "dutydb",
+	Name:      "stored",
+	Help:      "The number of entries currently stored by duty type",
+}, []string{"type"})
+
+var deadlinerHorizonGauge = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: "core",
+	Subsystem: "dutydb",
+	Name:      "deadliner_horizon_seconds",
+	Help:      "The horizon of the deadliner in seconds, i.e. how long after the start of their slot duties are retained. Not set if the deadliner doesn't expose its horizon",
+})
+
+var produceToAwaitHistogram = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "core",
+	Subsystem: "dutydb",
+	Name:      "produce_to_await_seconds",
+	Help:      "Signed delay in seconds between a duty's first store and its first resolved await by duty type. Negative values indicate that the await arrived first and waited for the duty to be produced",
+	Buckets:   []float64{-12, -8, -4, -2, -1, -0.5, -0.1, 0, 0.1, 0.5, 1, 2, 4, 8, 12},
+}, []string{"type"})
+
+var streamDroppedCounter = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: "core",
+	Subsystem: "dutydb",
+	Name:      "stream_dropped_total",
+	Help:      "Total number of stored duties dropped from store streams due to lagging consumers",
+})
+
+// queueMonitor tracks the high-water marks of the pending query queues
+// and warns when a queue crosses the soft threshold. Attester queues are per shard,
+// so the lengths of a type's queues are aggregated across shards.
+type queueMonitor struct {
+	threshold int // Zero disables warnings.
+
+	mu        sync.Mutex
+	lengths   map[queueKey]int // Last recorded length by queue.
+	totals    map[string]int   // Last recorded total length by type.
+	highWater map[string]int
+}
+
+// queueKey identifies a pending query queue, the shard is zero for queues that aren't sharded.
+type queueKey struct {
+	Type  string
+	Shard int
+}
+
+// observe records the length of the queue after enqueuing a query, warning if the total length
+// of the type's queues crossed the threshold. It is called in the enqueue path while holding the queue's lock.
+func (m *queueMonitor) observe(ctx context.Context, typ string, shard, n int) {
+	prev, total := m.update(typ, shard, n)
+
+	if m.threshold > 0 && prev < m.threshold && total >= m.threshold {
+		log.Warn(ctx, "Dutydb query queue exceeds threshold, producer may be lagging", nil,
+			z.Str("type", typ), z.Int("pending", total), z.Int("threshold", m.threshold))
+	}
+}
+
+// update records the length of the queue and returns the previous and current total lengths of the type's
+// queues, updating the high-water mark. It is also called after sharded queries are resolved, so the lengths
+// of drained shards don't inflate the total.
+func (m *queueMonitor) update(typ string, shard, n int) (prev, total int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lengths[queueKey{Type: typ, Shard: shard}] = n
+
+	for key, length := range m.lengths {
+		if key.Type == typ {
+			total += length
+		}
+	}
+
+	prev = m.totals[typ]
+	m.totals[typ] = total
+
+	if total > m.highWater[typ] {
+		m.highWater[typ] = total
+		queueHighWaterGauge.WithLabelValues(typ).Set(float64(total))
+	}
+
+	return prev, total
+}
+
+// produceMonitor tracks when duties are first stored and when their first resolved await arrived,
+// observing the delta between the two once both are known. Stores and awaits are both keyed by duty,
+// the duty stored and the duty of the awaited slot. Entries that are never matched, e.g. awaits resolved
+// by data stored for another duty's slot, are deleted once a later duty of the type expires.
+type produceMonitor struct {
+	mu     sync.Mutex
+	duties map[core.Duty]produceTimes
+}
+
+// produceTimes are the first store and first resolved await arrival times of a duty, zero if not known yet.
+type produceTimes struct {
+	Stored   time.Time
+	Awaited  time.Time
+	Observed bool
+}
+
+// stored records the time the duty was stored if it is the first store of the duty.
+func (m *produceMonitor) stored(duty core.Duty, at time.Time) {
+	m.update(duty, func(times *produceTimes) {
+		if times.Stored.IsZero() {
+			times.Stored = at
+		}
+	})
+}
+
+// awaited records the arrival time of a resolved await of the duty if it is the first.
+// Since a resolving store may record its time after the await resolved, the delta is observed
+// by whichever of stored and awaited completes the pair.
+func (m *produceMonitor) awaited(duty core.Duty, arrival time.Time) {
+	m.update(duty, func(times *produceTimes) {
+		if times.Awaited.IsZero() {
+			times.Awaited = arrival
+		}
+	})
+}
+
+// update applies the function to the times of the duty and observes the delta if both are known.
+func (m *produceMonitor) update(duty core.Duty, fn func(*produceTimes)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	times := m.duties[duty]
+	if times.Observed {
+		return
+	}
+
+	fn(&times)
+
+	if !times.Stored.IsZero() && !times.Awaited.IsZero() {
+		produceToAwaitHistogram.WithLabelValues(duty.Type.String()).Observe(times.Awaited.Sub(times.Stored).Seconds())
+		times.Observed = true
+	}
+
+	m.duties[duty] = times
+}
+
+// delete deletes the times of the duty.
+func (m *produceMonitor) delete(duty core.Duty) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.duties, duty)
+}
+
+// deleteExpired deletes the times of the expired duty and of all earlier duties of its type.
+func (m *produceMonitor) deleteExpired(expired core.Duty) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for duty := range m.duties {
+		if duty.Type == expired.Type && duty.Slot <= expired.Slot {
+			delete(m.duties, duty)
+		}
+	}
+}
+
+// observeAwait increments the await counter with the outcome of an await call. Immediate is true if
+// the query was resolved when enqueued, err is the error returned by the await call.
+func observeAwait(typ string, immediate bool, err error) {
+	awaitCounter.WithLabelValues(typ, awaitOutcome(immediate, err)).Inc()
+}
+
+// parkAwait increments the blocked awaits gauge of the query type and returns a function decrementing it.
+// It is deferred before an await call blocks, so the gauge is decremented on all return paths.
+// Awaits resolved immediately don't block, so they aren't counted.
+func parkAwait(typ string, immediate bool) func() {
+	if immediate {
+		return func() {}
+	}
+
+	gauge := blockedAwaitsGauge.WithLabelValues(typ)
+	gauge.Inc()
+
+	return gauge.Dec
+}
+
+// awaitOutcome returns the outcome label of an await call.
+func awaitOutcome(immediate bool, err error) string {
+	switch {
+	case errors.Is(err, ErrShutdown):
+		return awaitShutdown
+	case errors.Is(err, ErrQueryCancelled), errors.Is(err, context.Canceled):
+		return awaitCancelled
+	case errors.Is(err, ErrAwaitTimeout), errors.Is(err, context.DeadlineExceeded):
+		return awaitTimeout
+	case immediate:
+		return awaitImmediate
+	default:
+		// Errors after resolving, e.g. ambiguous results, are also considered resolved.
+		return awaitBlockedResolved
+	}
+}
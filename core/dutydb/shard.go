@@ -0,0 +1,305 @@
+// Copyright © 2022-2025 Obol Labs Inc. Licensed under the terms of a Business Source License 1.1
+
+package dutydb
+
+import (
+	"context"
+	"sync"
+
+	eth2p0 "github.com/attestantio/go-eth2-client/spec/phase0"
+
+	"github.com/obolnetwork/charon/app/errors"
+	"github.com/obolnetwork/charon/app/z"
+	"github.com/obolnetwork/charon/core"
+)
+
+// defaultAttShards is the default number of attester shards.
+const defaultAttShards = 8
+
+// attShard holds the attester duties and queries of the slots mapping to it, see MemDB.attShard.
+// Sharding by slot allows attester stores and queries for different slots to proceed concurrently.
+type attShard struct {
+	mu     sync.Mutex
+	idx    int
+	stored *storedCounts // Shared by all shards, see MemDB.Stats.
+	queues *queueMonitor // Shared by all shards.
+
+	attDuties       map[attKey]*eth2p0.AttestationData
+	attAliases      map[attKey]bool // Keys of attDuties only stored as the committee index 0 alias.
+	attPubKeys      map[pkKey]*core.PubKey
+	attKeysBySlot   map[uint64][]pkKey
+	attExpected     map[uint64][]uint64 // Expected committee indexes by slot, see MemDB.SetExpectedCommittees.
+	attQueries      []attQuery
+	attMultiQueries []attMultiQuery
+}
+
+// newAttShards returns n empty attester shards sharing the stored counts and queue monitor.
+func newAttShards(n int, stored *storedCounts, queues *queueMonitor) []*attShard {
+	if n <= 0 {
+		n = defaultAttShards
+	}
+
+	shards := make([]*attShard, 0, n)
+	for i := range n {
+		shards = append(shards, &attShard{
+			idx:           i,
+			stored:        stored,
+			queues:        queues,
+			attDuties:     make(map[attKey]*eth2p0.AttestationData),
+			attAliases:    make(map[attKey]bool),
+			attPubKeys:    make(map[pkKey]*core.PubKey),
+			attKeysBySlot: make(map[uint64][]pkKey),
+			attExpected:   make(map[uint64][]uint64),
+		})
+	}
+
+	return shards
+}
+
+// storeAttestations stores the unsigned attestations and resolves any pending queries.
+func (s *attShard) storeAttestations(ctx context.Context, unsignedSet core.UnsignedDataSet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for pubkey, unsignedData := range unsignedSet {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := s.storeAttestationUnsafe(pubkey, unsignedData)
+		if err != nil {
+			return err
+		}
+	}
+	s.resolveAttQueriesUnsafe()
+	s.resolveAttMultiQueriesUnsafe()
+
+	return nil
+}
+
+// storeAttestationUnsafe stores the unsigned attestation. It is unsafe since it assumes the shard lock is held.
+func (s *attShard) storeAttestationUnsafe(pubkey core.PubKey, unsignedData core.UnsignedData) error {
+	if provided, ok := unsignedData.(core.AttestationData); ok && s.storedUnsafe(pubkey, provided) {
+		return nil // Fast path for identical re-stores, e.g. VC retries.
+	}
+
+	cloned, err := unsignedData.Clone() // Clone before storing.
+	if err != nil {
+		return err
+	}
+
+	attData, ok := cloned.(core.AttestationData)
+	if !ok {
+		return errors.New("invalid unsigned attestation data")
+	}
+
+	pubkeyStore := &pubkey
+
+	// Store key and value for PubKeyByAttestation
+	pKey := pkKey{
+		Slot:    uint64(attData.Data.Slot),
+		CommIdx: uint64(attData.Duty.CommitteeIndex),
+		ValIdx:  uint64(attData.Duty.ValidatorIndex),
+	}
+
+	if value, ok := s.attPubKeys[pKey]; ok {
+		if *value != *pubkeyStore {
+			return errors.New("clashing public key", z.Any("pKey", pKey))
+		}
+	} else {
+		s.attPubKeys[pKey] = pubkeyStore
+		s.attKeysBySlot[uint64(attData.Duty.Slot)] = append(s.attKeysBySlot[uint64(attData.Duty.Slot)], pKey)
+	}
+
+	// Store key and value for AwaitAttestation
+	aKey := attKey{
+		Slot:    uint64(attData.Data.Slot),
+		CommIdx: uint64(attData.Duty.CommitteeIndex),
+	}
+
+	if value, ok := s.attDuties[aKey]; ok {
+		if value.String() != attData.Data.String() {
+			// Committee index 0 may only be stored as the alias of another committee's data.
+			if s.attAliases[aKey] {
+				attClashCounter.WithLabelValues(attClashAlias).Inc()
+				return errors.New("clashing attestation data for committee index 0 alias", z.Any("key", aKey))
+			}
+
+			attClashCounter.WithLabelValues(attClashCommittee).Inc()
+			return errors.New("clashing attestation data", z.Any("key", aKey))
+		}
+	} else {
+		s.attDuties[aKey] = &attData.Data
+		s.stored.add(core.DutyAttester, 1)
+	}
+	delete(s.attAliases, aKey) // Stored for the actual committee index, even if previously stored as an alias.
+
+	// TODO(kalo):
+	// Committee index 0 should be the default behaviour post-electra.
+	// However, some VCs are still requesting for attestation data with a committee index.
+	// Because of that on Charon side we are also saving attestation data with a committee index.
+	// VCs that work correctly and ask for the hardcoded committee index of 0 need the logic below in order to function properly.
+	// Once all VCs work correctly and ask for index 0, we can remove the logic below, as we will always receive committee index 0
+	// and write it as such from the logic on top.
+	// https://ethereum.github.io/beacon-APIs/#/Validator/produceAttestationData
+
+	// Store key and value for PubKeyByAttestation
+	pKeyCommIdx0 := pkKey{
+		Slot:    uint64(attData.Data.Slot),
+		CommIdx: 0,
+		ValIdx:  uint64(attData.Duty.ValidatorIndex),
+	}
+
+	if value, ok := s.attPubKeys[pKeyCommIdx0]; ok {
+		if *value != *pubkeyStore {
+			return errors.New("clashing public key", z.Any("pKey", pKeyCommIdx0))
+		}
+	} else {
+		s.attPubKeys[pKeyCommIdx0] = pubkeyStore
+		s.attKeysBySlot[uint64(attData.Duty.Slot)] = append(s.attKeysBySlot[uint64(attData.Duty.Slot)], pKeyCommIdx0)
+	}
+
+	// Store key and value for AwaitAttestation
+	aKeyCommIdx0 := attKey{
+		Slot:    uint64(attData.Data.Slot),
+		CommIdx: 0,
+	}
+
+	if value, ok := s.attDuties[aKeyCommIdx0]; ok {
+		if value.String() != attData.Data.String() {
+			attClashCounter.WithLabelValues(attClashAlias).Inc()
+			return errors.New("clashing attestation data for committee index 0 alias", z.Any("key", aKeyCommIdx0))
+		}
+	} else {
+		s.attDuties[aKeyCommIdx0] = &attData.Data
+		s.attAliases[aKeyCommIdx0] = true
+		s.stored.add(core.DutyAttester, 1)
+	}
+
+	return nil
+}
+
+// storedUnsafe returns true if the attestation is already stored identically, including its committee index 0 alias.
+// It is unsafe since it assumes the shard lock is held.
+func (s *attShard) storedUnsafe(pubkey core.PubKey, attData core.AttestationData) bool {
+	slot, valIdx := uint64(attData.Data.Slot), uint64(attData.Duty.ValidatorIndex)
+
+	for _, commIdx := range []uint64{uint64(attData.Duty.CommitteeIndex), 0} {
+		stored, ok := s.attPubKeys[pkKey{Slot: slot, CommIdx: commIdx, ValIdx: valIdx}]
+		if !ok || *stored != pubkey {
+			return false
+		}
+
+		data, ok := s.attDuties[attKey{Slot: slot, CommIdx: commIdx}]
+		if !ok || !equalAttData(data, &attData.Data) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// equalAttData returns true if the attestation data are equal. It is cheaper than comparing their strings.
+func equalAttData(a, b *eth2p0.AttestationData) bool {
+	equalCheckpoint := func(a, b *eth2p0.Checkpoint) bool {
+		if a == nil || b == nil {
+			return a == b
+		}
+
+		return *a == *b
+	}
+
+	return a.Slot == b.Slot &&
+		a.Index == b.Index &&
+		a.BeaconBlockRoot == b.BeaconBlockRoot &&
+		equalCheckpoint(a.Source, b.Source) &&
+		equalCheckpoint(a.Target, b.Target)
+}
+
+// resolveAttQueriesUnsafe resolve any attQuery to a result if found.
+// It is unsafe since it assumes that the shard lock is held.
+func (s *attShard) resolveAttQueriesUnsafe() {
+	var unresolved []attQuery
+	for _, query := range s.attQueries {
+		if cancelled(query.Cancel) {
+			continue // Drop cancelled queries.
+		}
+
+		value, ok := s.attDuties[query.Key]
+		if !ok || (query.Strict && s.attAliases[query.Key]) {
+			unresolved = append(unresolved, query)
+			continue
+		}
+
+		respond(query.Response, value)
+	}
+
+	s.attQueries = unresolved
+	s.queues.update(queueAttester, s.idx, len(s.attQueries))
+}
+
+// resolveAttMultiQueriesUnsafe resolves any attMultiQuery to a result if all its committees are found.
+// It is unsafe since it assumes that the shard lock is held.
+func (s *attShard) resolveAttMultiQueriesUnsafe() {
+	var unresolved []attMultiQuery
+	for _, query := range s.attMultiQueries {
+		if cancelled(query.Cancel) {
+			continue // Drop cancelled queries.
+		}
+
+		commIdxs := query.CommIdxs
+		if query.Expected {
+			var ok bool
+			if commIdxs, ok = s.attExpected[query.Slot]; !ok {
+				unresolved = append(unresolved, query)
+				continue
+			}
+		}
+
+		values, ok := s.attestationsUnsafe(query.Slot, commIdxs)
+		if !ok {
+			unresolved = append(unresolved, query)
+			continue
+		}
+
+		respond(query.Response, values)
+	}
+
+	s.attMultiQueries = unresolved
+	s.queues.update(queueAttesterMulti, s.idx, len(s.attMultiQueries))
+}
+
+// attestationsUnsafe returns the attestation data available for the slot and committee indexes
+// and true if all are available. It is unsafe since it assumes that the shard lock is held.
+func (s *attShard) attestationsUnsafe(slot uint64, commIdxs []uint64) (map[uint64]*eth2p0.AttestationData, bool) {
+	values := make(map[uint64]*eth2p0.AttestationData)
+	complete := true
+	for _, commIdx := range commIdxs {
+		value, ok := s.attDuties[attKey{Slot: slot, CommIdx: commIdx}]
+		if !ok {
+			complete = false
+			continue
+		}
+		values[commIdx] = value
+	}
+
+	return values, complete
+}
+
+// deleteSlotUnsafe deletes all attester duties of the slot. It is unsafe since it assumes that the shard lock is held.
+func (s *attShard) deleteSlotUnsafe(slot uint64) {
+	var deleted int
+	for _, key := range s.attKeysBySlot[slot] {
+		aKey := attKey{Slot: key.Slot, CommIdx: key.CommIdx}
+		if _, ok := s.attDuties[aKey]; ok { // Multiple validators share the same attestation data.
+			deleted++
+		}
+
+		delete(s.attPubKeys, key)
+		delete(s.attDuties, aKey)
+		delete(s.attAliases, aKey)
+	}
+	delete(s.attKeysBySlot, slot)
+	delete(s.attExpected, slot)
+	s.stored.add(core.DutyAttester, -deleted)
+}
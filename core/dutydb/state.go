@@ -0,0 +1,376 @@
+// Copyright © 2022-2025 Obol Labs Inc. Licensed under the terms of a Business Source License 1.1
+
+package dutydb
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math/big"
+	"slices"
+	"sort"
+
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	eth2p0 "github.com/attestantio/go-eth2-client/spec/phase0"
+
+	"github.com/obolnetwork/charon/app/errors"
+	"github.com/obolnetwork/charon/app/z"
+	"github.com/obolnetwork/charon/core"
+)
+
+// stateVersion is the version of the serialised state format, it must be incremented on breaking changes.
+const stateVersion uint16 = 1
+
+// stateMagic prefixes serialised state to identify it.
+var stateMagic = [4]byte{'d', 'u', 't', 'y'}
+
+// stateV1 is version 1 of the serialised MemDB state.
+// Fields added later are optional, so state written before they were added remains importable.
+type stateV1 struct {
+	Proposals      []core.VersionedProposal              `json:"proposals"`
+	Attestations   []attestationState                    `json:"attestations"`
+	PubKeys        []pubKeyState                         `json:"pubkeys"`
+	Aggregates     []core.VersionedAggregatedAttestation `json:"aggregates"`
+	Contributions  []*altair.SyncCommitteeContribution   `json:"contributions"`
+	Selections     []core.AggregatorSelection            `json:"selections,omitempty"`
+	ProposalValues []proposalValueState                  `json:"proposal_values,omitempty"`
+}
+
+// proposalValueState is a serialised proValues entry.
+type proposalValueState struct {
+	Slot  uint64   `json:"slot"`
+	Value *big.Int `json:"value"`
+}
+
+// attestationState is a serialised attDuties entry. Alias is true if the entry is only stored as the
+// committee index 0 alias of attestation data stored for another committee.
+type attestationState struct {
+	Slot    uint64                  `json:"slot"`
+	CommIdx uint64                  `json:"committee_index"`
+	Alias   bool                    `json:"alias,omitempty"`
+	Data    *eth2p0.AttestationData `json:"data"`
+}
+
+// pubKeyState is a serialised attPubKeys entry.
+type pubKeyState struct {
+	Slot    uint64      `json:"slot"`
+	CommIdx uint64      `json:"committee_index"`
+	ValIdx  uint64      `json:"validator_index"`
+	PubKey  core.PubKey `json:"pubkey"`
+}
+
+// MarshalState writes all stored duties to w in a versioned format that can be imported
+// into another MemDB via UnmarshalState. Pending queries are not included.
+// Entries are sorted by key, so the state of DBs storing the same duties is identical irrespective of store order.
+func (db *MemDB) MarshalState(w io.Writer) error {
+	db.mu.Lock()
+	unlock := db.lockAttShards()
+	state := db.stateUnsafe()
+	unlock()
+	db.mu.Unlock()
+
+	b, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrap(err, "marshal dutydb state")
+	}
+
+	var header [6]byte
+	copy(header[:], stateMagic[:])
+	binary.BigEndian.PutUint16(header[4:], stateVersion)
+
+	if _, err := w.Write(header[:]); err != nil {
+		return errors.Wrap(err, "write dutydb state header")
+	}
+
+	if _, err := w.Write(b); err != nil {
+		return errors.Wrap(err, "write dutydb state")
+	}
+
+	return nil
+}
+
+// UnmarshalState replaces all stored duties with the state read from r as written by MarshalState.
+// Imported duties are added to the deadliner and expired duties are dropped. Pending queries
+// are resolved against the imported state.
+func (db *MemDB) UnmarshalState(r io.Reader) error {
+	var header [6]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return errors.Wrap(err, "read dutydb state header")
+	}
+
+	if [4]byte(header[:4]) != stateMagic {
+		return errors.New("invalid dutydb state")
+	}
+
+	if version := binary.BigEndian.Uint16(header[4:]); version != stateVersion {
+		return errors.New("unsupported dutydb state version",
+			z.U64("version", uint64(version)), z.U64("supported", uint64(stateVersion)))
+	}
+
+	var state stateV1
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return errors.Wrap(err, "unmarshal dutydb state")
+	}
+
+	// Build the new maps before locking so that invalid state doesn't result in partial imports.
+	imported := NewMemDB(db.deadliner, WithAttesterShards(len(db.attShards)))
+
+	for _, proposal := range state.Proposals {
+		slot, err := proposal.Slot()
+		if err != nil {
+			return err
+		}
+
+		root, err := proposal.Root()
+		if err != nil {
+			return errors.Wrap(err, "proposal root")
+		}
+
+		imported.proDuties[uint64(slot)] = &proposal.VersionedProposal
+		imported.proRoots[uint64(slot)] = root
+	}
+
+	for _, att := range state.Attestations {
+		key := attKey{Slot: att.Slot, CommIdx: att.CommIdx}
+		shard := imported.attShard(att.Slot)
+		shard.attDuties[key] = att.Data
+		if att.Alias {
+			shard.attAliases[key] = true
+		}
+	}
+
+	for _, pk := range state.PubKeys {
+		key := pkKey{Slot: pk.Slot, CommIdx: pk.CommIdx, ValIdx: pk.ValIdx}
+		shard := imported.attShard(key.Slot)
+		shard.attPubKeys[key] = &pk.PubKey
+		shard.attKeysBySlot[key.Slot] = append(shard.attKeysBySlot[key.Slot], key)
+	}
+
+	for _, agg := range state.Aggregates {
+		data, err := agg.Data()
+		if err != nil {
+			return err
+		}
+
+		root, err := data.HashTreeRoot()
+		if err != nil {
+			return errors.Wrap(err, "hash aggregated attestation root")
+		}
+
+		key := aggKey{Slot: uint64(data.Slot), Root: root}
+		imported.aggDuties[key] = agg
+		imported.aggKeysBySlot[key.Slot] = append(imported.aggKeysBySlot[key.Slot], key)
+		imported.indexAggCommitteeUnsafe(key, agg)
+	}
+
+	for _, contrib := range state.Contributions {
+		key := contribKey{Slot: uint64(contrib.Slot), SubcommIdx: contrib.SubcommitteeIndex, Root: contrib.BeaconBlockRoot}
+		imported.contribDuties[key] = contrib
+		imported.contribKeysBySlot[key.Slot] = append(imported.contribKeysBySlot[key.Slot], key)
+	}
+
+	for _, sel := range state.Selections {
+		key := selKey{Slot: uint64(sel.Selection.Slot), CommIdx: uint64(sel.CommitteeIndex), ValIdx: uint64(sel.Selection.ValidatorIndex)}
+		imported.selDuties[key] = sel
+		imported.selKeysBySlot[key.Slot] = append(imported.selKeysBySlot[key.Slot], key)
+	}
+
+	for _, value := range state.ProposalValues {
+		if _, ok := imported.proDuties[value.Slot]; !ok || value.Value == nil {
+			return errors.New("invalid proposal value", z.U64("slot", value.Slot))
+		}
+
+		imported.proValues[value.Slot] = value.Value
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.stored.add(core.DutyProposer, len(imported.proDuties)-len(db.proDuties))
+	db.stored.add(core.DutyAggregator, len(imported.aggDuties)-len(db.aggDuties))
+	db.stored.add(core.DutySyncContribution, len(imported.contribDuties)-len(db.contribDuties))
+	db.stored.add(core.DutyPrepareAggregator, len(imported.selDuties)-len(db.selDuties))
+
+	db.proDuties = imported.proDuties
+	db.proRoots = imported.proRoots
+	db.proValues = imported.proValues
+	db.aggDuties = imported.aggDuties
+	db.aggKeysBySlot = imported.aggKeysBySlot
+	db.aggKeysByCommittee = imported.aggKeysByCommittee
+	db.contribDuties = imported.contribDuties
+	db.contribKeysBySlot = imported.contribKeysBySlot
+	db.selDuties = imported.selDuties
+	db.selKeysBySlot = imported.selKeysBySlot
+	db.preloadedExpired = imported.preloadedExpired // Imported duties are added to the deadliner below.
+
+	// Shards are updated in place since blocked queries reference them.
+	for i, shard := range db.attShards {
+		shard.mu.Lock()
+		db.stored.add(core.DutyAttester, len(imported.attShards[i].attDuties)-len(shard.attDuties))
+		shard.attDuties = imported.attShards[i].attDuties
+		shard.attAliases = imported.attShards[i].attAliases
+		shard.attPubKeys = imported.attShards[i].attPubKeys
+		shard.attKeysBySlot = imported.attShards[i].attKeysBySlot
+		shard.mu.Unlock()
+	}
+
+	for _, duty := range db.dutiesUnsafe() {
+		if db.deadliner.Add(duty) {
+			continue
+		}
+
+		if err := db.deleteDutyUnsafe(duty); err != nil {
+			return err
+		}
+	}
+
+	for _, shard := range db.attShards {
+		shard.mu.Lock()
+		shard.resolveAttQueriesUnsafe()
+		shard.resolveAttMultiQueriesUnsafe()
+		shard.mu.Unlock()
+	}
+
+	db.resolveProQueriesUnsafe()
+	db.resolveAggQueriesUnsafe()
+	db.resolveAggSlotQueriesUnsafe()
+	db.resolveContribQueriesUnsafe()
+	db.resolveContribMultiQueriesUnsafe()
+	db.resolveSelQueriesUnsafe()
+
+	return nil
+}
+
+// stateUnsafe returns the serialisable state of the DB sorted by key.
+// It is unsafe since it assumes the lock and all shard locks are held.
+func (db *MemDB) stateUnsafe() stateV1 {
+	var state stateV1
+
+	for _, slot := range sortedKeys(db.proDuties) {
+		state.Proposals = append(state.Proposals, core.VersionedProposal{VersionedProposal: *db.proDuties[slot]})
+		if value, ok := db.proValues[slot]; ok {
+			state.ProposalValues = append(state.ProposalValues, proposalValueState{Slot: slot, Value: value})
+		}
+	}
+
+	for _, shard := range db.attShards {
+		for key, data := range shard.attDuties {
+			state.Attestations = append(state.Attestations, attestationState{
+				Slot:    key.Slot,
+				CommIdx: key.CommIdx,
+				Alias:   shard.attAliases[key],
+				Data:    data,
+			})
+		}
+	}
+	sort.Slice(state.Attestations, func(i, j int) bool {
+		a, b := state.Attestations[i], state.Attestations[j]
+		if a.Slot != b.Slot {
+			return a.Slot < b.Slot
+		}
+
+		return a.CommIdx < b.CommIdx
+	})
+
+	for _, shard := range db.attShards {
+		for key, pubkey := range shard.attPubKeys {
+			state.PubKeys = append(state.PubKeys, pubKeyState{Slot: key.Slot, CommIdx: key.CommIdx, ValIdx: key.ValIdx, PubKey: *pubkey})
+		}
+	}
+	sort.Slice(state.PubKeys, func(i, j int) bool {
+		a, b := state.PubKeys[i], state.PubKeys[j]
+		if a.Slot != b.Slot {
+			return a.Slot < b.Slot
+		}
+		if a.CommIdx != b.CommIdx {
+			return a.CommIdx < b.CommIdx
+		}
+
+		return a.ValIdx < b.ValIdx
+	})
+
+	// Keys by slot are in store order, so they are sorted by root.
+	for _, slot := range sortedKeys(db.aggKeysBySlot) {
+		keys := slices.Clone(db.aggKeysBySlot[slot])
+		slices.SortFunc(keys, func(a, b aggKey) int {
+			return bytes.Compare(a.Root[:], b.Root[:])
+		})
+		for _, key := range keys {
+			state.Aggregates = append(state.Aggregates, db.aggDuties[key])
+		}
+	}
+
+	for _, slot := range sortedKeys(db.contribKeysBySlot) {
+		keys := slices.Clone(db.contribKeysBySlot[slot])
+		slices.SortFunc(keys, func(a, b contribKey) int {
+			if c := cmp.Compare(a.SubcommIdx, b.SubcommIdx); c != 0 {
+				return c
+			}
+
+			return bytes.Compare(a.Root[:], b.Root[:])
+		})
+		for _, key := range keys {
+			state.Contributions = append(state.Contributions, db.contribDuties[key])
+		}
+	}
+
+	for _, slot := range sortedKeys(db.selKeysBySlot) {
+		keys := slices.Clone(db.selKeysBySlot[slot])
+		slices.SortFunc(keys, func(a, b selKey) int {
+			if c := cmp.Compare(a.CommIdx, b.CommIdx); c != 0 {
+				return c
+			}
+
+			return cmp.Compare(a.ValIdx, b.ValIdx)
+		})
+		for _, key := range keys {
+			state.Selections = append(state.Selections, db.selDuties[key])
+		}
+	}
+
+	return state
+}
+
+// dutiesUnsafe returns all duties with data stored in the DB. It is unsafe since it assumes the lock is held,
+// shard locks are acquired as required.
+func (db *MemDB) dutiesUnsafe() []core.Duty {
+	var duties []core.Duty
+	for _, slot := range sortedKeys(db.proDuties) {
+		duties = append(duties, core.NewProposerDuty(slot))
+	}
+	attSlots := make(map[uint64]struct{})
+	for _, shard := range db.attShards {
+		shard.mu.Lock()
+		for slot := range shard.attKeysBySlot {
+			attSlots[slot] = struct{}{}
+		}
+		shard.mu.Unlock()
+	}
+	for _, slot := range sortedKeys(attSlots) {
+		duties = append(duties, core.NewAttesterDuty(slot))
+	}
+	for _, slot := range sortedKeys(db.aggKeysBySlot) {
+		duties = append(duties, core.NewAggregatorDuty(slot))
+	}
+	for _, slot := range sortedKeys(db.contribKeysBySlot) {
+		duties = append(duties, core.NewSyncContributionDuty(slot))
+	}
+	for _, slot := range sortedKeys(db.selKeysBySlot) {
+		duties = append(duties, core.NewPrepareAggregatorDuty(slot))
+	}
+
+	return duties
+}
+
+// sortedKeys returns the slot keys of the map in ascending order.
+func sortedKeys[V any](m map[uint64]V) []uint64 {
+	keys := make([]uint64, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	return keys
+}
@@ -0,0 +1,227 @@
+// Copyright © 2022-2025 Obol Labs Inc. Licensed under the terms of a Business Source License 1.1
+
+package dutydb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math/rand"
+	"slices"
+	"testing"
+
+	eth2v1 "github.com/attestantio/go-eth2-client/api/v1"
+	eth2spec "github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	eth2p0 "github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/stretchr/testify/require"
+
+	"github.com/obolnetwork/charon/core"
+	"github.com/obolnetwork/charon/testutil"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	db := NewMemDB(noopDeadliner{})
+
+	const slot = 123
+
+	proposal := testutil.RandomCapellaCoreVersionedProposal()
+	proposal.Capella.Slot = slot
+	err := db.Store(ctx, core.NewProposerDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): proposal})
+	require.NoError(t, err)
+
+	attSet := make(core.UnsignedDataSet)
+	for commIdx := range uint64(3) {
+		attSet[testutil.RandomCorePubKey(t)] = core.AttestationData{
+			Data: eth2p0.AttestationData{
+				Slot:   slot,
+				Source: &eth2p0.Checkpoint{},
+				Target: &eth2p0.Checkpoint{},
+			},
+			Duty: eth2v1.AttesterDuty{
+				Slot:             slot,
+				CommitteeIndex:   eth2p0.CommitteeIndex(commIdx + 1),
+				CommitteeLength:  8,
+				CommitteesAtSlot: 8,
+				ValidatorIndex:   eth2p0.ValidatorIndex(commIdx),
+			},
+		}
+	}
+	err = db.Store(ctx, core.NewAttesterDuty(slot), attSet)
+	require.NoError(t, err)
+
+	agg := testutil.RandomDenebCoreVersionedAggregateAttestation()
+	agg.Deneb.Data.Slot = slot
+	err = db.Store(ctx, core.NewAggregatorDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): agg})
+	require.NoError(t, err)
+
+	contrib := testutil.RandomSyncCommitteeContribution()
+	contrib.Slot = slot
+	err = db.Store(ctx, core.NewSyncContributionDuty(slot), core.UnsignedDataSet{
+		testutil.RandomCorePubKey(t): core.NewSyncContribution(contrib),
+	})
+	require.NoError(t, err)
+
+	sel := testutil.RandomCoreAggregatorSelection()
+	sel.Selection.Slot = slot
+	err = db.Store(ctx, core.NewPrepareAggregatorDuty(slot), core.UnsignedDataSet{testutil.RandomCorePubKey(t): sel})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, db.MarshalState(&buf))
+
+	// Selections stored before the import are replaced like all other duties.
+	imported := NewMemDB(noopDeadliner{})
+	stale := testutil.RandomCoreAggregatorSelection()
+	stale.Selection.Slot = slot + 1
+	err = imported.Store(ctx, core.NewPrepareAggregatorDuty(slot+1), core.UnsignedDataSet{testutil.RandomCorePubKey(t): stale})
+	require.NoError(t, err)
+
+	require.NoError(t, imported.UnmarshalState(&buf))
+
+	require.Equal(t, db.proDuties, imported.proDuties)
+	require.Equal(t, db.proRoots, imported.proRoots)
+	for i, shard := range db.attShards {
+		require.Equal(t, shard.attDuties, imported.attShards[i].attDuties)
+		require.Equal(t, shard.attPubKeys, imported.attShards[i].attPubKeys)
+		require.Len(t, imported.attShards[i].attKeysBySlot, len(shard.attKeysBySlot))
+	}
+	require.Equal(t, db.aggDuties, imported.aggDuties)
+	require.Equal(t, db.aggKeysBySlot, imported.aggKeysBySlot)
+	require.Equal(t, db.contribDuties, imported.contribDuties)
+	require.Equal(t, db.contribKeysBySlot, imported.contribKeysBySlot)
+	require.Equal(t, db.selDuties, imported.selDuties)
+	require.Equal(t, db.selKeysBySlot, imported.selKeysBySlot)
+	require.Equal(t, db.Stats(), imported.Stats())
+	require.ElementsMatch(t, db.attShard(slot).attKeysBySlot[slot], imported.attShard(slot).attKeysBySlot[slot])
+
+	// Queries behave identically after import.
+	for commIdx := range uint64(4) {
+		expect, err := db.AwaitAttestation(ctx, slot, commIdx)
+		require.NoError(t, err)
+		actual, err := imported.AwaitAttestation(ctx, slot, commIdx)
+		require.NoError(t, err)
+		require.Equal(t, expect, actual)
+	}
+
+	actualProposal, err := imported.AwaitProposal(ctx, slot)
+	require.NoError(t, err)
+	require.Equal(t, proposal.Capella, actualProposal.Capella)
+
+	isAggregator, proof, err := imported.AwaitAggregatorSelection(ctx, slot, uint64(sel.CommitteeIndex), uint64(sel.Selection.ValidatorIndex))
+	require.NoError(t, err)
+	require.Equal(t, sel.IsAggregator, isAggregator)
+	require.Equal(t, sel.Selection.SelectionProof, proof)
+}
+
+func TestStateStable(t *testing.T) {
+	ctx := context.Background()
+	r := rand.New(rand.NewSource(0))
+
+	const slot = 123
+
+	type entry struct {
+		duty core.Duty
+		set  core.UnsignedDataSet
+	}
+	var entries []entry
+
+	// Attestation data of all committees is identical post-electra, so the committee index 0 alias doesn't depend on store order.
+	attData := testutil.RandomAttestationDataSeedElectra(r)
+	attData.Slot = slot
+	for commIdx := range uint64(3) {
+		entries = append(entries, entry{
+			duty: core.NewAttesterDuty(slot),
+			set: core.UnsignedDataSet{testutil.RandomCorePubKeySeed(t, r): core.AttestationData{
+				Data: *attData,
+				Duty: eth2v1.AttesterDuty{
+					Slot:             slot,
+					CommitteeIndex:   eth2p0.CommitteeIndex(commIdx + 1),
+					CommitteeLength:  8,
+					CommitteesAtSlot: 8,
+					ValidatorIndex:   eth2p0.ValidatorIndex(commIdx),
+				},
+			}},
+		})
+	}
+
+	for range 3 {
+		data := testutil.RandomAttestationDataSeedPhase0(r)
+		data.Slot = slot
+		bits := bitfield.NewBitlist(8)
+		bits.SetBitAt(r.Uint64()%8, true)
+		entries = append(entries, entry{
+			duty: core.NewAggregatorDuty(slot),
+			set: core.UnsignedDataSet{testutil.RandomCorePubKeySeed(t, r): core.VersionedAggregatedAttestation{
+				VersionedAttestation: eth2spec.VersionedAttestation{
+					Version: eth2spec.DataVersionDeneb,
+					Deneb: &eth2p0.Attestation{
+						AggregationBits: bits,
+						Data:            data,
+						Signature:       testutil.RandomEth2SignatureWithSeed(r.Int63()),
+					},
+				},
+			}},
+		})
+	}
+
+	for _, subcommIdx := range []uint64{1, 0, 1} {
+		entries = append(entries, entry{
+			duty: core.NewSyncContributionDuty(slot),
+			set: core.UnsignedDataSet{testutil.RandomCorePubKeySeed(t, r): core.NewSyncContribution(&altair.SyncCommitteeContribution{
+				Slot:              slot,
+				BeaconBlockRoot:   testutil.RandomRootSeed(r),
+				SubcommitteeIndex: subcommIdx,
+				AggregationBits:   bitfield.NewBitvector128(),
+				Signature:         testutil.RandomEth2SignatureWithSeed(r.Int63()),
+			})},
+		})
+	}
+
+	marshal := func(entries []entry) []byte {
+		db := NewMemDB(noopDeadliner{})
+		for _, e := range entries {
+			require.NoError(t, db.Store(ctx, e.duty, e.set))
+		}
+
+		var buf bytes.Buffer
+		require.NoError(t, db.MarshalState(&buf))
+
+		return buf.Bytes()
+	}
+
+	reversed := slices.Clone(entries)
+	slices.Reverse(reversed)
+
+	state := marshal(entries)
+	require.Equal(t, state, marshal(entries))
+	require.Equal(t, state, marshal(reversed))
+
+	// The header isn't included in the golden file since it isn't text.
+	testutil.RequireGoldenBytes(t, state[6:])
+
+	// The committee index 0 alias is preserved across imports.
+	imported := NewMemDB(noopDeadliner{})
+	require.NoError(t, imported.UnmarshalState(bytes.NewReader(state)))
+
+	var buf bytes.Buffer
+	require.NoError(t, imported.MarshalState(&buf))
+	require.Equal(t, state, buf.Bytes())
+	require.True(t, imported.attShard(slot).attAliases[attKey{Slot: slot, CommIdx: 0}])
+}
+
+func TestStateVersionMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, NewMemDB(noopDeadliner{}).MarshalState(&buf))
+
+	b := buf.Bytes()
+	binary.BigEndian.PutUint16(b[4:], stateVersion+1)
+
+	err := NewMemDB(noopDeadliner{}).UnmarshalState(bytes.NewReader(b))
+	require.ErrorContains(t, err, "unsupported dutydb state version")
+
+	err = NewMemDB(noopDeadliner{}).UnmarshalState(bytes.NewReader([]byte("invalid")))
+	require.ErrorContains(t, err, "invalid dutydb state")
+}
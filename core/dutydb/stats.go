@@ -0,0 +1,76 @@
+// Copyright © 2022-2025 Obol Labs Inc. Licensed under the terms of a Business Source License 1.1
+
+package dutydb
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/obolnetwork/charon/core"
+)
+
+// Stats is a snapshot of the number of entries stored by duty type.
+type Stats struct {
+	Proposals     int64 // Proposals by slot.
+	Attestations  int64 // Attestation data by slot and committee index, including committee index 0 aliases.
+	Aggregates    int64 // Aggregated attestations by slot and attestation data root.
+	Contributions int64 // Sync committee contributions by slot, subcommittee index and beacon block root.
+	Selections    int64 // Aggregator selections by slot, committee index and validator index.
+}
+
+// Stats returns the number of entries stored by duty type.
+// It doesn't take any locks, so it never contends with stores or queries.
+func (db *MemDB) Stats() Stats {
+	return Stats{
+		Proposals:     db.stored.proposals.Load(),
+		Attestations:  db.stored.attestations.Load(),
+		Aggregates:    db.stored.aggregates.Load(),
+		Contributions: db.stored.contributions.Load(),
+		Selections:    db.stored.selections.Load(),
+	}
+}
+
+// LastStore returns the time of the last successful store, excluding data loaded from a snapshot,
+// or false if nothing was stored yet. Like Stats, it doesn't take any locks.
+func (db *MemDB) LastStore() (time.Time, bool) {
+	nanos := db.lastStore.Load()
+	if nanos == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, nanos), true
+}
+
+// storedCounts holds the number of stored entries by duty type. The counts are updated
+// at the same points as the maps they count, so they can be read without holding any lock.
+type storedCounts struct {
+	proposals     atomic.Int64
+	attestations  atomic.Int64
+	aggregates    atomic.Int64
+	contributions atomic.Int64
+	selections    atomic.Int64
+}
+
+// add adds delta to the count of the duty type and to the stored gauge.
+func (c *storedCounts) add(typ core.DutyType, delta int) {
+	if delta == 0 {
+		return
+	}
+
+	switch typ {
+	case core.DutyProposer:
+		c.proposals.Add(int64(delta))
+	case core.DutyAttester:
+		c.attestations.Add(int64(delta))
+	case core.DutyAggregator:
+		c.aggregates.Add(int64(delta))
+	case core.DutySyncContribution:
+		c.contributions.Add(int64(delta))
+	case core.DutyPrepareAggregator:
+		c.selections.Add(int64(delta))
+	default:
+		return
+	}
+
+	storedGauge.WithLabelValues(typ.String()).Add(float64(delta))
+}
@@ -0,0 +1,100 @@
+// Copyright © 2022-2025 Obol Labs Inc. Licensed under the terms of a Business Source License 1.1
+
+package dutydb
+
+import (
+	"context"
+	"sync"
+
+	"github.com/obolnetwork/charon/app/log"
+	"github.com/obolnetwork/charon/app/z"
+	"github.com/obolnetwork/charon/core"
+)
+
+// streamBuffer is the number of stored duties buffered per stream before dropping.
+const streamBuffer = 256
+
+// StoredEvent is a copy of an unsigned data set stored in a MemDB, see StreamStores.
+type StoredEvent struct {
+	Duty        core.Duty
+	UnsignedSet core.UnsignedDataSet
+}
+
+// StreamStores returns a channel delivering a copy of each subsequently stored unsigned data set and a function
+// to stop the stream, which closes the channel. The stream is also stopped when the context is closed.
+// Sending never blocks Store, events are dropped and counted if the consumer lags, see ApplyStores.
+func (db *MemDB) StreamStores(ctx context.Context) (<-chan StoredEvent, func()) {
+	ch := make(chan StoredEvent, streamBuffer)
+
+	db.streamsMu.Lock()
+	db.streams[ch] = struct{}{}
+	db.streamsMu.Unlock()
+
+	var once sync.Once
+	closeStream := func() {
+		once.Do(func() {
+			db.streamsMu.Lock()
+			delete(db.streams, ch)
+			close(ch)
+			db.streamsMu.Unlock()
+		})
+	}
+
+	// The context callback only closes the stream, so it may run immediately if the context is already closed.
+	stopAfter := context.AfterFunc(ctx, closeStream)
+
+	return ch, func() {
+		stopAfter()
+		closeStream()
+	}
+}
+
+// publishStore sends a copy of the stored unsigned data set to all streams without blocking.
+// The set is cloned once and shared by all streams, so consumers must not modify it.
+func (db *MemDB) publishStore(ctx context.Context, duty core.Duty, unsignedSet core.UnsignedDataSet) {
+	db.streamsMu.Lock()
+	defer db.streamsMu.Unlock()
+
+	if len(db.streams) == 0 {
+		return
+	}
+
+	clone, err := unsignedSet.Clone()
+	if err != nil {
+		streamDroppedCounter.Add(float64(len(db.streams)))
+		log.Warn(ctx, "Failed to clone stored data for streams, dropping event", err,
+			z.Any("duty", duty), z.Int("streams", len(db.streams)))
+
+		return
+	}
+
+	for ch := range db.streams {
+		if len(ch) == cap(ch) {
+			streamDroppedCounter.Inc()
+			continue
+		}
+
+		// Only publishStore sends on the channel while holding the lock, so this doesn't block.
+		ch <- StoredEvent{Duty: duty, UnsignedSet: clone}
+	}
+}
+
+// ApplyStores stores the events received from another MemDB's stream, see StreamStores.
+// It blocks until the channel is closed or the context is closed. Events failing to store,
+// e.g. for duties already expired, are logged and skipped.
+func (db *MemDB) ApplyStores(ctx context.Context, events <-chan StoredEvent) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			if err := db.Store(ctx, event.Duty, event.UnsignedSet); err != nil {
+				log.Warn(ctx, "Failed to apply streamed duty", err, z.Any("duty", event.Duty))
+			}
+		}
+	}
+}
@@ -47,10 +47,14 @@ type DutyDB interface {
 	// Store stores the unsigned duty data set.
 	Store(context.Context, Duty, UnsignedDataSet) error
 
-	// AwaitProposal blocks and returns the proposed beacon block
+	// AwaitProposal blocks and returns the full (non-blinded) proposed beacon block
 	// for the slot when available.
 	AwaitProposal(ctx context.Context, slot uint64) (*eth2api.VersionedProposal, error)
 
+	// AwaitProposalAnyKind blocks and returns the proposed beacon block, blinded or not,
+	// for the slot when available.
+	AwaitProposalAnyKind(ctx context.Context, slot uint64) (*eth2api.VersionedProposal, error)
+
 	// AwaitAttestation blocks and returns the attestation data
 	// for the slot and committee index when available.
 	AwaitAttestation(ctx context.Context, slot, commIdx uint64) (*eth2p0.AttestationData, error)
@@ -69,6 +73,49 @@ type DutyDB interface {
 	AwaitSyncContribution(ctx context.Context, slot, subcommIdx uint64, beaconBlockRoot eth2p0.Root) (*altair.SyncCommitteeContribution, error)
 }
 
+// DutyDBReader provides read-only access to a DutyDB, it excludes storing data.
+type DutyDBReader interface {
+	// AwaitProposal blocks and returns the full (non-blinded) proposed beacon block
+	// for the slot when available.
+	AwaitProposal(ctx context.Context, slot uint64) (*eth2api.VersionedProposal, error)
+
+	// AwaitProposalBlinded blocks and returns the blinded proposed beacon block
+	// for the slot when available.
+	AwaitProposalBlinded(ctx context.Context, slot uint64) (*eth2api.VersionedProposal, error)
+
+	// AwaitProposalAnyKind blocks and returns the proposed beacon block, blinded or not,
+	// for the slot when available.
+	AwaitProposalAnyKind(ctx context.Context, slot uint64) (*eth2api.VersionedProposal, error)
+
+	// AwaitAttestation blocks and returns the attestation data
+	// for the slot and committee index when available.
+	AwaitAttestation(ctx context.Context, slot, commIdx uint64) (*eth2p0.AttestationData, error)
+
+	// MultiAwaitAttestation blocks and returns the attestation data
+	// for the slot and all committee indexes when available.
+	MultiAwaitAttestation(ctx context.Context, slot uint64, commIdxs []uint64) (map[uint64]*eth2p0.AttestationData, error)
+
+	// PubKeyByAttestation returns the validator PubKey for the provided attestation data
+	// slot, committee index and validator index.
+	PubKeyByAttestation(ctx context.Context, slot, commIdx, valIdx uint64) (PubKey, error)
+
+	// AwaitAggAttestation blocks and returns the aggregated attestation for the slot
+	// and attestation when available.
+	AwaitAggAttestation(ctx context.Context, slot uint64, attestationRoot eth2p0.Root) (*eth2spec.VersionedAttestation, error)
+
+	// AwaitAnyAggAttestation blocks and returns the single aggregated attestation
+	// for the slot when available.
+	AwaitAnyAggAttestation(ctx context.Context, slot uint64) (*eth2spec.VersionedAttestation, error)
+
+	// AwaitSyncContribution blocks and returns the sync committee contribution data for the slot and
+	// the subcommittee and the beacon block root when available.
+	AwaitSyncContribution(ctx context.Context, slot, subcommIdx uint64, beaconBlockRoot eth2p0.Root) (*altair.SyncCommitteeContribution, error)
+
+	// MultiAwaitSyncContribution blocks and returns the sync committee contribution data for the slot,
+	// all the subcommittees and the beacon block root when available.
+	MultiAwaitSyncContribution(ctx context.Context, slot uint64, subcommIdxs []uint64, beaconBlockRoot eth2p0.Root) (map[uint64]*altair.SyncCommitteeContribution, error)
+}
+
 // P2PProtocol defines an arbitrary libp2p protocol.
 type P2PProtocol interface {
 	// ProtocolID returns the protocol ID.
@@ -305,7 +352,7 @@ func Wire(sched Scheduler,
 		ConsensusSubscribe:                cons.Subscribe,
 		DutyDBStore:                       dutyDB.Store,
 		DutyDBAwaitAttestation:            dutyDB.AwaitAttestation,
-		DutyDBAwaitProposal:               dutyDB.AwaitProposal,
+		DutyDBAwaitProposal:               dutyDB.AwaitProposalAnyKind, // The validator API serves blinded and full proposals.
 		DutyDBPubKeyByAttestation:         dutyDB.PubKeyByAttestation,
 		DutyDBAwaitAggAttestation:         dutyDB.AwaitAggAttestation,
 		DutyDBAwaitSyncContribution:       dutyDB.AwaitSyncContribution,
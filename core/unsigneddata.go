@@ -22,6 +22,7 @@ import (
 	"github.com/obolnetwork/charon/app/errors"
 	"github.com/obolnetwork/charon/app/z"
 	"github.com/obolnetwork/charon/eth2util"
+	"github.com/obolnetwork/charon/eth2util/eth2exp"
 )
 
 var (
@@ -30,6 +31,7 @@ var (
 	_ UnsignedData = VersionedAggregatedAttestation{}
 	_ UnsignedData = VersionedProposal{}
 	_ UnsignedData = SyncContribution{}
+	_ UnsignedData = AggregatorSelection{}
 
 	// Some types also support SSZ marshalling and unmarshalling.
 	_ ssz.Marshaler   = AttestationData{}
@@ -570,6 +572,68 @@ func (s *SyncContribution) UnmarshalSSZ(b []byte) error {
 	return s.SyncCommitteeContribution.UnmarshalSSZ(b)
 }
 
+// NewAggregatorSelection returns a new AggregatorSelection.
+func NewAggregatorSelection(selection *eth2exp.BeaconCommitteeSelection, commIdx eth2p0.CommitteeIndex, isAggregator bool) AggregatorSelection {
+	return AggregatorSelection{
+		Selection:      *selection,
+		CommitteeIndex: commIdx,
+		IsAggregator:   isAggregator,
+	}
+}
+
+// AggregatorSelection wraps the aggregated beacon committee selection of a validator and adds its
+// committee index and whether the selection proof selects the validator as aggregator.
+type AggregatorSelection struct {
+	Selection      eth2exp.BeaconCommitteeSelection
+	CommitteeIndex eth2p0.CommitteeIndex
+	IsAggregator   bool
+}
+
+func (s AggregatorSelection) Clone() (UnsignedData, error) {
+	var resp AggregatorSelection
+	err := cloneJSONMarshaler(s, &resp)
+	if err != nil {
+		return nil, errors.Wrap(err, "clone aggregator selection")
+	}
+
+	return resp, nil
+}
+
+func (s AggregatorSelection) MarshalJSON() ([]byte, error) {
+	resp, err := json.Marshal(aggregatorSelectionJSON{
+		Selection:      &s.Selection,
+		CommitteeIndex: s.CommitteeIndex,
+		IsAggregator:   s.IsAggregator,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal aggregator selection")
+	}
+
+	return resp, nil
+}
+
+func (s *AggregatorSelection) UnmarshalJSON(input []byte) error {
+	var sel aggregatorSelectionJSON
+	if err := json.Unmarshal(input, &sel); err != nil {
+		return errors.Wrap(err, "unmarshal aggregator selection")
+	}
+	if sel.Selection == nil {
+		return errors.New("missing beacon committee selection")
+	}
+
+	s.Selection = *sel.Selection
+	s.CommitteeIndex = sel.CommitteeIndex
+	s.IsAggregator = sel.IsAggregator
+
+	return nil
+}
+
+type aggregatorSelectionJSON struct {
+	Selection      *eth2exp.BeaconCommitteeSelection `json:"selection"`
+	CommitteeIndex eth2p0.CommitteeIndex             `json:"committee_index"`
+	IsAggregator   bool                              `json:"is_aggregator"`
+}
+
 // unmarshalUnsignedData returns an instantiated unsigned data based on the duty type.
 func unmarshalUnsignedData(typ DutyType, data []byte) (UnsignedData, error) {
 	switch typ {
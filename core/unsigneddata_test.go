@@ -49,6 +49,10 @@ func TestUnsignedDataClone(t *testing.T) {
 			name: "sync contribution",
 			data: testutil.RandomCoreSyncContribution(),
 		},
+		{
+			name: "aggregator selection",
+			data: testutil.RandomCoreAggregatorSelection(),
+		},
 	}
 
 	for _, test := range tests {
@@ -64,9 +64,7 @@ func SlotFromTimestamp(ctx context.Context, client eth2wrap.Client, timestamp ti
 		timestamp = nextTimestamp
 	}
 
-	delta := timestamp.Sub(genesisTime)
-
-	return eth2p0.Slot(delta / slotDuration), nil
+	return eth2p0.Slot(eth2wrap.SlotAt(genesisTime, slotDuration, timestamp)), nil
 }
 
 // NewComponentInsecure returns a new instance of the validator API core workflow component
@@ -854,6 +854,10 @@ func RandomCoreBeaconCommitteeSelection() core.BeaconCommitteeSelection {
 	return core.NewBeaconCommitteeSelection(RandomBeaconCommitteeSelection())
 }
 
+func RandomCoreAggregatorSelection() core.AggregatorSelection {
+	return core.NewAggregatorSelection(RandomBeaconCommitteeSelection(), RandomCommIdx(), rand.Intn(2) == 0)
+}
+
 func RandomCoreSyncCommitteeSelection() core.SyncCommitteeSelection {
 	return core.NewSyncCommitteeSelection(RandomSyncCommitteeSelection())
 }